@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AdlistType defines whether the domains of an adlist are blocked or allowed
+// +kubebuilder:validation:Enum=Block;Allow
+type AdlistType string
+
+const (
+	// AdlistTypeBlock blocks the domains on the list
+	AdlistTypeBlock AdlistType = "Block"
+	// AdlistTypeAllow allows the domains on the list, even if they are blocked by another list
+	AdlistTypeAllow AdlistType = "Allow"
+)
+
+// AdlistSpec defines the desired state of Adlist
+type AdlistSpec struct {
+	// InstanceRef references the Pi-hole the adlist is subscribed to
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="instanceRef is immutable"
+	InstanceRef InstanceReference `json:"instanceRef"`
+
+	// URL is the address the adlist is downloaded from
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +kubebuilder:validation:MaxLength=2048
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="url is immutable"
+	URL string `json:"url"`
+
+	// Type defines whether the domains on the adlist are blocked or allowed
+	// +kubebuilder:default=Block
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="type is immutable"
+	Type AdlistType `json:"type,omitempty"`
+
+	// Enabled is false if the adlist is kept but not used
+	// +kubebuilder:default=true
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Comment describes the adlist in the Pi-hole UI
+	Comment string `json:"comment,omitempty"`
+
+	// Groups are the names of the Pi-hole groups the adlist applies to, it applies to the
+	// Default group if none are given
+	// +listType=set
+	Groups []string `json:"groups,omitempty"`
+
+	// AdoptionPolicy defines whether an adlist with the same URL and type that hasn't been
+	// created by the operator, e.g. because it was added in the Pi-hole UI, is taken over.
+	// Adopted adlists are removed from the Pi-hole together with the Adlist.
+	// +kubebuilder:default=Never
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
+}
+
+// AdlistStatus defines the observed state of Adlist
+type AdlistStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ObservedGeneration is the generation of the Adlist the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ID is the id of the adlist on the Pi-hole, it is only set once the adlist has been
+	// created or adopted by the operator
+	ID int32 `json:"id,omitempty"`
+
+	// Domains is the number of domains on the adlist as of the last gravity update
+	Domains int32 `json:"domains,omitempty"`
+
+	// LastGravityUpdate is the last time the adlist was downloaded by a gravity update
+	LastGravityUpdate *metav1.Time `json:"lastGravityUpdate,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Domains",type=integer,JSONPath=`.status.domains`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// Adlist is the Schema for the adlists API
+type Adlist struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AdlistSpec   `json:"spec,omitempty"`
+	Status AdlistStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AdlistList contains a list of Adlist
+type AdlistList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Adlist `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Adlist{}, &AdlistList{})
+}
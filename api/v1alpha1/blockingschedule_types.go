@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BlockingState is the blocking state a BlockingSchedule enforces while it is active
+// +kubebuilder:validation:Enum=Disabled;Enabled
+type BlockingState string
+
+const (
+	// BlockingDisabled pauses blocking, all queries are answered
+	BlockingDisabled BlockingState = "Disabled"
+	// BlockingEnabled keeps blocking enabled, e.g. to override another schedule
+	BlockingEnabled BlockingState = "Enabled"
+)
+
+// BlockingWindow is a recurring period a BlockingSchedule is active
+type BlockingWindow struct {
+	// Schedule is a cron schedule the window starts on, e.g. "0 9 * * mon-fri"
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Duration is the length of the window
+	Duration metav1.Duration `json:"duration"`
+}
+
+// BlockingScheduleSpec defines the desired state of BlockingSchedule
+// +kubebuilder:validation:XValidation:rule="has(self.activeFor) || (has(self.windows) && size(self.windows) > 0)",message="activeFor or windows must be set"
+type BlockingScheduleSpec struct {
+	// InstanceRef references the Pi-hole whose blocking is scheduled
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="instanceRef is immutable"
+	InstanceRef InstanceReference `json:"instanceRef"`
+
+	// Blocking is the blocking state enforced while the schedule is active
+	// +kubebuilder:default=Disabled
+	Blocking BlockingState `json:"blocking,omitempty"`
+
+	// ActiveFor makes the schedule active once, for this long after the BlockingSchedule
+	// has been created, e.g. to pause blocking for 10 minutes
+	ActiveFor *metav1.Duration `json:"activeFor,omitempty"`
+
+	// Windows are the recurring periods the schedule is active
+	Windows []BlockingWindow `json:"windows,omitempty"`
+
+	// TimeZone is the time zone of the schedules of the windows, e.g. Europe/Berlin.
+	// It defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Priority decides between active BlockingSchedules of the same Pi-hole, the one
+	// with the highest priority is enforced. If their priorities are equal, Enabled
+	// takes precedence over Disabled.
+	Priority int32 `json:"priority,omitempty"`
+}
+
+// BlockingScheduleStatus defines the observed state of BlockingSchedule
+type BlockingScheduleStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ObservedGeneration is the generation of the BlockingSchedule the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Active is true while the schedule is in one of its windows
+	Active bool `json:"active,omitempty"`
+
+	// ActiveUntil is the end of the current window
+	ActiveUntil *metav1.Time `json:"activeUntil,omitempty"`
+
+	// NextWindow is the start of the next window
+	NextWindow *metav1.Time `json:"nextWindow,omitempty"`
+
+	// Blocking is the blocking state reported by the Pi-hole: enabled, disabled, failed or unknown
+	Blocking string `json:"blocking,omitempty"`
+
+	// Timer is the time left until the Pi-hole reverts its blocking state on its own,
+	// as reported together with Blocking
+	Timer *metav1.Duration `json:"timer,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Enforces",type=string,JSONPath=`.spec.blocking`
+// +kubebuilder:printcolumn:name="Active",type=boolean,JSONPath=`.status.active`
+// +kubebuilder:printcolumn:name="Blocking",type=string,JSONPath=`.status.blocking`
+// +kubebuilder:printcolumn:name="Effective",type=string,JSONPath=`.status.conditions[?(@.type=="Effective")].reason`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BlockingSchedule pauses or enforces blocking on a Pi-hole once or on a timetable
+type BlockingSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BlockingScheduleSpec   `json:"spec,omitempty"`
+	Status BlockingScheduleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// BlockingScheduleList contains a list of BlockingSchedule
+type BlockingScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BlockingSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BlockingSchedule{}, &BlockingScheduleList{})
+}
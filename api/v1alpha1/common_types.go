@@ -1,6 +1,7 @@
 package v1alpha1
 
 // +kubebuilder:validation:Pattern="((^((([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])\\.){3}([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5]))$)|(^(([0-9a-fA-F]{1,4}:){7,7}[0-9a-fA-F]{1,4}|([0-9a-fA-F]{1,4}:){1,7}:|([0-9a-fA-F]{1,4}:){1,6}:[0-9a-fA-F]{1,4}|([0-9a-fA-F]{1,4}:){1,5}(:[0-9a-fA-F]{1,4}){1,2}|([0-9a-fA-F]{1,4}:){1,4}(:[0-9a-fA-F]{1,4}){1,3}|([0-9a-fA-F]{1,4}:){1,3}(:[0-9a-fA-F]{1,4}){1,4}|([0-9a-fA-F]{1,4}:){1,2}(:[0-9a-fA-F]{1,4}){1,5}|[0-9a-fA-F]{1,4}:((:[0-9a-fA-F]{1,4}){1,6})|:((:[0-9a-fA-F]{1,4}){1,7}|:))$))"
+// +kubebuilder:validation:MaxLength=45
 // IPAddress is used for validation of an IP address.
 type IPAddressStr string
 
@@ -13,4 +14,23 @@ type DNSRecordType string
 const (
 	CName DNSRecordType = "CNAME"
 	A     DNSRecordType = "A"
+	AAAA  DNSRecordType = "AAAA"
 )
+
+type InstanceKind string
+
+const (
+	PiHoleInstanceKind        InstanceKind = "PiHoleInstance"
+	ClusterPiHoleInstanceKind InstanceKind = "ClusterPiHoleInstance"
+)
+
+// InstanceReference references a PiHoleInstance in the same namespace or a ClusterPiHoleInstance.
+type InstanceReference struct {
+	// Kind is the kind of the referenced instance
+	// +kubebuilder:validation:Enum=PiHoleInstance;ClusterPiHoleInstance
+	// +kubebuilder:default=PiHoleInstance
+	Kind InstanceKind `json:"kind,omitempty"`
+
+	// Name is the name of the referenced instance
+	Name string `json:"name"`
+}
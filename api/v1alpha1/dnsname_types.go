@@ -20,15 +20,42 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// AdoptionPolicy defines how entries that exist on a Pi-hole but weren't created by the operator, e.g. records,
+// adlists or domain rules, are treated
+// +kubebuilder:validation:Enum=Never;Adopt
+type AdoptionPolicy string
+
+const (
+	// AdoptionPolicyNever leaves existing entries untouched, the resource gets a Conflict condition if one is in the way
+	AdoptionPolicyNever AdoptionPolicy = "Never"
+	// AdoptionPolicyAdopt takes over existing entries, they are updated and deleted with the resource
+	AdoptionPolicyAdopt AdoptionPolicy = "Adopt"
+)
+
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 // DNSNameSpec defines the desired state of DNSName
+// +kubebuilder:validation:XValidation:rule="has(self.instanceRef) || has(self.instanceRefs) || has(self.instanceSelector)",message="one of instanceRef, instanceRefs or instanceSelector is required"
+// +kubebuilder:validation:XValidation:rule="self.type != 'A' || !has(self.targetIP) || !self.targetIP.contains(':')",message="targetIP must be an IPv4 address for A records"
+// +kubebuilder:validation:XValidation:rule="self.type != 'AAAA' || !has(self.targetIP) || self.targetIP.contains(':')",message="targetIP must be an IPv6 address for AAAA records"
+// +kubebuilder:validation:XValidation:rule="self.type != 'AAAA' || !has(self.targetIPs) || self.targetIPs.all(ip, ip.contains(':'))",message="targetIPs must only contain IPv6 addresses for AAAA records"
+// +kubebuilder:validation:XValidation:rule="self.type == 'CNAME' || has(self.targetIP) || has(self.targetIPs)",message="targetIP or targetIPs is required for A and AAAA records"
 type DNSNameSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
+	// InstanceRef references the Pi-hole the DNSName is managed in
+	InstanceRef *InstanceReference `json:"instanceRef,omitempty"`
+
+	// InstanceRefs references all Pi-holes the DNSName is replicated to
+	InstanceRefs []InstanceReference `json:"instanceRefs,omitempty"`
+
+	// InstanceSelector selects PiHoleInstances in the namespace of the DNSName and
+	// ClusterPiHoleInstances by their labels, the DNSName is replicated to all of them
+	InstanceSelector *metav1.LabelSelector `json:"instanceSelector,omitempty"`
+
 	// Type is the type of the DNSName
-	// +kubebuilder:validation:Enum=CNAME;A
+	// +kubebuilder:validation:Enum=CNAME;A;AAAA
 	Type DNSRecordType `json:"type"`
 
 	// Domain is the source domain of the DNSName
@@ -38,21 +65,78 @@ type DNSNameSpec struct {
 	// Target is the target of a CNAME record
 	Target *Hostname `json:"target,omitempty"`
 
-	// IP is the IPv4 or IPv6 of the type A DNSName (only applies to A records)
+	// IP is the IPv4 of an A record or the IPv6 of an AAAA record
 	TargetIP *IPAddressStr `json:"targetIP,omitempty"`
 
+	// TargetIPs are the addresses of a host with several addresses, e.g. for round-robin
+	// or dual-stack. IPv6 addresses of an A record are published as AAAA records, AAAA
+	// records only accept IPv6 addresses.
+	// +listType=set
+	// +kubebuilder:validation:MaxItems=64
+	TargetIPs []IPAddressStr `json:"targetIPs,omitempty"`
+
 	// TTL is the TTL of the DNSName (only applies to CNAME records)
 	// +kubebuilder:validation:Minimum=0
 	TTL *int32 `json:"ttl,omitempty"`
+
+	// AdoptionPolicy defines whether records of the domain that haven't been created by the
+	// operator, e.g. because they were added in the Pi-hole UI, are taken over
+	// +kubebuilder:default=Never
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
+}
+
+// RecordStatus is a DNS record as reported by a Pi-hole
+type RecordStatus struct {
+	// Type is the type of the record
+	Type DNSRecordType `json:"type"`
+
+	// Target is the address of an A or AAAA record or the target of a CNAME record
+	Target string `json:"target"`
+
+	// TTL is the TTL of a CNAME record
+	TTL *int32 `json:"ttl,omitempty"`
+}
+
+// InstanceStatus is the state of a DNSName on a single Pi-hole
+type InstanceStatus struct {
+	InstanceReference `json:",inline"`
+
+	// Synced is true if the record is up to date on the instance
+	Synced bool `json:"synced"`
+
+	// Error is the error that occurred during the last sync with the instance
+	Error string `json:"error,omitempty"`
+
+	// Addresses are the addresses of the DNSName currently present on the instance
+	Addresses []IPAddressStr `json:"addresses,omitempty"`
+
+	// Records are all records of the domain as seen on the instance during the last sync,
+	// including records that are not managed by the DNSName
+	Records []RecordStatus `json:"records,omitempty"`
 }
 
 // DNSNameStatus defines the observed state of DNSName
 type DNSNameStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ObservedGeneration is the generation of the DNSName the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncedTime is the last time the DNSName was synced to all of its instances successfully
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+
+	// Instances holds the state of the DNSName on each Pi-hole it is managed in
+	Instances []InstanceStatus `json:"instances,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:selectablefield:JSONPath=`.spec.domain`
+// +kubebuilder:printcolumn:name="Domain",type=string,JSONPath=`.spec.domain`
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // DNSName is the Schema for the dnsnames API
 type DNSName struct {
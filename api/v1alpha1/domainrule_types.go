@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DomainRuleKind defines whether the domains matched by a rule are allowed or denied
+// +kubebuilder:validation:Enum=Allow;Deny
+type DomainRuleKind string
+
+const (
+	// DomainRuleAllow allows the matched domains, even if they are on a block list
+	DomainRuleAllow DomainRuleKind = "Allow"
+	// DomainRuleDeny blocks the matched domains
+	DomainRuleDeny DomainRuleKind = "Deny"
+)
+
+// DomainRuleMatch defines how a rule matches domains
+// +kubebuilder:validation:Enum=Exact;Regex
+type DomainRuleMatch string
+
+const (
+	// DomainRuleMatchExact matches the domain only
+	DomainRuleMatchExact DomainRuleMatch = "Exact"
+	// DomainRuleMatchRegex matches all domains matching a regular expression
+	DomainRuleMatchRegex DomainRuleMatch = "Regex"
+)
+
+// DomainRuleSpec defines the desired state of DomainRule
+type DomainRuleSpec struct {
+	// InstanceRef references the Pi-hole the rule is managed in
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="instanceRef is immutable"
+	InstanceRef InstanceReference `json:"instanceRef"`
+
+	// Kind defines whether the matched domains are allowed or denied
+	Kind DomainRuleKind `json:"kind"`
+
+	// Match defines whether the domain is matched exactly or is a regular expression
+	// +kubebuilder:default=Exact
+	Match DomainRuleMatch `json:"match,omitempty"`
+
+	// Domain is the domain or, for regex rules, the regular expression matching domains,
+	// including Pi-hole options like ;querytype=A
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=1024
+	Domain string `json:"domain"`
+
+	// Enabled is false if the rule is kept but not used
+	// +kubebuilder:default=true
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Comment describes the rule in the Pi-hole UI
+	Comment string `json:"comment,omitempty"`
+
+	// Groups are the names of the Pi-hole groups the rule applies to, it applies to the
+	// Default group if none are given
+	// +listType=set
+	Groups []string `json:"groups,omitempty"`
+
+	// AdoptionPolicy defines whether an entry with the same domain, kind and match that
+	// hasn't been created by the operator, e.g. because it was added in the Pi-hole UI, is
+	// taken over. Adopted entries are removed from the Pi-hole together with the DomainRule.
+	// +kubebuilder:default=Never
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
+}
+
+// AppliedDomainRule identifies the entry of a rule on the Pi-hole
+type AppliedDomainRule struct {
+	// Kind is the kind of the entry
+	Kind DomainRuleKind `json:"kind"`
+
+	// Match is the match of the entry
+	Match DomainRuleMatch `json:"match"`
+
+	// Domain is the domain or regular expression of the entry
+	Domain string `json:"domain"`
+}
+
+// DomainRuleStatus defines the observed state of DomainRule
+type DomainRuleStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ObservedGeneration is the generation of the DomainRule the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ID is the id of the entry on the Pi-hole
+	ID int32 `json:"id,omitempty"`
+
+	// Applied is the entry created or adopted by the rule on the Pi-hole, it is replaced
+	// once the kind, match or domain of the rule change
+	Applied *AppliedDomainRule `json:"applied,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.kind`
+// +kubebuilder:printcolumn:name="Match",type=string,JSONPath=`.spec.match`
+// +kubebuilder:printcolumn:name="Domain",type=string,JSONPath=`.spec.domain`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// DomainRule is the Schema for the domainrules API
+type DomainRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DomainRuleSpec   `json:"spec,omitempty"`
+	Status DomainRuleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DomainRuleList contains a list of DomainRule
+type DomainRuleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DomainRule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DomainRule{}, &DomainRuleList{})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GravityUpdatePhase is the phase of a gravity update
+// +kubebuilder:validation:Enum=Running;Succeeded;Failed
+type GravityUpdatePhase string
+
+const (
+	// GravityUpdateRunning means the Pi-hole is downloading the adlists and rebuilding gravity
+	GravityUpdateRunning GravityUpdatePhase = "Running"
+	// GravityUpdateSucceeded means gravity has been rebuilt without errors
+	GravityUpdateSucceeded GravityUpdatePhase = "Succeeded"
+	// GravityUpdateFailed means the update couldn't be started or gravity reported errors
+	GravityUpdateFailed GravityUpdatePhase = "Failed"
+)
+
+// GravityRun is the state of a single gravity update
+type GravityRun struct {
+	// Phase is the phase of the update
+	Phase GravityUpdatePhase `json:"phase,omitempty"`
+
+	// StartTime is the time the update was started
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the update succeeded or failed
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Output contains the last lines of the output of gravity, it is updated while the update runs
+	Output string `json:"output,omitempty"`
+}
+
+// GravityUpdateSpec defines the desired state of GravityUpdate
+type GravityUpdateSpec struct {
+	// InstanceRef references the Pi-hole whose gravity is updated
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="instanceRef is immutable"
+	InstanceRef InstanceReference `json:"instanceRef"`
+}
+
+// GravityUpdateStatus defines the observed state of GravityUpdate
+type GravityUpdateStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	GravityRun `json:",inline"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Instance",type=string,JSONPath=`.spec.instanceRef.name`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Started",type=date,JSONPath=`.status.startTime`
+// +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completionTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// GravityUpdate updates gravity on a Pi-hole once, like running pihole -g
+type GravityUpdate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GravityUpdateSpec   `json:"spec,omitempty"`
+	Status GravityUpdateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GravityUpdateList contains a list of GravityUpdate
+type GravityUpdateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GravityUpdate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GravityUpdate{}, &GravityUpdateList{})
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PiHoleClientSpec defines the desired state of PiHoleClient
+type PiHoleClientSpec struct {
+	// InstanceRef references the Pi-hole the client is managed in
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="instanceRef is immutable"
+	InstanceRef InstanceReference `json:"instanceRef"`
+
+	// Client identifies the devices the queries of the client come from by an IP address,
+	// a subnet in CIDR notation like 192.168.178.0/24, a MAC address or a hostname
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="client is immutable"
+	Client string `json:"client"`
+
+	// Comment describes the client in the Pi-hole UI
+	Comment string `json:"comment,omitempty"`
+
+	// Groups are the names of the Pi-hole groups the client is assigned to, it is assigned
+	// to the Default group if none are given
+	// +listType=set
+	Groups []string `json:"groups,omitempty"`
+
+	// AdoptionPolicy defines whether a client with the same address or name that hasn't
+	// been created by the operator, e.g. because it was added in the Pi-hole UI, is taken
+	// over. Adopted clients are removed from the Pi-hole together with the PiHoleClient.
+	// +kubebuilder:default=Never
+	AdoptionPolicy AdoptionPolicy `json:"adoptionPolicy,omitempty"`
+}
+
+// PiHoleClientStatus defines the observed state of PiHoleClient
+type PiHoleClientStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ObservedGeneration is the generation of the PiHoleClient the status was computed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ID is the id of the client created or adopted by the PiHoleClient on the Pi-hole
+	ID int32 `json:"id,omitempty"`
+
+	// Hostname is the name the Pi-hole resolved for the client, if any
+	Hostname string `json:"hostname,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Client",type=string,JSONPath=`.spec.client`
+// +kubebuilder:printcolumn:name="Groups",type=string,JSONPath=`.spec.groups`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PiHoleClient is the Schema for the piholeclients API
+type PiHoleClient struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PiHoleClientSpec   `json:"spec,omitempty"`
+	Status PiHoleClientStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PiHoleClientList contains a list of PiHoleClient
+type PiHoleClientList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PiHoleClient `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PiHoleClient{}, &PiHoleClientList{})
+}
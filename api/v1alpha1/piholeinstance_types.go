@@ -0,0 +1,179 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretKeyReference references a single key of a Secret.
+type SecretKeyReference struct {
+	// Name is the name of the Secret
+	Name string `json:"name"`
+
+	// Key is the key within the Secret
+	// +kubebuilder:default=password
+	Key string `json:"key,omitempty"`
+
+	// Namespace is the namespace of the Secret. It is required for ClusterPiHoleInstances
+	// and ignored for PiHoleInstances, which always read Secrets from their own namespace.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// TLSConfig configures how the connection to the Pi-hole API is secured.
+type TLSConfig struct {
+	// InsecureSkipVerify disables verification of the Pi-hole's certificate
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// CASecretRef references a PEM encoded CA bundle used to verify the Pi-hole's certificate
+	CASecretRef *SecretKeyReference `json:"caSecretRef,omitempty"`
+
+	// ServerName overrides the server name used to verify the Pi-hole's certificate
+	ServerName string `json:"serverName,omitempty"`
+}
+
+// RetryPolicy configures how requests failing because the Pi-hole is unavailable
+// are retried. Fields that are not set default to the policy configured for the operator.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a request, 1 disables retries
+	// +kubebuilder:validation:Minimum=1
+	MaxAttempts *int32 `json:"maxAttempts,omitempty"`
+
+	// InitialBackoff is the delay before the first retry, it doubles with every further retry
+	InitialBackoff *metav1.Duration `json:"initialBackoff,omitempty"`
+
+	// MaxBackoff is the maximum delay between two attempts
+	MaxBackoff *metav1.Duration `json:"maxBackoff,omitempty"`
+
+	// BudgetPercent limits the retries to this share of all requests, 0 doesn't limit them
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	BudgetPercent *int32 `json:"budgetPercent,omitempty"`
+}
+
+// GravityConfig configures when gravity is updated on a Pi-hole
+type GravityConfig struct {
+	// Schedule is a cron schedule gravity is updated on, e.g. "0 3 * * 0" for Sundays
+	// at 3 am. The macros @hourly, @daily, @weekly, @monthly and @yearly are supported.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule,omitempty"`
+
+	// TimeZone is the time zone of the schedule, e.g. Europe/Berlin. It defaults to UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// UpdateOnAdlistChange updates gravity after Adlists of the Pi-hole have been
+	// created, changed or deleted. Changes made in short succession are applied by
+	// a single update.
+	UpdateOnAdlistChange bool `json:"updateOnAdlistChange,omitempty"`
+}
+
+// PiHoleInstanceSpec defines the connection details of a Pi-hole
+type PiHoleInstanceSpec struct {
+	// URL is the URL of the Pi-hole, e.g. http://pi.hole. The path of the API
+	// (/api for v6 or /admin/api.php for v5) may be included.
+	// +kubebuilder:validation:Pattern="^https?://"
+	URL string `json:"url"`
+
+	// Version is the API version of the Pi-hole, it is detected automatically if not set
+	// +kubebuilder:validation:Enum=v5;v6
+	Version string `json:"version,omitempty"`
+
+	// AppPasswordSecretRef references the app password used to authenticate against the Pi-hole API.
+	// For Pi-hole v5 it references the API token instead.
+	AppPasswordSecretRef SecretKeyReference `json:"appPasswordSecretRef"`
+
+	// TOTPSecretRef references the base32 encoded secret used to generate codes for
+	// two-factor authentication. It is only needed if 2FA is enabled on the Pi-hole.
+	// As the key defaults to password, it usually needs to be set.
+	TOTPSecretRef *SecretKeyReference `json:"totpSecretRef,omitempty"`
+
+	// TLS configures the TLS connection to the Pi-hole API
+	TLS *TLSConfig `json:"tls,omitempty"`
+
+	// Timeout is the timeout of a single request against the Pi-hole API,
+	// it defaults to the timeout configured for the operator
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Retry configures how failed requests against the Pi-hole API are retried,
+	// it defaults to the policy configured for the operator
+	Retry *RetryPolicy `json:"retry,omitempty"`
+
+	// Gravity configures scheduled gravity updates and updates after adlist changes
+	Gravity *GravityConfig `json:"gravity,omitempty"`
+}
+
+// PiHoleInstanceStatus defines the observed state of a Pi-hole instance
+type PiHoleInstanceStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// Gravity is the last gravity update of the Pi-hole, whether it was scheduled,
+	// caused by an adlist change or requested by a GravityUpdate
+	Gravity *GravityRun `json:"gravity,omitempty"`
+
+	// NextGravityUpdate is the next time gravity is updated according to the schedule
+	NextGravityUpdate *metav1.Time `json:"nextGravityUpdate,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+
+// PiHoleInstance is the Schema for the piholeinstances API
+type PiHoleInstance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PiHoleInstanceSpec   `json:"spec,omitempty"`
+	Status PiHoleInstanceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PiHoleInstanceList contains a list of PiHoleInstance
+type PiHoleInstanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PiHoleInstance `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+
+// ClusterPiHoleInstance is the Schema for the clusterpiholeinstances API
+type ClusterPiHoleInstance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PiHoleInstanceSpec   `json:"spec,omitempty"`
+	Status PiHoleInstanceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterPiHoleInstanceList contains a list of ClusterPiHoleInstance
+type ClusterPiHoleInstanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterPiHoleInstance `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PiHoleInstance{}, &PiHoleInstanceList{})
+	SchemeBuilder.Register(&ClusterPiHoleInstance{}, &ClusterPiHoleInstanceList{})
+}
@@ -17,9 +17,11 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -38,6 +40,7 @@ import (
 	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
 	"github.com/domnikl/pihole-operator/internal/controller"
 	"github.com/domnikl/pihole-operator/internal/pihole"
+	webhooknetworkingv1alpha1 "github.com/domnikl/pihole-operator/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -59,6 +62,14 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var piHoleTimeout time.Duration
+	var maxConcurrentReconciles int
+	var ownershipNamespace string
+	var resyncInterval time.Duration
+	var recordCacheMaxAge time.Duration
+	var batchWindow time.Duration
+	var gravityDebounce time.Duration
+	retry := pihole.DefaultRetryPolicy
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -70,6 +81,32 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&piHoleTimeout, "pihole-timeout", pihole.DefaultTimeout,
+		"The timeout of a single request against a Pi-hole API, can be overridden per instance.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The number of DNSNames reconciled in parallel.")
+	flag.StringVar(&ownershipNamespace, "ownership-namespace", os.Getenv("POD_NAMESPACE"),
+		"The namespace the ownership of records is stored in, defaults to the namespace of the operator.")
+	flag.DurationVar(&resyncInterval, "resync-interval", 5*time.Minute,
+		"The interval in which the records, domain rules, groups and clients on the Pi-holes are checked for manual "+
+			"changes and adlists are refreshed, 0 disables the check.")
+	flag.DurationVar(&recordCacheMaxAge, "record-cache-max-age", time.Minute,
+		"The time the records of a Pi-hole are cached between two reconciliations, 0 disables the cache.")
+	flag.DurationVar(&batchWindow, "batch-window", 0,
+		"The time changes to a Pi-hole are collected to be applied in a single write, 0 applies each change on its own. "+
+			"Only DNSNames reconciled in parallel are batched, see --max-concurrent-reconciles.")
+	flag.DurationVar(&gravityDebounce, "gravity-debounce", time.Minute,
+		"The time waited after the last change of the adlists of a Pi-hole before gravity is updated, "+
+			"for instances that update gravity on adlist changes.")
+	flag.IntVar(&retry.MaxAttempts, "retry-max-attempts", retry.MaxAttempts,
+		"The maximum number of attempts of a request against an unavailable Pi-hole, 1 disables retries. "+
+			"The retry flags can be overridden per instance.")
+	flag.DurationVar(&retry.InitialBackoff, "retry-initial-backoff", retry.InitialBackoff,
+		"The delay before the first retry of a request, it doubles with every further retry.")
+	flag.DurationVar(&retry.MaxBackoff, "retry-max-backoff", retry.MaxBackoff,
+		"The maximum delay between two attempts of a request.")
+	flag.IntVar(&retry.BudgetPercent, "retry-budget-percent", retry.BudgetPercent,
+		"The share of requests to a Pi-hole that may be retried in percent, 0 doesn't limit retries.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -139,17 +176,128 @@ func main() {
 		os.Exit(1)
 	}
 
-	piHole := pihole.NewPiHole(os.Getenv("PIHOLE_API_URL"), os.Getenv("PIHOLE_APP_PASSWORD"))
+	piHoles := &controller.PiHoleClients{
+		Client:       mgr.GetClient(),
+		SecretReader: mgr.GetAPIReader(),
+		Timeout:      piHoleTimeout,
+		CacheMaxAge:  recordCacheMaxAge,
+		BatchWindow:  batchWindow,
+		Retry:        retry,
+	}
+
+	if ownershipNamespace == "" {
+		setupLog.Error(nil, "--ownership-namespace or POD_NAMESPACE must be set")
+		os.Exit(1)
+	}
+
+	registry := &controller.OwnershipRegistry{
+		Client:    mgr.GetClient(),
+		Reader:    mgr.GetAPIReader(),
+		Namespace: ownershipNamespace,
+	}
+
+	gravity := controller.NewGravityScheduler(mgr.GetClient(), mgr.GetEventRecorderFor("gravity-scheduler"), piHoles, gravityDebounce)
+	if err := mgr.Add(gravity); err != nil {
+		setupLog.Error(err, "unable to set up gravity scheduler")
+		os.Exit(1)
+	}
 
 	if err = (&controller.DNSNameReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),
 		Recorder: mgr.GetEventRecorderFor("dnsname-controller"),
-		PiHole:   piHole,
+		PiHoles:  piHoles,
+		Registry: registry,
+
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		ResyncInterval:          resyncInterval,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DNSName")
 		os.Exit(1)
 	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhooknetworkingv1alpha1.SetupDNSNameWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "DNSName")
+			os.Exit(1)
+		}
+	}
+	if err = (&controller.AdlistReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("adlist-controller"),
+		PiHoles:  piHoles,
+		Registry: registry,
+		Gravity:  gravity,
+
+		ResyncInterval: resyncInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Adlist")
+		os.Exit(1)
+	}
+	if err = (&controller.DomainRuleReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("domainrule-controller"),
+		PiHoles:  piHoles,
+		Registry: registry,
+
+		ResyncInterval: resyncInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DomainRule")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhooknetworkingv1alpha1.SetupDomainRuleWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "DomainRule")
+			os.Exit(1)
+		}
+	}
+	if err = (&controller.GroupReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("group-controller"),
+		PiHoles:  piHoles,
+		Registry: registry,
+
+		ResyncInterval: resyncInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Group")
+		os.Exit(1)
+	}
+	if err = (&controller.PiHoleClientReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("piholeclient-controller"),
+		PiHoles:  piHoles,
+		Registry: registry,
+
+		ResyncInterval: resyncInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PiHoleClient")
+		os.Exit(1)
+	}
+	if err = (&controller.GravityUpdateReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("gravityupdate-controller"),
+		Gravity:  gravity,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "GravityUpdate")
+		os.Exit(1)
+	}
+	if err = (&controller.BlockingScheduleReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("blockingschedule-controller"),
+		PiHoles:  piHoles,
+
+		ResyncInterval: resyncInterval,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "BlockingSchedule")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -167,5 +315,10 @@ func main() {
 		os.Exit(1)
 	}
 
-	defer piHole.Close()
+	closeCtx, cancel := context.WithTimeout(context.Background(), piHoleTimeout)
+	defer cancel()
+
+	if err := piHoles.Close(closeCtx); err != nil {
+		setupLog.Error(err, "problem closing Pi-hole sessions")
+	}
 }
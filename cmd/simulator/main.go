@@ -0,0 +1,67 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The simulator serves a local stand-in for the Pi-hole v6 API, it is used by the e2e tests.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+func main() {
+	var bindAddress string
+	var sessionValidity time.Duration
+	var legacy bool
+	flag.StringVar(&bindAddress, "bind-address", ":8080", "The address the simulated Pi-hole API binds to.")
+	flag.DurationVar(&sessionValidity, "session-validity", simulator.DefaultSessionValidity,
+		"The time a session stays valid without being used.")
+	flag.BoolVar(&legacy, "legacy", false,
+		"Serve the Pi-hole v5 API instead, PIHOLE_APP_PASSWORD is used as its API token.")
+	flag.Parse()
+
+	password := os.Getenv("PIHOLE_APP_PASSWORD")
+	if password == "" {
+		log.Fatal("PIHOLE_APP_PASSWORD must be set")
+	}
+
+	var handler http.Handler
+	if legacy {
+		handler = simulator.NewLegacy(password)
+	} else {
+		s := simulator.New(password)
+		s.SessionValidity = sessionValidity
+		s.TOTPSecret = os.Getenv("PIHOLE_TOTP_SECRET")
+		handler = s
+	}
+
+	log.Printf("serving simulated Pi-hole API on %s", bindAddress)
+
+	server := &http.Server{
+		Addr:              bindAddress,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	kerrors "errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const adlistFinalizerName = "adlist.networking.liebler.dev/finalizer"
+
+// AdlistReconciler reconciles an Adlist object
+type AdlistReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	PiHoles  *PiHoleClients
+	// Registry records which Adlist created or adopted which adlist on a Pi-hole
+	Registry *OwnershipRegistry
+	// Gravity is told about created, changed and deleted adlists, so it can update
+	// gravity if the instance enables it. It may be nil.
+	Gravity *GravityScheduler
+
+	// ResyncInterval is the interval in which the number of domains and the last
+	// gravity update are read from the Pi-hole, 0 disables it
+	ResyncInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=adlists,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=adlists/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=adlists/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=groups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=clusterpiholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// Reconcile subscribes the Pi-hole referenced by an Adlist to its URL. An adlist
+// with the same URL and type that already exists on the Pi-hole, e.g. because it
+// was added in the Pi-hole UI, is only taken over and removed with the Adlist if
+// its adoption policy allows it.
+func (r *AdlistReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	adlist := &networkingv1alpha1.Adlist{}
+	err := r.Get(ctx, req.NamespacedName, adlist)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("Adlist resource not found. Ignoring since object must be deleted.")
+
+			return ctrl.Result{}, nil
+		}
+
+		reqLogger.Error(err, "Failed to get Adlist")
+		return ctrl.Result{}, err
+	}
+
+	reqLogger.Info("Reconciling Adlist", "Name", adlist.Name)
+
+	if adlist.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(adlist, adlistFinalizerName) {
+			controllerutil.AddFinalizer(adlist, adlistFinalizerName)
+			err = r.Update(ctx, adlist)
+			if err != nil {
+				reqLogger.Error(err, "Failed to update Adlist with finalizer")
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if controllerutil.ContainsFinalizer(adlist, adlistFinalizerName) {
+			reqLogger.Info("Deleting adlist")
+
+			err = r.cleanupList(ctx, adlist)
+			if err != nil {
+				reqLogger.Error(err, "Failed to cleanup adlist")
+				return ctrl.Result{}, err
+			}
+		}
+
+		// Stop reconciliation as the item is being deleted
+		return ctrl.Result{}, nil
+	}
+
+	adlist.Status.ObservedGeneration = adlist.Generation
+
+	list, syncErr := r.syncList(ctx, adlist)
+	if syncErr != nil {
+		reqLogger.Error(syncErr, "Failed to sync adlist")
+		r.Recorder.Eventf(adlist, "Warning", "SyncFailed", "Failed to sync adlist to %s %s: %v", adlist.Spec.InstanceRef.Kind, adlist.Spec.InstanceRef.Name, syncErr)
+	} else {
+		adlist.Status.ID = int32(list.ID)
+		adlist.Status.Domains = int32(list.Number)
+		adlist.Status.LastGravityUpdate = nil
+		if !list.DateUpdated.IsZero() {
+			updated := metav1.NewTime(list.DateUpdated)
+			adlist.Status.LastGravityUpdate = &updated
+		}
+	}
+
+	setConflict(&adlist.Status.Conditions, adlist.Generation, syncErr, "Adlist is managed by the operator")
+	setReady(&adlist.Status.Conditions, adlist.Generation, syncErr,
+		fmt.Sprintf("Adlist is subscribed to by %s %s", adlist.Spec.InstanceRef.Kind, adlist.Spec.InstanceRef.Name))
+
+	err = r.Status().Update(ctx, adlist)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update Adlist status")
+		return ctrl.Result{}, err
+	}
+
+	return resourceSyncResult(syncErr, r.ResyncInterval)
+}
+
+// syncList creates or updates the adlist on the Pi-hole and returns it as stored there
+func (r *AdlistReconciler) syncList(ctx context.Context, adlist *networkingv1alpha1.Adlist) (pihole.List, error) {
+	ref := adlist.Spec.InstanceRef
+
+	lists, err := r.PiHoles.Lists(ctx, adlist.Namespace, ref)
+	if err != nil {
+		return pihole.List{}, err
+	}
+
+	groups, err := resolveGroups(ctx, r.PiHoles, adlist.Namespace, ref, adlist.Spec.Groups)
+	if err != nil {
+		return pihole.List{}, err
+	}
+
+	wanted := pihole.List{
+		Address: adlist.Spec.URL,
+		Type:    listType(adlist.Spec.Type),
+		Comment: adlist.Spec.Comment,
+		Groups:  groups,
+		Enabled: adlist.Spec.Enabled == nil || *adlist.Spec.Enabled,
+	}
+
+	existing, err := lists.GetLists(ctx)
+	if err != nil {
+		return pihole.List{}, err
+	}
+
+	instance := instanceKey(adlist.Namespace, ref)
+	key := adlistKey(adlist)
+
+	i := slices.IndexFunc(existing, func(l pihole.List) bool {
+		return l.Address == wanted.Address && l.Type == wanted.Type
+	})
+	if i < 0 {
+		// claim the adlist before creating it, so it is never left behind unowned
+		if err := r.Registry.claim(ctx, instance, key, adlist, "Adlist"); err != nil {
+			return pihole.List{}, fmt.Errorf("adlist %s: %w", adlist.Spec.URL, err)
+		}
+
+		created, err := lists.CreateList(ctx, wanted)
+		if err != nil {
+			return pihole.List{}, err
+		}
+
+		r.Recorder.Eventf(adlist, "Normal", "Created", "Successfully created adlist in %s %s", ref.Kind, ref.Name)
+		r.Gravity.AdlistsChanged(adlist.Namespace, ref)
+
+		return created, nil
+	}
+
+	current := existing[i]
+	owned, err := r.Registry.owns(ctx, instance, key, adlist)
+	if err != nil {
+		return pihole.List{}, err
+	}
+
+	if !owned {
+		if adlist.Spec.AdoptionPolicy != networkingv1alpha1.AdoptionPolicyAdopt {
+			return pihole.List{}, fmt.Errorf("%w: adlist %d in %s %s was not created by the operator, set adoptionPolicy to Adopt to take it over",
+				errNotOwned, current.ID, ref.Kind, ref.Name)
+		}
+
+		if err := r.Registry.claim(ctx, instance, key, adlist, "Adlist"); err != nil {
+			return pihole.List{}, fmt.Errorf("adlist %d in %s %s: %w", current.ID, ref.Kind, ref.Name, err)
+		}
+
+		r.Recorder.Eventf(adlist, "Normal", "Adopted", "Adopted existing adlist %d in %s %s", current.ID, ref.Kind, ref.Name)
+	}
+
+	if listUpToDate(current, wanted) {
+		return current, nil
+	}
+
+	updated, err := lists.UpdateList(ctx, wanted)
+	if err != nil {
+		return pihole.List{}, err
+	}
+
+	r.Gravity.AdlistsChanged(adlist.Namespace, ref)
+
+	return updated, nil
+}
+
+// cleanupList removes the adlist from the Pi-hole and the finalizer from the Adlist.
+// Adlists that were neither created nor adopted by the Adlist are left untouched.
+func (r *AdlistReconciler) cleanupList(ctx context.Context, adlist *networkingv1alpha1.Adlist) error {
+	lists, err := r.PiHoles.Lists(ctx, adlist.Namespace, adlist.Spec.InstanceRef)
+	if err == nil {
+		err = r.deleteOwnedList(ctx, lists, adlist)
+	}
+
+	switch {
+	case isInstanceNotFound(err):
+		// the instance is gone, there is nothing left to clean up
+	case kerrors.Is(err, pihole.ErrNotFound), kerrors.Is(err, pihole.ErrUnsupported):
+		// the adlist has already been removed or was never created by the Adlist
+	case err != nil:
+		return err
+	default:
+		r.Gravity.AdlistsChanged(adlist.Namespace, adlist.Spec.InstanceRef)
+	}
+
+	controllerutil.RemoveFinalizer(adlist, adlistFinalizerName)
+
+	return r.Update(ctx, adlist)
+}
+
+// deleteOwnedList deletes the adlist from the Pi-hole and forgets its owner if it has
+// been created or adopted by the Adlist, it fails with pihole.ErrNotFound otherwise
+func (r *AdlistReconciler) deleteOwnedList(ctx context.Context, lists pihole.ListClient, adlist *networkingv1alpha1.Adlist) error {
+	instance := instanceKey(adlist.Namespace, adlist.Spec.InstanceRef)
+	key := adlistKey(adlist)
+
+	owned, err := r.Registry.owns(ctx, instance, key, adlist)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return pihole.ErrNotFound
+	}
+
+	err = lists.DeleteList(ctx, adlist.Spec.URL, listType(adlist.Spec.Type))
+	if err != nil && !kerrors.Is(err, pihole.ErrNotFound) {
+		return err
+	}
+
+	if releaseErr := r.Registry.releaseKey(ctx, instance, key); releaseErr != nil {
+		return releaseErr
+	}
+
+	return err
+}
+
+// adlistKey identifies the adlist of an Adlist in the ownership registry
+func adlistKey(adlist *networkingv1alpha1.Adlist) string {
+	return fmt.Sprintf("Adlist %s %s", listType(adlist.Spec.Type), adlist.Spec.URL)
+}
+
+// listType converts the type of an Adlist to the one used by the Pi-hole API
+func listType(adlistType networkingv1alpha1.AdlistType) pihole.ListType {
+	if adlistType == networkingv1alpha1.AdlistTypeAllow {
+		return pihole.ListTypeAllow
+	}
+
+	return pihole.ListTypeBlock
+}
+
+// listUpToDate is true if the Pi-hole doesn't need to be updated for the adlist to match wanted
+func listUpToDate(current pihole.List, wanted pihole.List) bool {
+	return current.Comment == wanted.Comment &&
+		current.Enabled == wanted.Enabled &&
+		sameGroups(current.Groups, wanted.Groups)
+}
+
+// sameGroups is true if both lists contain the same group ids, regardless of their order
+func sameGroups(a []int, b []int) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(a, b)
+}
+
+// adlistsForInstance maps a PiHoleInstance or ClusterPiHoleInstance to all Adlists referencing it.
+func (r *AdlistReconciler) adlistsForInstance(ctx context.Context, obj client.Object) []reconcile.Request {
+	ref := instanceRefOf(obj)
+
+	var opts []client.ListOption
+	if obj.GetNamespace() != "" {
+		opts = append(opts, client.InNamespace(obj.GetNamespace()))
+	}
+
+	adlists := &networkingv1alpha1.AdlistList{}
+	if err := r.List(ctx, adlists, opts...); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list Adlists")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, adlist := range adlists.Items {
+		if !sameInstance(adlist.Spec.InstanceRef, ref) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&adlist)})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AdlistReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1alpha1.Adlist{}, builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.PiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.adlistsForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.ClusterPiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.adlistsForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.Group{}, handler.EnqueueRequestsFromMapFunc(
+			resourcesForGroup(r, func() client.ObjectList { return &networkingv1alpha1.AdlistList{} }))).
+		Complete(r)
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+var _ = Describe("Adlist Controller", func() {
+	const resourceName = "test-adlist"
+	const instanceName = "adlist-instance"
+	const url = "https://example.com/hosts.txt"
+
+	ctx := context.Background()
+
+	typeNamespacedName := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+	var piHole *fake.PiHole
+	var controllerReconciler *AdlistReconciler
+
+	reconcileAdlist := func() (reconcile.Result, error) {
+		return controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+	}
+
+	getAdlist := func() *networkingv1alpha1.Adlist {
+		adlist := &networkingv1alpha1.Adlist{}
+		Expect(k8sClient.Get(ctx, typeNamespacedName, adlist)).To(Succeed())
+
+		return adlist
+	}
+
+	readyCondition := func() *metav1.Condition {
+		condition := meta.FindStatusCondition(getAdlist().Status.Conditions, "Ready")
+		Expect(condition).NotTo(BeNil())
+
+		return condition
+	}
+
+	updateSpec := func(update func(spec *networkingv1alpha1.AdlistSpec)) {
+		adlist := getAdlist()
+		update(&adlist.Spec)
+		Expect(k8sClient.Update(ctx, adlist)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		piHole = fake.NewPiHole()
+		controllerReconciler = &AdlistReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+			PiHoles:  fakePiHoles(piHole),
+			Registry: newRegistry(),
+		}
+
+		createInstance(ctx, instanceName)
+
+		adlist := &networkingv1alpha1.Adlist{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			Spec: networkingv1alpha1.AdlistSpec{
+				InstanceRef: networkingv1alpha1.InstanceReference{Name: instanceName},
+				URL:         url,
+				Comment:     "ads",
+			},
+		}
+		Expect(k8sClient.Create(ctx, adlist)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		deleteOwnership(ctx)
+
+		adlist := &networkingv1alpha1.Adlist{}
+		err := k8sClient.Get(ctx, typeNamespacedName, adlist)
+		if errors.IsNotFound(err) {
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+
+		adlist.Finalizers = nil
+		Expect(k8sClient.Update(ctx, adlist)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, adlist)).To(Succeed())
+	})
+
+	It("should subscribe the Pi-hole to the adlist", func() {
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		lists := piHole.Lists()
+		Expect(lists).To(HaveLen(1))
+		Expect(lists[0].Address).To(Equal(url))
+		Expect(lists[0].Type).To(Equal(pihole.ListTypeBlock))
+		Expect(lists[0].Comment).To(Equal("ads"))
+		Expect(lists[0].Groups).To(Equal([]int{pihole.DefaultGroupID}))
+		Expect(lists[0].Enabled).To(BeTrue())
+
+		adlist := getAdlist()
+		Expect(adlist.Finalizers).To(ContainElement(adlistFinalizerName))
+		Expect(adlist.Status.ID).To(Equal(int32(lists[0].ID)))
+		Expect(adlist.Status.ObservedGeneration).To(Equal(adlist.Generation))
+		Expect(meta.IsStatusConditionTrue(adlist.Status.Conditions, "Ready")).To(BeTrue())
+	})
+
+	It("should not take over an existing adlist it didn't create", func() {
+		piHole.SetLists(pihole.List{Address: url, Type: pihole.ListTypeBlock, Comment: "by hand", Groups: []int{0}})
+
+		result, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		Expect(piHole.Lists()[0].Comment).To(Equal("by hand"))
+		Expect(readyCondition().Reason).To(Equal("Conflict"))
+		Expect(readyCondition().Message).To(ContainSubstring("adoptionPolicy"))
+		Expect(meta.IsStatusConditionTrue(getAdlist().Status.Conditions, "Conflict")).To(BeTrue())
+
+		Expect(k8sClient.Delete(ctx, getAdlist())).To(Succeed())
+		_, err = reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Lists()).To(HaveLen(1))
+		Expect(piHole.Calls(fake.DeleteList)).To(BeZero())
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.Adlist{}))).To(BeTrue())
+	})
+
+	It("should keep owning its adlist when the status wasn't written after creating it", func() {
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		By("losing the status of the Adlist")
+		adlist := getAdlist()
+		adlist.Status = networkingv1alpha1.AdlistStatus{}
+		Expect(k8sClient.Status().Update(ctx, adlist)).To(Succeed())
+
+		_, err = reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readyCondition().Status).To(Equal(metav1.ConditionTrue))
+		Expect(meta.IsStatusConditionFalse(getAdlist().Status.Conditions, "Conflict")).To(BeTrue())
+
+		Expect(k8sClient.Delete(ctx, getAdlist())).To(Succeed())
+		_, err = reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Lists()).To(BeEmpty())
+	})
+
+	It("should not take over an adlist owned by another Adlist", func() {
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		other := &networkingv1alpha1.Adlist{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-adlist", Namespace: "default"},
+			Spec: networkingv1alpha1.AdlistSpec{
+				InstanceRef:    networkingv1alpha1.InstanceReference{Name: instanceName},
+				URL:            url,
+				AdoptionPolicy: networkingv1alpha1.AdoptionPolicyAdopt,
+			},
+		}
+		Expect(k8sClient.Create(ctx, other)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+			other.Finalizers = nil
+			Expect(k8sClient.Update(ctx, other)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, other)).To(Succeed())
+		})
+
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(other)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+		condition := meta.FindStatusCondition(other.Status.Conditions, "Conflict")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring("Adlist default/" + resourceName))
+		Expect(piHole.Lists()[0].Comment).To(Equal("ads"))
+	})
+
+	It("should adopt an existing adlist and report its gravity state", func() {
+		updated := time.Unix(1700000000, 0)
+		piHole.SetLists(pihole.List{Address: url, Type: pihole.ListTypeBlock, Groups: []int{0}, Number: 4711, DateUpdated: updated})
+		updateSpec(func(spec *networkingv1alpha1.AdlistSpec) {
+			spec.AdoptionPolicy = networkingv1alpha1.AdoptionPolicyAdopt
+		})
+
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		lists := piHole.Lists()
+		Expect(lists).To(HaveLen(1))
+		Expect(lists[0].Comment).To(Equal("ads"))
+		Expect(lists[0].Enabled).To(BeTrue())
+
+		adlist := getAdlist()
+		Expect(adlist.Status.Domains).To(Equal(int32(4711)))
+		Expect(adlist.Status.LastGravityUpdate.Time.Equal(updated)).To(BeTrue())
+	})
+
+	It("should not update an adlist that is up to date", func() {
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Calls(fake.CreateList)).To(Equal(1))
+		Expect(piHole.Calls(fake.UpdateList)).To(BeZero())
+	})
+
+	It("should disable the adlist", func() {
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		enabled := false
+		updateSpec(func(spec *networkingv1alpha1.AdlistSpec) { spec.Enabled = &enabled })
+
+		_, err = reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Lists()[0].Enabled).To(BeFalse())
+	})
+
+	It("should subscribe an allow list", func() {
+		updateSpec(func(spec *networkingv1alpha1.AdlistSpec) { spec.Type = networkingv1alpha1.AdlistTypeAllow })
+
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Lists()[0].Type).To(Equal(pihole.ListTypeAllow))
+	})
+
+	It("should assign the adlist to groups by name", func() {
+		piHole.SetGroups(pihole.Group{ID: 3, Name: "kids"}, pihole.Group{ID: 5, Name: "iot"})
+		updateSpec(func(spec *networkingv1alpha1.AdlistSpec) { spec.Groups = []string{"iot", "kids"} })
+
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Lists()[0].Groups).To(Equal([]int{5, 3}))
+	})
+
+	It("should wait for groups that don't exist", func() {
+		updateSpec(func(spec *networkingv1alpha1.AdlistSpec) { spec.Groups = []string{"guests"} })
+
+		result, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(groupRetryInterval))
+		Expect(piHole.Lists()).To(BeEmpty())
+
+		condition := readyCondition()
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("GroupNotFound"))
+		Expect(condition.Message).To(ContainSubstring("guests"))
+	})
+
+	It("should report Pi-holes without adlist support", func() {
+		controllerReconciler.PiHoles.NewClient = func(pihole.Config) pihole.Client {
+			return pihole.NewLegacyPiHole("http://pi.hole/admin/api.php", "token")
+		}
+
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readyCondition().Reason).To(Equal("Unsupported"))
+	})
+
+	It("should back off while the Pi-hole is unavailable", func() {
+		piHole.SetError(fake.GetLists, &pihole.APIError{StatusCode: http.StatusServiceUnavailable})
+
+		_, err := reconcileAdlist()
+		Expect(err).To(MatchError(pihole.ErrUnavailable))
+		Expect(readyCondition().Reason).To(Equal("PiHoleUnreachable"))
+	})
+
+	It("should refresh the status every resync interval", func() {
+		controllerReconciler.ResyncInterval = 5 * time.Minute
+
+		result, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+	})
+
+	It("should remove the adlist from the Pi-hole when it is deleted", func() {
+		piHole.SetLists(pihole.List{Address: url, Type: pihole.ListTypeAllow, Groups: []int{0}})
+
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Lists()).To(HaveLen(2))
+
+		Expect(k8sClient.Delete(ctx, getAdlist())).To(Succeed())
+		_, err = reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Lists()).To(ConsistOf(HaveField("Type", pihole.ListTypeAllow)))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.Adlist{}))).To(BeTrue())
+	})
+
+	It("should finish deleting an adlist that is already gone from the Pi-hole", func() {
+		_, err := reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		piHole.SetLists()
+		Expect(k8sClient.Delete(ctx, getAdlist())).To(Succeed())
+		_, err = reconcileAdlist()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.Adlist{}))).To(BeTrue())
+	})
+})
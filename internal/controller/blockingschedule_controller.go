@@ -0,0 +1,557 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	kerrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/cron"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const blockingScheduleFinalizerName = "blockingschedule.networking.liebler.dev/finalizer"
+
+// conditionEffective is true if the blocking state of an active BlockingSchedule is
+// enforced on its Pi-hole
+const conditionEffective = "Effective"
+
+// Condition reasons of a BlockingSchedule
+const (
+	reasonEffective  = "Effective"
+	reasonOverridden = "Overridden"
+	reasonInactive   = "Inactive"
+)
+
+// blockingTimerTolerance is the difference between the timer of the Pi-hole and the
+// end of a window that is not corrected, it covers the time between two reconciliations
+const blockingTimerTolerance = 5 * time.Second
+
+// maxMergedWindows limits the windows of a schedule merged into the current one
+const maxMergedWindows = 1000
+
+// BlockingScheduleReconciler reconciles a BlockingSchedule object
+type BlockingScheduleReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	PiHoles  *PiHoleClients
+
+	// ResyncInterval is the interval in which the blocking state is read from the
+	// Pi-hole and restored if it was changed manually, 0 disables it
+	ResyncInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=blockingschedules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=blockingschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=blockingschedules/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=clusterpiholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// Reconcile enforces the blocking state of the BlockingSchedule that takes precedence
+// on a Pi-hole. Every BlockingSchedule of the Pi-hole applies the same decision, so it
+// doesn't matter which of them is reconciled. Blocking is disabled with a timer ending
+// with the window, so the Pi-hole enables it again on its own even if the operator
+// is down by then.
+func (r *BlockingScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	schedule := &networkingv1alpha1.BlockingSchedule{}
+	err := r.Get(ctx, req.NamespacedName, schedule)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("BlockingSchedule resource not found. Ignoring since object must be deleted.")
+
+			return ctrl.Result{}, nil
+		}
+
+		reqLogger.Error(err, "Failed to get BlockingSchedule")
+		return ctrl.Result{}, err
+	}
+
+	reqLogger.Info("Reconciling BlockingSchedule", "Name", schedule.Name)
+
+	if schedule.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(schedule, blockingScheduleFinalizerName) {
+			controllerutil.AddFinalizer(schedule, blockingScheduleFinalizerName)
+			err = r.Update(ctx, schedule)
+			if err != nil {
+				reqLogger.Error(err, "Failed to update BlockingSchedule with finalizer")
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if controllerutil.ContainsFinalizer(schedule, blockingScheduleFinalizerName) {
+			reqLogger.Info("Deleting blocking schedule")
+
+			err = r.cleanupSchedule(ctx, schedule)
+			if err != nil {
+				reqLogger.Error(err, "Failed to cleanup blocking schedule")
+				return ctrl.Result{}, err
+			}
+		}
+
+		// Stop reconciliation as the item is being deleted
+		return ctrl.Result{}, nil
+	}
+
+	schedule.Status.ObservedGeneration = schedule.Generation
+	wasActive := schedule.Status.Active
+
+	now := time.Now()
+	until, next, specErr := scheduleWindow(schedule, now)
+	if specErr != nil {
+		r.Recorder.Eventf(schedule, "Warning", reasonInvalidSpec, "Invalid blocking schedule: %v", specErr)
+		setStatusCondition(&schedule.Status.Conditions, schedule.Generation, conditionReady, metav1.ConditionFalse, reasonInvalidSpec, specErr.Error())
+		setStatusCondition(&schedule.Status.Conditions, schedule.Generation, conditionEffective, metav1.ConditionFalse, reasonInvalidSpec, specErr.Error())
+		schedule.Status.Active = false
+		schedule.Status.ActiveUntil = nil
+		schedule.Status.NextWindow = nil
+
+		return ctrl.Result{}, r.Status().Update(ctx, schedule)
+	}
+
+	schedule.Status.Active = !until.IsZero()
+	schedule.Status.ActiveUntil = optionalTime(until)
+	schedule.Status.NextWindow = optionalTime(next)
+
+	// blocking is released if the window was cut short, e.g. by changing its duration
+	release := wasActive && !schedule.Status.Active && blockingState(schedule) == networkingv1alpha1.BlockingDisabled
+
+	winner, syncErr := r.syncBlocking(ctx, schedule, release, now)
+	if syncErr != nil {
+		reqLogger.Error(syncErr, "Failed to sync blocking state")
+		r.Recorder.Eventf(schedule, "Warning", "SyncFailed", "Failed to sync blocking state to %s %s: %v", schedule.Spec.InstanceRef.Kind, schedule.Spec.InstanceRef.Name, syncErr)
+	} else {
+		setEffective(schedule, winner)
+	}
+
+	setReady(&schedule.Status.Conditions, schedule.Generation, syncErr,
+		fmt.Sprintf("Blocking schedule is synced to %s %s", schedule.Spec.InstanceRef.Kind, schedule.Spec.InstanceRef.Name))
+
+	err = r.Status().Update(ctx, schedule)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update BlockingSchedule status")
+		return ctrl.Result{}, err
+	}
+
+	result, err := resourceSyncResult(syncErr, r.ResyncInterval)
+	if syncErr == nil {
+		// the window starting or ending changes the blocking state
+		for _, boundary := range []time.Time{until, next} {
+			if after := time.Until(boundary); !boundary.IsZero() && (result.RequeueAfter == 0 || after < result.RequeueAfter) {
+				result.RequeueAfter = max(after, time.Second)
+			}
+		}
+	}
+
+	return result, err
+}
+
+// syncBlocking enforces the blocking state of the BlockingSchedule of the instance that
+// takes precedence and returns that schedule, nil if none is active. schedule is
+// used instead of the cached version of it. If no schedule is active and release is
+// true, disabled blocking is enabled again. The blocking state reported by the
+// Pi-hole is stored in the status of schedule.
+func (r *BlockingScheduleReconciler) syncBlocking(ctx context.Context, schedule *networkingv1alpha1.BlockingSchedule,
+	release bool, now time.Time) (*activeSchedule, error) {
+	ref := schedule.Spec.InstanceRef
+
+	blocking, err := r.PiHoles.Blocking(ctx, schedule.Namespace, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := r.activeSchedules(ctx, schedule, now)
+	if err != nil {
+		return nil, err
+	}
+	winner := effectiveSchedule(active)
+
+	current, err := blocking.GetBlocking(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case winner == nil:
+		if release && current.State == pihole.BlockingDisabled {
+			current, err = blocking.SetBlocking(ctx, true, 0)
+			if err == nil {
+				r.Recorder.Eventf(schedule, "Normal", "BlockingEnabled", "Enabled blocking on %s %s", ref.Kind, ref.Name)
+			}
+		}
+	case blockingState(winner.schedule) == networkingv1alpha1.BlockingEnabled:
+		if current.State != pihole.BlockingEnabled || current.Timer > 0 {
+			current, err = blocking.SetBlocking(ctx, true, 0)
+			if err == nil {
+				r.Recorder.Eventf(schedule, "Normal", "BlockingEnabled", "Enabled blocking on %s %s as required by BlockingSchedule %s",
+					ref.Kind, ref.Name, client.ObjectKeyFromObject(winner.schedule))
+			}
+		}
+	default:
+		timer := winner.until.Sub(now)
+		if current.State != pihole.BlockingDisabled || (current.Timer-timer).Abs() > blockingTimerTolerance {
+			current, err = blocking.SetBlocking(ctx, false, timer)
+			if err == nil {
+				r.Recorder.Eventf(schedule, "Normal", "BlockingDisabled", "Disabled blocking on %s %s until %s as required by BlockingSchedule %s",
+					ref.Kind, ref.Name, winner.until.Format(time.RFC3339), client.ObjectKeyFromObject(winner.schedule))
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Status.Blocking = string(current.State)
+	schedule.Status.Timer = nil
+	if current.Timer > 0 {
+		schedule.Status.Timer = &metav1.Duration{Duration: current.Timer.Round(time.Second)}
+	}
+
+	return winner, nil
+}
+
+// cleanupSchedule hands the blocking state over to the remaining BlockingSchedules of
+// the instance and removes the finalizer from the BlockingSchedule. Blocking disabled
+// by it is enabled again if no other schedule is active.
+func (r *BlockingScheduleReconciler) cleanupSchedule(ctx context.Context, schedule *networkingv1alpha1.BlockingSchedule) error {
+	now := time.Now()
+	until, _, specErr := scheduleWindow(schedule, now)
+	release := specErr == nil && !until.IsZero() && blockingState(schedule) == networkingv1alpha1.BlockingDisabled
+
+	// the schedule is being deleted, so it is left out of the schedules taking precedence
+	_, err := r.syncBlocking(ctx, schedule, release, now)
+
+	switch {
+	case isInstanceNotFound(err):
+		// the instance is gone, there is nothing left to clean up
+	case kerrors.Is(err, pihole.ErrUnsupported):
+		// blocking was never changed
+	case err != nil:
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(schedule, blockingScheduleFinalizerName)
+
+	return r.Update(ctx, schedule)
+}
+
+// activeSchedule is a BlockingSchedule in one of its windows
+type activeSchedule struct {
+	schedule *networkingv1alpha1.BlockingSchedule
+	// until is the end of the current window
+	until time.Time
+}
+
+// activeSchedules returns the active BlockingSchedules of the instance of schedule,
+// schedule itself is used instead of its cached version. Schedules being deleted or
+// with an invalid spec are left out.
+func (r *BlockingScheduleReconciler) activeSchedules(ctx context.Context, schedule *networkingv1alpha1.BlockingSchedule, now time.Time) ([]activeSchedule, error) {
+	var opts []client.ListOption
+	if schedule.Spec.InstanceRef.Kind != networkingv1alpha1.ClusterPiHoleInstanceKind {
+		opts = append(opts, client.InNamespace(schedule.Namespace))
+	}
+
+	schedules := &networkingv1alpha1.BlockingScheduleList{}
+	if err := r.List(ctx, schedules, opts...); err != nil {
+		return nil, err
+	}
+
+	key := instanceKey(schedule.Namespace, schedule.Spec.InstanceRef)
+
+	var active []activeSchedule
+	for i := range schedules.Items {
+		candidate := &schedules.Items[i]
+		if candidate.Namespace == schedule.Namespace && candidate.Name == schedule.Name {
+			candidate = schedule
+		}
+
+		if !candidate.DeletionTimestamp.IsZero() || instanceKey(candidate.Namespace, candidate.Spec.InstanceRef) != key {
+			continue
+		}
+
+		until, _, err := scheduleWindow(candidate, now)
+		if err != nil || until.IsZero() {
+			continue
+		}
+
+		active = append(active, activeSchedule{schedule: candidate, until: until})
+	}
+
+	return active, nil
+}
+
+// effectiveSchedule returns the active schedule taking precedence, nil if there is none.
+// The schedule with the highest priority wins, then Enabled wins over Disabled, then the
+// window ending last, so blocking isn't enabled while another window is still active.
+func effectiveSchedule(active []activeSchedule) *activeSchedule {
+	if len(active) == 0 {
+		return nil
+	}
+
+	winner := slices.MinFunc(active, func(a, b activeSchedule) int {
+		if a.schedule.Spec.Priority != b.schedule.Spec.Priority {
+			return int(b.schedule.Spec.Priority) - int(a.schedule.Spec.Priority)
+		}
+
+		aEnabled := blockingState(a.schedule) == networkingv1alpha1.BlockingEnabled
+		bEnabled := blockingState(b.schedule) == networkingv1alpha1.BlockingEnabled
+		if aEnabled != bEnabled {
+			if aEnabled {
+				return -1
+			}
+
+			return 1
+		}
+
+		if c := b.until.Compare(a.until); c != 0 {
+			return c
+		}
+
+		return strings.Compare(client.ObjectKeyFromObject(a.schedule).String(), client.ObjectKeyFromObject(b.schedule).String())
+	})
+
+	return &winner
+}
+
+// setEffective sets the Effective condition of a BlockingSchedule from the schedule taking precedence
+func setEffective(schedule *networkingv1alpha1.BlockingSchedule, winner *activeSchedule) {
+	conditions := &schedule.Status.Conditions
+
+	switch {
+	case !schedule.Status.Active:
+		message := "Blocking schedule is not in one of its windows"
+		if schedule.Status.NextWindow != nil {
+			message = fmt.Sprintf("%s, the next one starts at %s", message, schedule.Status.NextWindow.Format(time.RFC3339))
+		}
+		setStatusCondition(conditions, schedule.Generation, conditionEffective, metav1.ConditionFalse, reasonInactive, message)
+	case winner.schedule.Namespace == schedule.Namespace && winner.schedule.Name == schedule.Name:
+		setStatusCondition(conditions, schedule.Generation, conditionEffective, metav1.ConditionTrue, reasonEffective,
+			fmt.Sprintf("Blocking is %s until %s", strings.ToLower(string(blockingState(schedule))), winner.until.Format(time.RFC3339)))
+	default:
+		setStatusCondition(conditions, schedule.Generation, conditionEffective, metav1.ConditionFalse, reasonOverridden,
+			fmt.Sprintf("BlockingSchedule %s takes precedence", client.ObjectKeyFromObject(winner.schedule)))
+	}
+}
+
+// blockingState returns the blocking state a BlockingSchedule enforces
+func blockingState(schedule *networkingv1alpha1.BlockingSchedule) networkingv1alpha1.BlockingState {
+	if schedule.Spec.Blocking == "" {
+		return networkingv1alpha1.BlockingDisabled
+	}
+
+	return schedule.Spec.Blocking
+}
+
+// scheduleWindow returns the end of the window a BlockingSchedule is in at now, zero
+// if it is inactive, and the start of its next window, zero if there is none
+func scheduleWindow(schedule *networkingv1alpha1.BlockingSchedule, now time.Time) (time.Time, time.Time, error) {
+	var until, next time.Time
+
+	if activeFor := schedule.Spec.ActiveFor; activeFor != nil {
+		if activeFor.Duration <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("activeFor must be positive")
+		}
+
+		start := schedule.CreationTimestamp.Time
+		if end := start.Add(activeFor.Duration); !now.Before(start) && now.Before(end) {
+			until = end
+		}
+	}
+
+	location := time.UTC
+	if schedule.Spec.TimeZone != "" {
+		var err error
+		location, err = time.LoadLocation(schedule.Spec.TimeZone)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid time zone %q: %w", schedule.Spec.TimeZone, err)
+		}
+	}
+
+	for _, window := range schedule.Spec.Windows {
+		parsed, err := cron.Parse(window.Schedule)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if window.Duration.Duration <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("duration of window %q must be positive", window.Schedule)
+		}
+
+		windowUntil, windowNext, err := cronWindow(parsed, window.Duration.Duration, now.In(location))
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if windowUntil.After(until) {
+			until = windowUntil
+		}
+		if !windowNext.IsZero() && (next.IsZero() || windowNext.Before(next)) {
+			next = windowNext
+		}
+	}
+
+	return until, next, nil
+}
+
+// cronWindow returns the end of the window of a schedule now is in, zero if there is
+// none, and the start of the next window. Windows overlapping the current one or
+// following it without a gap are merged into it. It fails if the schedule is never
+// due or more than maxMergedWindows windows started within duration before now.
+func cronWindow(schedule *cron.Schedule, duration time.Duration, now time.Time) (time.Time, time.Time, error) {
+	start := schedule.Next(now.Add(-duration))
+	if start.IsZero() {
+		return time.Time{}, time.Time{}, fmt.Errorf("schedule %q is never due", schedule)
+	}
+
+	var until time.Time
+	for i := 0; !start.IsZero() && !start.After(now); i++ {
+		if i == maxMergedWindows {
+			return time.Time{}, time.Time{}, fmt.Errorf("schedule %q starts more than %d windows within %s", schedule, maxMergedWindows, duration)
+		}
+
+		until = start.Add(duration)
+		start = schedule.Next(start)
+	}
+
+	next := schedule.Next(now)
+	for i := 0; !until.IsZero() && !next.IsZero() && !next.After(until) && i < maxMergedWindows; i++ {
+		until = next.Add(duration)
+		next = schedule.Next(next)
+	}
+
+	return until, next, nil
+}
+
+// optionalTime returns t as a timestamp of a status, nil if it is zero
+func optionalTime(t time.Time) *metav1.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	timestamp := metav1.NewTime(t)
+
+	return &timestamp
+}
+
+// schedulesOfSameInstance maps a BlockingSchedule to the other BlockingSchedules of its
+// instance, their Effective condition depends on it
+func (r *BlockingScheduleReconciler) schedulesOfSameInstance(ctx context.Context, obj client.Object) []reconcile.Request {
+	schedule, ok := obj.(*networkingv1alpha1.BlockingSchedule)
+	if !ok {
+		return nil
+	}
+
+	schedules := &networkingv1alpha1.BlockingScheduleList{}
+	if err := r.List(ctx, schedules); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list BlockingSchedules")
+		return nil
+	}
+
+	key := instanceKey(schedule.Namespace, schedule.Spec.InstanceRef)
+
+	var requests []reconcile.Request
+	for _, other := range schedules.Items {
+		if other.Namespace == schedule.Namespace && other.Name == schedule.Name {
+			continue
+		}
+		if instanceKey(other.Namespace, other.Spec.InstanceRef) != key {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&other)})
+	}
+
+	return requests
+}
+
+// blockingScheduleChanged passes changes of a BlockingSchedule that affect the other
+// schedules of its instance. The status changes on every reconciliation as it contains
+// the timer of the Pi-hole, only windows starting or ending are passed.
+var blockingScheduleChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldSchedule, ok := e.ObjectOld.(*networkingv1alpha1.BlockingSchedule)
+		if !ok {
+			return false
+		}
+		newSchedule, ok := e.ObjectNew.(*networkingv1alpha1.BlockingSchedule)
+		if !ok {
+			return false
+		}
+
+		return ignoreStatusUpdates.Update(e) || oldSchedule.Status.Active != newSchedule.Status.Active
+	},
+}
+
+// blockingSchedulesForInstance maps a PiHoleInstance or ClusterPiHoleInstance to all BlockingSchedules referencing it.
+func (r *BlockingScheduleReconciler) blockingSchedulesForInstance(ctx context.Context, obj client.Object) []reconcile.Request {
+	ref := instanceRefOf(obj)
+
+	var opts []client.ListOption
+	if obj.GetNamespace() != "" {
+		opts = append(opts, client.InNamespace(obj.GetNamespace()))
+	}
+
+	schedules := &networkingv1alpha1.BlockingScheduleList{}
+	if err := r.List(ctx, schedules, opts...); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list BlockingSchedules")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, schedule := range schedules.Items {
+		if !sameInstance(schedule.Spec.InstanceRef, ref) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&schedule)})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BlockingScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1alpha1.BlockingSchedule{}, builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.BlockingSchedule{}, handler.EnqueueRequestsFromMapFunc(r.schedulesOfSameInstance),
+			builder.WithPredicates(blockingScheduleChanged)).
+		Watches(&networkingv1alpha1.PiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.blockingSchedulesForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.ClusterPiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.blockingSchedulesForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Complete(r)
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/cron"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+var _ = Describe("BlockingSchedule Controller", func() {
+	const resourceName = "ten-minutes"
+	const instanceName = "blocking-instance"
+
+	ctx := context.Background()
+
+	typeNamespacedName := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+	var piHole *fake.PiHole
+	var controllerReconciler *BlockingScheduleReconciler
+
+	reconcileSchedule := func(name string) (reconcile.Result, error) {
+		return controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}})
+	}
+
+	getSchedule := func(name string) *networkingv1alpha1.BlockingSchedule {
+		schedule := &networkingv1alpha1.BlockingSchedule{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, schedule)).To(Succeed())
+
+		return schedule
+	}
+
+	// createSchedule creates a BlockingSchedule and removes it without waiting for
+	// its controller once the test is done
+	createSchedule := func(name string, spec networkingv1alpha1.BlockingScheduleSpec) {
+		spec.InstanceRef = networkingv1alpha1.InstanceReference{Name: instanceName}
+		schedule := &networkingv1alpha1.BlockingSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       spec,
+		}
+		Expect(k8sClient.Create(ctx, schedule)).To(Succeed())
+		DeferCleanup(func() {
+			schedule := &networkingv1alpha1.BlockingSchedule{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, schedule)
+			if errors.IsNotFound(err) {
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+
+			schedule.Finalizers = nil
+			Expect(k8sClient.Update(ctx, schedule)).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, schedule))).To(Succeed())
+		})
+	}
+
+	BeforeEach(func() {
+		piHole = fake.NewPiHole()
+		controllerReconciler = &BlockingScheduleReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+			PiHoles:  fakePiHoles(piHole),
+		}
+
+		createInstance(ctx, instanceName)
+
+		createSchedule(resourceName, networkingv1alpha1.BlockingScheduleSpec{
+			ActiveFor: &metav1.Duration{Duration: 10 * time.Minute},
+		})
+	})
+
+	It("should disable blocking until the schedule ends", func() {
+		result, err := reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", 10*time.Minute, 10*time.Second))
+
+		blocking := piHole.Blocking()
+		Expect(blocking.State).To(Equal(pihole.BlockingDisabled))
+		Expect(blocking.Timer).To(BeNumerically("~", 10*time.Minute, 10*time.Second))
+
+		schedule := getSchedule(resourceName)
+		Expect(schedule.Finalizers).To(ContainElement(blockingScheduleFinalizerName))
+		Expect(schedule.Status.Active).To(BeTrue())
+		Expect(schedule.Status.ActiveUntil).NotTo(BeNil())
+		Expect(schedule.Status.Blocking).To(Equal("disabled"))
+		Expect(schedule.Status.Timer).NotTo(BeNil())
+		Expect(meta.IsStatusConditionTrue(schedule.Status.Conditions, "Ready")).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(schedule.Status.Conditions, "Effective")).To(BeTrue())
+	})
+
+	It("should not set the timer again while it matches the schedule", func() {
+		_, err := reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Calls(fake.SetBlocking)).To(Equal(1))
+	})
+
+	It("should disable blocking again after it was enabled manually", func() {
+		_, err := reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+
+		piHole.SetBlockingState(true, 0)
+		_, err = reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Blocking().State).To(Equal(pihole.BlockingDisabled))
+	})
+
+	It("should leave blocking alone outside of the windows", func() {
+		createSchedule("new-year", networkingv1alpha1.BlockingScheduleSpec{
+			Windows: []networkingv1alpha1.BlockingWindow{{Schedule: "0 0 1 1 *", Duration: metav1.Duration{Duration: time.Minute}}},
+		})
+
+		result, err := reconcileSchedule("new-year")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		// the other schedule is active, but this one didn't apply it yet
+		schedule := getSchedule("new-year")
+		Expect(schedule.Status.Active).To(BeFalse())
+		Expect(schedule.Status.NextWindow).NotTo(BeNil())
+
+		condition := meta.FindStatusCondition(schedule.Status.Conditions, "Effective")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("Inactive"))
+	})
+
+	It("should let the schedule with the highest priority take precedence", func() {
+		createSchedule("keep-blocking", networkingv1alpha1.BlockingScheduleSpec{
+			Blocking:  networkingv1alpha1.BlockingEnabled,
+			ActiveFor: &metav1.Duration{Duration: time.Hour},
+			Priority:  10,
+		})
+
+		_, err := reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Blocking()).To(Equal(pihole.Blocking{State: pihole.BlockingEnabled}))
+
+		condition := meta.FindStatusCondition(getSchedule(resourceName).Status.Conditions, "Effective")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("Overridden"))
+		Expect(condition.Message).To(ContainSubstring("default/keep-blocking"))
+
+		_, err = reconcileSchedule("keep-blocking")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(meta.IsStatusConditionTrue(getSchedule("keep-blocking").Status.Conditions, "Effective")).To(BeTrue())
+	})
+
+	It("should report invalid windows", func() {
+		createSchedule("invalid", networkingv1alpha1.BlockingScheduleSpec{
+			Windows: []networkingv1alpha1.BlockingWindow{{Schedule: "0 25 * * *", Duration: metav1.Duration{Duration: time.Hour}}},
+		})
+
+		result, err := reconcileSchedule("invalid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		condition := meta.FindStatusCondition(getSchedule("invalid").Status.Conditions, "Ready")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("InvalidSpec"))
+		Expect(piHole.Calls(fake.SetBlocking)).To(BeZero())
+	})
+
+	It("should report windows that are never due", func() {
+		createSchedule("never", networkingv1alpha1.BlockingScheduleSpec{
+			Windows: []networkingv1alpha1.BlockingWindow{{Schedule: "0 0 31 2 *", Duration: metav1.Duration{Duration: time.Hour}}},
+		})
+
+		result, err := reconcileSchedule("never")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		condition := meta.FindStatusCondition(getSchedule("never").Status.Conditions, "Ready")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("InvalidSpec"))
+		Expect(condition.Message).To(ContainSubstring("never due"))
+	})
+
+	It("should report Pi-holes without blocking support", func() {
+		controllerReconciler.PiHoles.NewClient = func(pihole.Config) pihole.Client {
+			return pihole.NewLegacyPiHole("http://pi.hole/admin/api.php", "secret")
+		}
+
+		_, err := reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+
+		condition := meta.FindStatusCondition(getSchedule(resourceName).Status.Conditions, "Ready")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("Unsupported"))
+	})
+
+	It("should enable blocking again when the schedule is deleted", func() {
+		_, err := reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, getSchedule(resourceName))).To(Succeed())
+		_, err = reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Blocking()).To(Equal(pihole.Blocking{State: pihole.BlockingEnabled}))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.BlockingSchedule{}))).To(BeTrue())
+	})
+
+	It("should hand over to another active schedule when it is deleted", func() {
+		createSchedule("one-hour", networkingv1alpha1.BlockingScheduleSpec{
+			ActiveFor: &metav1.Duration{Duration: time.Hour},
+		})
+
+		_, err := reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, getSchedule(resourceName))).To(Succeed())
+		_, err = reconcileSchedule(resourceName)
+		Expect(err).NotTo(HaveOccurred())
+
+		blocking := piHole.Blocking()
+		Expect(blocking.State).To(Equal(pihole.BlockingDisabled))
+		Expect(blocking.Timer).To(BeNumerically("~", time.Hour, 10*time.Second))
+	})
+})
+
+var _ = DescribeTable("cronWindow",
+	func(spec string, duration time.Duration, now string, until string, next string) {
+		schedule, err := cron.Parse(spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		at := func(value string) time.Time {
+			if value == "" {
+				return time.Time{}
+			}
+			t, err := time.Parse(time.RFC3339, value)
+			Expect(err).NotTo(HaveOccurred())
+
+			return t
+		}
+
+		gotUntil, gotNext, err := cronWindow(schedule, duration, at(now))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gotUntil).To(BeTemporally("==", at(until)))
+		Expect(gotNext).To(BeTemporally("==", at(next)))
+	},
+	Entry("before a window", "0 9 * * mon-fri", 8*time.Hour, "2025-06-02T08:00:00Z", "", "2025-06-02T09:00:00Z"),
+	Entry("within a window", "0 9 * * mon-fri", 8*time.Hour, "2025-06-02T12:00:00Z", "2025-06-02T17:00:00Z", "2025-06-03T09:00:00Z"),
+	Entry("at the end of a window", "0 9 * * mon-fri", 8*time.Hour, "2025-06-02T17:00:00Z", "", "2025-06-03T09:00:00Z"),
+	Entry("on a weekend", "0 9 * * mon-fri", 8*time.Hour, "2025-06-07T12:00:00Z", "", "2025-06-09T09:00:00Z"),
+	Entry("overlapping windows", "0 9,12 * * *", 4*time.Hour, "2025-06-02T10:00:00Z", "2025-06-02T16:00:00Z", "2025-06-03T09:00:00Z"),
+)
+
+var _ = DescribeTable("cronWindow of invalid schedules",
+	func(spec string, duration time.Duration, message string) {
+		schedule, err := cron.Parse(spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, _, err = cronWindow(schedule, duration, time.Date(2025, time.June, 2, 12, 0, 0, 0, time.UTC))
+		Expect(err).To(MatchError(ContainSubstring(message)))
+	},
+	Entry("never due", "0 0 31 2 *", time.Hour, "never due"),
+	Entry("too many windows", "* * * * *", 24*time.Hour, "more than 1000 windows"),
+)
@@ -0,0 +1,139 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+// Condition types of a DNSName, the other resources use Ready and, where it applies, Conflict
+const (
+	// conditionReady is true if the DNSName is served by all of its instances
+	conditionReady = "Ready"
+	// conditionSynced is true if the records are up to date on all instances
+	conditionSynced = "Synced"
+	// conditionDegraded is true if the records are up to date on some instances only
+	conditionDegraded = "Degraded"
+	// conditionConflict is true if another DNSName owns the domain
+	conditionConflict = "Conflict"
+	// conditionDrifted is true if the last sync restored records changed outside of the operator
+	conditionDrifted = "Drifted"
+)
+
+// Condition reasons of a DNSName
+const (
+	reasonSynced            = "Synced"
+	reasonNoConflict        = "NoConflict"
+	reasonConflict          = "Conflict"
+	reasonInvalidSpec       = "InvalidSpec"
+	reasonInstanceNotFound  = "InstanceNotFound"
+	reasonAuthFailed        = "AuthFailed"
+	reasonPiHoleUnreachable = "PiHoleUnreachable"
+	reasonTimeout           = "Timeout"
+	reasonSyncFailed        = "SyncFailed"
+	reasonNoDrift           = "NoDrift"
+	reasonDriftCorrected    = "DriftCorrected"
+	reasonGroupNotFound     = "GroupNotFound"
+	reasonUnsupported       = "Unsupported"
+	reasonInUse             = "InUse"
+)
+
+// failureReason classifies an error that occurred while syncing a DNSName, so
+// users can tell a wrong password from a Pi-hole that is down.
+func failureReason(err error) string {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		// the Pi-hole didn't answer in time, make that obvious to the user
+		return reasonTimeout
+	case errors.Is(err, pihole.ErrUnauthorized):
+		return reasonAuthFailed
+	case errors.Is(err, pihole.ErrInvalid):
+		return reasonInvalidSpec
+	case errors.Is(err, errNotOwned):
+		return reasonConflict
+	case errors.Is(err, pihole.ErrUnsupported):
+		return reasonUnsupported
+	case errors.Is(err, pihole.ErrUnavailable), errors.As(err, &netErr):
+		return reasonPiHoleUnreachable
+	case apierrors.IsNotFound(err):
+		return reasonInstanceNotFound
+	}
+
+	return reasonSyncFailed
+}
+
+// setCondition sets a condition of the DNSName for its current generation
+func setCondition(dnsName *networkingv1alpha1.DNSName, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	setStatusCondition(&dnsName.Status.Conditions, dnsName.Generation, conditionType, status, reason, message)
+}
+
+// setStatusCondition sets a condition of any resource for the given generation
+func setStatusCondition(conditions *[]metav1.Condition, generation int64, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// setReady sets the Ready condition of a resource managed in a single instance from
+// the error of its last sync
+func setReady(conditions *[]metav1.Condition, generation int64, err error, message string) {
+	if err == nil {
+		setStatusCondition(conditions, generation, conditionReady, metav1.ConditionTrue, reasonSynced, message)
+		return
+	}
+
+	reason := failureReason(err)
+	if errors.Is(err, errGroupNotFound) {
+		reason = reasonGroupNotFound
+	}
+
+	setStatusCondition(conditions, generation, conditionReady, metav1.ConditionFalse, reason, err.Error())
+}
+
+// setConflict sets the Conflict condition of a resource managed in a single instance,
+// it is true if an entry the resource doesn't own is in its way
+func setConflict(conditions *[]metav1.Condition, generation int64, err error, message string) {
+	if errors.Is(err, errNotOwned) {
+		setStatusCondition(conditions, generation, conditionConflict, metav1.ConditionTrue, reasonConflict, err.Error())
+		return
+	}
+
+	setStatusCondition(conditions, generation, conditionConflict, metav1.ConditionFalse, reasonNoConflict, message)
+}
+
+// setNotReady marks the DNSName as neither ready nor synced for a reason that
+// affects all of its instances alike.
+func setNotReady(dnsName *networkingv1alpha1.DNSName, reason string, message string) {
+	setCondition(dnsName, conditionReady, metav1.ConditionFalse, reason, message)
+	setCondition(dnsName, conditionSynced, metav1.ConditionFalse, reason, message)
+	setCondition(dnsName, conditionDegraded, metav1.ConditionFalse, reason, message)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+// domainIndexKey indexes DNSNames by their domain. It matches the selectable field
+// of the CRD, so the same field selector works against the cache and the API server.
+const domainIndexKey = "spec.domain"
+
+func indexDNSNameDomain(obj client.Object) []string {
+	return []string{obj.(*networkingv1alpha1.DNSName).Spec.Domain}
+}
+
+// conflictingClaim returns the DNSName winning the claim on the domain of dnsName,
+// which is the oldest DNSName with conflicting records on one of the same instances.
+// It returns nil if dnsName wins itself.
+func (r *DNSNameReconciler) conflictingClaim(ctx context.Context, dnsName *networkingv1alpha1.DNSName, refs []networkingv1alpha1.InstanceReference) (*networkingv1alpha1.DNSName, error) {
+	dnsNames := &networkingv1alpha1.DNSNameList{}
+	if err := r.List(ctx, dnsNames, client.MatchingFields{domainIndexKey: dnsName.Spec.Domain}); err != nil {
+		return nil, err
+	}
+
+	types := pihole.RecordTypes(dnsName.Spec)
+
+	var winner *networkingv1alpha1.DNSName
+	for i := range dnsNames.Items {
+		other := &dnsNames.Items[i]
+
+		if other.UID == dnsName.UID || !other.DeletionTimestamp.IsZero() || !claimedBefore(other, dnsName) {
+			continue
+		}
+
+		if winner != nil && !claimedBefore(other, winner) {
+			continue
+		}
+
+		if !typesConflict(types, pihole.RecordTypes(other.Spec)) {
+			continue
+		}
+
+		otherRefs, err := r.instanceRefs(ctx, other)
+		if err != nil {
+			return nil, err
+		}
+
+		if !instancesOverlap(dnsName.Namespace, refs, other.Namespace, otherRefs) {
+			continue
+		}
+
+		winner = other
+	}
+
+	return winner, nil
+}
+
+// claimedBefore orders DNSNames by their creation, the name breaks ties so the
+// order is deterministic
+func claimedBefore(a *networkingv1alpha1.DNSName, b *networkingv1alpha1.DNSName) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+
+	return a.Name < b.Name
+}
+
+func typesConflict(a []networkingv1alpha1.DNSRecordType, b []networkingv1alpha1.DNSRecordType) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if pihole.TypesConflict(x, y) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// instancesOverlap is true if the refs of two objects share an instance, PiHoleInstances
+// are resolved in the namespace of the object referencing them
+func instancesOverlap(namespaceA string, a []networkingv1alpha1.InstanceReference, namespaceB string, b []networkingv1alpha1.InstanceReference) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if instanceKey(namespaceA, x) == instanceKey(namespaceB, y) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// claimsOnDomain enqueues all other DNSNames of the old and the new domain of a
+// changed DNSName, so losers of a conflict take over once the winner is gone.
+func (r *DNSNameReconciler) claimsOnDomain() handler.EventHandler {
+	enqueue := func(ctx context.Context, obj client.Object, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+		dnsName, ok := obj.(*networkingv1alpha1.DNSName)
+		if !ok {
+			return
+		}
+
+		dnsNames := &networkingv1alpha1.DNSNameList{}
+		if err := r.List(ctx, dnsNames, client.MatchingFields{domainIndexKey: dnsName.Spec.Domain}); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to list DNSNames", "Domain", dnsName.Spec.Domain)
+			return
+		}
+
+		for _, other := range dnsNames.Items {
+			if other.UID != dnsName.UID {
+				q.Add(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&other)})
+			}
+		}
+	}
+
+	return handler.Funcs{
+		CreateFunc: func(ctx context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, q)
+		},
+		UpdateFunc: func(ctx context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			// status updates don't change claims, ignoring them avoids ping-pong between competing DNSNames
+			if e.ObjectOld.GetGeneration() == e.ObjectNew.GetGeneration() &&
+				e.ObjectOld.GetDeletionTimestamp().Equal(e.ObjectNew.GetDeletionTimestamp()) {
+				return
+			}
+
+			enqueue(ctx, e.ObjectOld, q)
+			enqueue(ctx, e.ObjectNew, q)
+		},
+		DeleteFunc: func(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(ctx, e.Object, q)
+		},
+	}
+}
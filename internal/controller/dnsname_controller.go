@@ -18,16 +18,26 @@ package controller
 
 import (
 	"context"
+	kerrors "errors"
+	"fmt"
+	"slices"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
 	"github.com/domnikl/pihole-operator/internal/pihole"
@@ -40,12 +50,24 @@ type DNSNameReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
-	PiHole   *pihole.PiHole
+	PiHoles  *PiHoleClients
+	Registry *OwnershipRegistry
+
+	// MaxConcurrentReconciles is the number of DNSNames reconciled in parallel, it defaults to 1
+	MaxConcurrentReconciles int
+
+	// ResyncInterval is the interval in which the records on the Pi-holes are checked
+	// for changes made outside of the operator, 0 disables the check
+	ResyncInterval time.Duration
 }
 
 // +kubebuilder:rbac:groups=networking.liebler.dev,resources=dnsnames,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.liebler.dev,resources=dnsnames/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=networking.liebler.dev,resources=dnsnames/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=clusterpiholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -74,13 +96,6 @@ func (r *DNSNameReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 	reqLogger.Info("Reconciling DNSName", "Name", dnsName.Name)
 
-	dnsName.Status.Conditions = append(dnsName.Status.Conditions, v1.Condition{
-		Type:    "Pending",
-		Status:  v1.ConditionTrue,
-		Reason:  "Pending",
-		Message: "DNS record is pending",
-	})
-
 	if dnsName.ObjectMeta.DeletionTimestamp.IsZero() {
 		if !controllerutil.ContainsFinalizer(dnsName, finalizerName) {
 			dnsName.ObjectMeta.Finalizers = append(dnsName.ObjectMeta.Finalizers, finalizerName)
@@ -106,72 +121,504 @@ func (r *DNSNameReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, nil
 	}
 
-	records, err := r.PiHole.GetDNSRecords()
+	dnsName.Status.ObservedGeneration = dnsName.Generation
+
+	newRecords, err := pihole.NewDNSRecordsFromSpec(dnsName.Spec)
+	if err != nil {
+		reqLogger.Error(err, "Failed to create DNS record from spec")
+
+		// retrying doesn't help, the DNSName is reconciled again once its spec changes
+		setNotReady(dnsName, reasonInvalidSpec, err.Error())
+		if err := r.Status().Update(ctx, dnsName); err != nil {
+			reqLogger.Error(err, "Failed to update DNSName status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	refs, err := r.instanceRefs(ctx, dnsName)
 	if err != nil {
-		reqLogger.Error(err, "Failed to get DNS records")
+		reqLogger.Error(err, "Failed to resolve Pi-hole instances")
 		return ctrl.Result{}, err
 	}
 
-	newRecord, err := pihole.NewDNSRecordFromSpec(dnsName.Spec)
+	winner, err := r.conflictingClaim(ctx, dnsName, refs)
 	if err != nil {
-		reqLogger.Error(err, "Failed to create DNS record from spec")
+		reqLogger.Error(err, "Failed to check for conflicting DNSNames")
 		return ctrl.Result{}, err
 	}
 
+	if winner != nil {
+		return ctrl.Result{}, r.yield(ctx, dnsName, winner)
+	}
+
+	setCondition(dnsName, conditionConflict, v1.ConditionFalse, reasonNoConflict,
+		fmt.Sprintf("DNSName owns the domain %s", dnsName.Spec.Domain))
+
+	var errs []error
+	instances := make([]networkingv1alpha1.InstanceStatus, 0, len(refs))
+	synced := isSynced(dnsName)
+	corrections := 0
+
+	for _, ref := range refs {
+		status := networkingv1alpha1.InstanceStatus{InstanceReference: ref, Synced: true}
+
+		view, changes, err := r.syncDNSRecords(ctx, dnsName, ref, newRecords)
+		status.Addresses = addresses(view, newRecords)
+		status.Records = recordStatuses(view)
+
+		// records of an instance that already was in sync must have been changed by someone else
+		if changes > 0 && synced && wasSynced(dnsName, ref) {
+			reqLogger.Info("Restored DNS records changed outside of the operator", "Instance", ref.Name, "Changes", changes)
+			r.Recorder.Eventf(dnsName, "Warning", reasonDriftCorrected, "Restored %d DNS record(s) changed outside of the operator in %s %s", changes, ref.Kind, ref.Name)
+
+			driftCorrections.WithLabelValues(instanceKey(dnsName.Namespace, ref)).Add(float64(changes))
+			corrections += changes
+		}
+
+		if err != nil {
+			reqLogger.Error(err, "Failed to sync DNS record", "Instance", ref.Name)
+			r.Recorder.Eventf(dnsName, "Warning", "SyncFailed", "Failed to sync DNS record to %s %s: %v", ref.Kind, ref.Name, err)
+
+			status.Synced = false
+			status.Error = err.Error()
+			errs = append(errs, err)
+		}
+
+		instances = append(instances, status)
+	}
+
+	// remove the record from all instances the DNSName is not managed in anymore
+	for _, previous := range dnsName.Status.Instances {
+		if containsInstanceRef(refs, previous.InstanceReference) {
+			continue
+		}
+
+		err := r.deleteDNSRecords(ctx, dnsName, previous.InstanceReference)
+		if err != nil {
+			reqLogger.Error(err, "Failed to delete DNS record", "Instance", previous.Name)
+
+			previous.Synced = false
+			previous.Error = err.Error()
+			instances = append(instances, previous)
+			errs = append(errs, err)
+		}
+	}
+
+	dnsName.Status.Instances = instances
+	setSyncConditions(dnsName, errs)
+
+	for _, err := range errs {
+		if kerrors.Is(err, errNotOwned) {
+			setCondition(dnsName, conditionConflict, v1.ConditionTrue, reasonConflict, err.Error())
+			break
+		}
+	}
+
+	if corrections > 0 {
+		setCondition(dnsName, conditionDrifted, v1.ConditionTrue, reasonDriftCorrected,
+			fmt.Sprintf("Restored %d DNS record(s) changed outside of the operator", corrections))
+	} else {
+		setCondition(dnsName, conditionDrifted, v1.ConditionFalse, reasonNoDrift,
+			"DNS records were not changed outside of the operator")
+	}
+
+	err = r.Status().Update(ctx, dnsName)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update DNSName status")
+		return ctrl.Result{}, err
+	}
+
+	return syncResult(errs)
+}
+
+// syncResult decides how a DNSName that failed to sync is retried. Records that
+// differ from the ones read before, e.g. because they have just been changed by
+// someone else, are synced again right away. Records the Pi-hole rejects as
+// invalid, features it doesn't support and records in the way that the operator
+// didn't create are not retried until the spec changes, everything else is
+// retried with exponential backoff.
+func syncResult(errs []error) (ctrl.Result, error) {
+	stale := false
+
+	for _, err := range errs {
+		switch {
+		case pihole.IsRetryable(err):
+			// the Pi-hole is unreachable or restarting, back off until it is back
+			return ctrl.Result{}, kerrors.Join(errs...)
+		case kerrors.Is(err, pihole.ErrNotFound), kerrors.Is(err, pihole.ErrConflict):
+			stale = true
+		case kerrors.Is(err, pihole.ErrInvalid), kerrors.Is(err, pihole.ErrUnsupported), kerrors.Is(err, errNotOwned):
+			// reported by the conditions, retrying won't help
+		default:
+			return ctrl.Result{}, kerrors.Join(errs...)
+		}
+	}
+
+	// the next sync reads the records again and doesn't repeat the failed change
+	return ctrl.Result{Requeue: stale}, nil
+}
+
+// resourceSyncResult decides how a resource managed in a single instance is
+// retried, like syncResult does for DNSNames. A resource referencing a group that
+// doesn't exist yet is retried every groupRetryInterval, as groups created in the
+// Pi-hole UI aren't watched. Synced resources are reconciled again after
+// resyncInterval to refresh their status, 0 disables that.
+func resourceSyncResult(err error, resyncInterval time.Duration) (ctrl.Result, error) {
+	switch {
+	case kerrors.Is(err, errGroupNotFound):
+		return ctrl.Result{RequeueAfter: groupRetryInterval}, nil
+	case err != nil:
+		return syncResult([]error{err})
+	}
+
+	return ctrl.Result{RequeueAfter: resyncInterval}, nil
+}
+
+// setSyncConditions sets the Ready, Synced and Degraded conditions from the
+// errors that occurred while syncing the instances in the status of dnsName.
+func setSyncConditions(dnsName *networkingv1alpha1.DNSName, errs []error) {
+	instances := dnsName.Status.Instances
+
+	if len(errs) == 0 {
+		now := v1.Now()
+		dnsName.Status.LastSyncedTime = &now
+
+		message := fmt.Sprintf("DNS record is synced to %d instance(s)", len(instances))
+		setCondition(dnsName, conditionReady, v1.ConditionTrue, reasonSynced, message)
+		setCondition(dnsName, conditionSynced, v1.ConditionTrue, reasonSynced, message)
+		setCondition(dnsName, conditionDegraded, v1.ConditionFalse, reasonSynced, message)
+
+		return
+	}
+
+	// the first error decides, it usually is the same for all instances anyway
+	reason := failureReason(errs[0])
+	message := fmt.Sprintf("DNS record failed to sync to %d of %d instance(s)", len(errs), len(instances))
+
+	setCondition(dnsName, conditionReady, v1.ConditionFalse, reason, message)
+	setCondition(dnsName, conditionSynced, v1.ConditionFalse, reason, message)
+
+	synced := 0
+	for _, instance := range instances {
+		if instance.Synced {
+			synced++
+		}
+	}
+
+	// the record still resolves if it is synced to at least one instance
+	if synced > 0 {
+		setCondition(dnsName, conditionDegraded, v1.ConditionTrue, reason, message)
+	} else {
+		setCondition(dnsName, conditionDegraded, v1.ConditionFalse, reason, message)
+	}
+}
+
+// yield gives up the domain of dnsName in favor of the winner of a conflict. The
+// records of dnsName are removed, so the winner can take over.
+func (r *DNSNameReconciler) yield(ctx context.Context, dnsName *networkingv1alpha1.DNSName, winner *networkingv1alpha1.DNSName) error {
+	reqLogger := log.FromContext(ctx)
+
+	message := fmt.Sprintf("Domain %s is already claimed by DNSName %s/%s", dnsName.Spec.Domain, winner.Namespace, winner.Name)
+	reqLogger.Info("DNSName conflicts with another one", "Winner", client.ObjectKeyFromObject(winner))
+
+	if !meta.IsStatusConditionTrue(dnsName.Status.Conditions, conditionConflict) {
+		r.Recorder.Event(dnsName, "Warning", "Conflict", message)
+	}
+
+	var errs []error
+	for _, previous := range dnsName.Status.Instances {
+		if err := r.deleteDNSRecords(ctx, dnsName, previous.InstanceReference); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete DNS record from %s %s: %w", previous.Kind, previous.Name, err))
+		}
+	}
+
+	if len(errs) == 0 {
+		dnsName.Status.Instances = nil
+	}
+
+	setCondition(dnsName, conditionConflict, v1.ConditionTrue, reasonConflict, message)
+	setNotReady(dnsName, reasonConflict, message)
+
+	if err := r.Status().Update(ctx, dnsName); err != nil {
+		reqLogger.Error(err, "Failed to update DNSName status")
+		return err
+	}
+
+	return kerrors.Join(errs...)
+}
+
+// instanceRefs returns all Pi-hole instances the DNSName is managed in.
+func (r *DNSNameReconciler) instanceRefs(ctx context.Context, dnsName *networkingv1alpha1.DNSName) ([]networkingv1alpha1.InstanceReference, error) {
+	var refs []networkingv1alpha1.InstanceReference
+
+	add := func(ref networkingv1alpha1.InstanceReference) {
+		if ref.Kind == "" {
+			ref.Kind = networkingv1alpha1.PiHoleInstanceKind
+		}
+		if !containsInstanceRef(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+
+	if dnsName.Spec.InstanceRef != nil {
+		add(*dnsName.Spec.InstanceRef)
+	}
+
+	for _, ref := range dnsName.Spec.InstanceRefs {
+		add(ref)
+	}
+
+	if dnsName.Spec.InstanceSelector != nil {
+		selected, err := r.PiHoles.Select(ctx, dnsName.Namespace, dnsName.Spec.InstanceSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range selected {
+			add(ref)
+		}
+	}
+
+	return refs, nil
+}
+
+// syncDNSRecords makes the records on the given instance match newRecords. Records
+// conflicting with the new ones are replaced by the missing ones in a single step,
+// so the domain keeps resolving, and records that already exist are left untouched.
+// Only records owned by the DNSName are changed, records created by someone else
+// are taken over if the adoption policy allows it. It returns the records of the domain as present on the instance
+// afterwards, which is nil if they couldn't be read, and the number of records
+// that were created or deleted.
+func (r *DNSNameReconciler) syncDNSRecords(ctx context.Context, dnsName *networkingv1alpha1.DNSName, ref networkingv1alpha1.InstanceReference, newRecords []pihole.DNSRecord) ([]pihole.DNSRecord, int, error) {
+	reqLogger := log.FromContext(ctx).WithValues("Instance", ref.Name)
+
+	piHole, err := r.PiHoles.Get(ctx, dnsName.Namespace, ref)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	records, err := piHole.GetDNSRecords(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var view []pihole.DNSRecord
+	changes := 0
 	for _, record := range records {
-		if record.Domain == newRecord.Domain {
-			if record.Equals(newRecord) {
-				reqLogger.Info("DNS record already exists")
-				return ctrl.Result{}, nil
+		if record.Domain == dnsName.Spec.Domain {
+			view = append(view, record)
+		}
+	}
+
+	instance := instanceKey(dnsName.Namespace, ref)
+	ownership, err := r.Registry.Get(ctx, instance)
+	if err != nil {
+		return view, changes, err
+	}
+
+	owner := ownerOf(dnsName)
+	adopt := dnsName.Spec.AdoptionPolicy == networkingv1alpha1.AdoptionPolicyAdopt
+
+	// checks whether the DNSName may change a record that is in its way
+	mayChange := func(record pihole.DNSRecord) error {
+		current, ok := ownership.OwnerOf(record)
+		switch {
+		case ok && current.UID == owner.UID:
+			return nil
+		case ok:
+			return fmt.Errorf("DNS record %s %s is owned by DNSName %s/%s", record.Type, record.Target, current.Namespace, current.Name)
+		case adopt:
+			r.Recorder.Eventf(dnsName, "Normal", "Adopted", "Adopted DNS record %s %s in %s %s", record.Type, record.Target, ref.Kind, ref.Name)
+			return nil
+		}
+
+		return fmt.Errorf("%w: DNS record %s %s was not created by the operator, set adoptionPolicy to Adopt to take it over", errNotOwned, record.Type, record.Target)
+	}
+
+	var present, outdated []pihole.DNSRecord
+	for _, record := range view {
+		if containsDNSRecord(newRecords, record) {
+			if err := mayChange(record); err != nil {
+				return view, changes, err
 			}
 
-			reqLogger.Info("DNS record needs update")
+			present = append(present, record)
+			continue
+		}
+
+		// records of the DNSName it doesn't want anymore are deleted even if they don't
+		// conflict with the new ones, e.g. the AAAA record of a removed IPv6 target
+		current, ok := ownership.OwnerOf(record)
+		owned := ok && current.UID == owner.UID
+		if !owned && !conflictsWithAny(newRecords, record) {
+			continue
+		}
 
-			err = r.PiHole.DeleteDNSRecord(record)
+		if err := mayChange(record); err != nil {
+			return view, changes, err
+		}
+
+		outdated = append(outdated, record)
+	}
+
+	// claim the records before creating them, so they are never left behind unowned.
+	// Another DNSName may have claimed them since the ownership was read above.
+	err = r.Registry.Update(ctx, instance, func(latest Ownership) error {
+		for _, record := range slices.Concat(newRecords, outdated) {
+			if current, ok := latest.OwnerOf(record); ok && current.UID != owner.UID {
+				return fmt.Errorf("%w: DNS record %s %s has been claimed by DNSName %s/%s", pihole.ErrConflict, record.Type, record.Target, current.Namespace, current.Name)
+			}
+
+			latest.Claim(record, owner)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return view, changes, err
+	}
+
+	var missing []pihole.DNSRecord
+	for _, newRecord := range newRecords {
+		if !containsDNSRecord(present, newRecord) {
+			missing = append(missing, newRecord)
+		}
+	}
+
+	var deleted, created []pihole.DNSRecord
+	if len(outdated) > 0 && len(missing) > 0 {
+		// replace the records in one go, so the domain keeps resolving in between
+		reqLogger.Info("Replacing outdated DNS records", "Outdated", len(outdated), "New", len(missing))
+
+		err = piHole.ReplaceDNSRecords(ctx, outdated, missing)
+		if err == nil {
+			deleted, created = outdated, missing
+		}
+	} else {
+		for _, record := range outdated {
+			reqLogger.Info("Deleting outdated DNS record", "Target", record.Target)
+
+			err = piHole.DeleteDNSRecord(ctx, record)
 			if err != nil {
-				reqLogger.Error(err, "Failed to delete DNS record")
-				return ctrl.Result{}, err
+				break
+			}
+
+			deleted = append(deleted, record)
+		}
+
+		for _, newRecord := range missing {
+			err = piHole.CreateDNSRecord(ctx, newRecord)
+			if err != nil {
+				break
 			}
+
+			created = append(created, newRecord)
 		}
 	}
 
-	// Create the DNS record
-	err = r.PiHole.CreateDNSRecord(*newRecord)
-	if err != nil {
-		reqLogger.Error(err, "Failed to create DNS record")
-		return ctrl.Result{}, err
+	for _, record := range deleted {
+		view = removeDNSRecord(view, record)
+		changes++
+	}
+
+	for _, newRecord := range created {
+		view = append(view, newRecord)
+		changes++
+
+		r.Recorder.Eventf(dnsName, "Normal", "Created", "Successfully created DNS record %s %s in %s %s", newRecord.Type, newRecord.Target, ref.Kind, ref.Name)
+		reqLogger.Info("Successfully created DNS record", "Target", newRecord.Target)
+	}
+
+	if releaseErr := r.release(ctx, instance, deleted...); releaseErr != nil && err == nil {
+		err = releaseErr
+	}
+
+	return view, changes, err
+}
+
+// release removes records from the ownership registry of an instance
+func (r *DNSNameReconciler) release(ctx context.Context, instance string, records ...pihole.DNSRecord) error {
+	if len(records) == 0 {
+		return nil
 	}
 
-	r.Recorder.Event(dnsName, "Normal", "Created", "Successfully created DNS record")
+	return r.Registry.Update(ctx, instance, func(ownership Ownership) error {
+		for _, record := range records {
+			ownership.Release(record)
+		}
 
-	// Update the status of the DNSName resource
-	dnsName.Status.Conditions = append(dnsName.Status.Conditions, v1.Condition{
-		Type:    "Created",
-		Status:  v1.ConditionTrue,
-		Reason:  "Created",
-		Message: "Successfully created DNS record",
+		return nil
 	})
+}
+
+// deleteDNSRecords deletes all records owned by the DNSName from the given instance.
+func (r *DNSNameReconciler) deleteDNSRecords(ctx context.Context, dnsName *networkingv1alpha1.DNSName, ref networkingv1alpha1.InstanceReference) error {
+	piHole, err := r.PiHoles.Get(ctx, dnsName.Namespace, ref)
+	if err != nil {
+		if isInstanceNotFound(err) {
+			// the instance is gone, there is nothing left to clean up
+			return nil
+		}
 
-	reqLogger.Info("Successfully created DNS record")
+		return err
+	}
+
+	records, err := piHole.GetDNSRecords(ctx)
+	if err != nil {
+		return err
+	}
 
-	return ctrl.Result{}, nil
+	instance := instanceKey(dnsName.Namespace, ref)
+	ownership, err := r.Registry.Get(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if owner, ok := ownership.OwnerOf(record); !ok || owner.UID != dnsName.UID {
+			continue
+		}
+
+		err := piHole.DeleteDNSRecord(ctx, record)
+		if err != nil {
+			return err
+		}
+	}
+
+	// forget everything owned by the DNSName, including records deleted by hand
+	return r.Registry.Update(ctx, instance, func(ownership Ownership) error {
+		ownership.ReleaseAll(dnsName.UID)
+
+		return nil
+	})
 }
 
 func (r *DNSNameReconciler) cleanupDNSRecord(ctx context.Context, dnsName *networkingv1alpha1.DNSName) error {
-	records, err := r.PiHole.GetDNSRecords()
+	refs, err := r.instanceRefs(ctx, dnsName)
 	if err != nil {
 		return err
 	}
 
-	for _, record := range records {
-		if record.Domain == dnsName.Spec.Domain {
-			err := r.PiHole.DeleteDNSRecord(record)
-			if err != nil {
-				return err
-			}
+	for _, previous := range dnsName.Status.Instances {
+		if !containsInstanceRef(refs, previous.InstanceReference) {
+			refs = append(refs, previous.InstanceReference)
+		}
+	}
+
+	var errs []error
+	for _, ref := range refs {
+		err := r.deleteDNSRecords(ctx, dnsName, ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete DNS record from %s %s: %w", ref.Kind, ref.Name, err))
 		}
 	}
 
+	if len(errs) > 0 {
+		return kerrors.Join(errs...)
+	}
+
 	controllerutil.RemoveFinalizer(dnsName, finalizerName)
 	err = r.Update(ctx, dnsName)
 	if err != nil {
@@ -181,10 +628,149 @@ func (r *DNSNameReconciler) cleanupDNSRecord(ctx context.Context, dnsName *netwo
 	return nil
 }
 
+func containsDNSRecord(records []pihole.DNSRecord, record pihole.DNSRecord) bool {
+	for i := range records {
+		if records[i].Equals(&record) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func conflictsWithAny(records []pihole.DNSRecord, record pihole.DNSRecord) bool {
+	for i := range records {
+		if records[i].Conflicts(&record) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func removeDNSRecord(records []pihole.DNSRecord, record pihole.DNSRecord) []pihole.DNSRecord {
+	result := make([]pihole.DNSRecord, 0, len(records))
+	for _, r := range records {
+		if !r.Equals(&record) {
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// addresses returns the targets of all A and AAAA records that are wanted
+func addresses(records []pihole.DNSRecord, wanted []pihole.DNSRecord) []networkingv1alpha1.IPAddressStr {
+	var addresses []networkingv1alpha1.IPAddressStr
+	for _, record := range records {
+		if record.Type != networkingv1alpha1.A && record.Type != networkingv1alpha1.AAAA {
+			continue
+		}
+
+		if containsDNSRecord(wanted, record) {
+			addresses = append(addresses, networkingv1alpha1.IPAddressStr(record.Target))
+		}
+	}
+
+	return addresses
+}
+
+// recordStatuses converts records to their representation in the status of a DNSName
+func recordStatuses(records []pihole.DNSRecord) []networkingv1alpha1.RecordStatus {
+	var statuses []networkingv1alpha1.RecordStatus
+	for _, record := range records {
+		statuses = append(statuses, networkingv1alpha1.RecordStatus{
+			Type:   record.Type,
+			Target: record.Target,
+			TTL:    record.TTL,
+		})
+	}
+
+	return statuses
+}
+
+// wasSynced is true if the DNSName was synced to the instance during the last reconciliation
+func wasSynced(dnsName *networkingv1alpha1.DNSName, ref networkingv1alpha1.InstanceReference) bool {
+	for _, instance := range dnsName.Status.Instances {
+		if instance.Kind == ref.Kind && instance.Name == ref.Name {
+			return instance.Synced
+		}
+	}
+
+	return false
+}
+
+func containsInstanceRef(refs []networkingv1alpha1.InstanceReference, ref networkingv1alpha1.InstanceReference) bool {
+	for _, r := range refs {
+		if r.Kind == ref.Kind && r.Name == ref.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dnsNamesForInstance maps a PiHoleInstance to all DNSNames in its namespace and a
+// ClusterPiHoleInstance to all DNSNames, so that selectors pick up new instances.
+func (r *DNSNameReconciler) dnsNamesForInstance(ctx context.Context, obj client.Object) []reconcile.Request {
+	var opts []client.ListOption
+	if obj.GetNamespace() != "" {
+		opts = append(opts, client.InNamespace(obj.GetNamespace()))
+	}
+
+	dnsNames := &networkingv1alpha1.DNSNameList{}
+	if err := r.List(ctx, dnsNames, opts...); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list DNSNames")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(dnsNames.Items))
+	for _, dnsName := range dnsNames.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&dnsName)})
+	}
+
+	return requests
+}
+
+// ignoreStatusUpdates drops update events that only changed the status of an
+// object, otherwise every status update would trigger another reconciliation of it
+// or, for instances, of every resource on the instance.
+var ignoreStatusUpdates = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() ||
+			!e.ObjectOld.GetDeletionTimestamp().Equal(e.ObjectNew.GetDeletionTimestamp())
+	},
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *DNSNameReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&networkingv1alpha1.DNSName{}).
-		WithOptions(controller.Options{MaxConcurrentReconciles: 1}).
-		Complete(r)
+	maxConcurrentReconciles := r.MaxConcurrentReconciles
+	if maxConcurrentReconciles < 1 {
+		maxConcurrentReconciles = 1
+	}
+
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &networkingv1alpha1.DNSName{}, domainIndexKey, indexDNSNameDomain)
+	if err != nil {
+		return err
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1alpha1.DNSName{}, builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.DNSName{}, r.claimsOnDomain()).
+		Watches(&networkingv1alpha1.PiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.dnsNamesForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.ClusterPiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.dnsNamesForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: maxConcurrentReconciles})
+
+	if r.ResyncInterval > 0 {
+		detector := NewDriftDetector(mgr.GetClient(), r.PiHoles, r.ResyncInterval)
+		if err := mgr.Add(detector); err != nil {
+			return err
+		}
+
+		b = b.WatchesRawSource(detector.Source())
+	}
+
+	return b.Complete(r)
 }
@@ -18,21 +18,34 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
 )
 
 var _ = Describe("DNSName Controller", func() {
 	Context("When reconciling a resource", func() {
 		const resourceName = "test-resource"
+		const instanceName = "test-instance"
 
 		ctx := context.Background()
 
@@ -40,44 +53,733 @@ var _ = Describe("DNSName Controller", func() {
 			Name:      resourceName,
 			Namespace: "default",
 		}
-		dnsname := &networkingv1alpha1.DNSName{}
+
+		var piHole *fake.PiHole
+		var controllerReconciler *DNSNameReconciler
+
+		reconcileDNSName := func() error {
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+
+			return err
+		}
+
+		target := func(t string) *networkingv1alpha1.IPAddressStr {
+			ip := networkingv1alpha1.IPAddressStr(t)
+			return &ip
+		}
 
 		BeforeEach(func() {
-			By("creating the custom resource for the Kind DNSName")
-			err := k8sClient.Get(ctx, typeNamespacedName, dnsname)
-			if err != nil && errors.IsNotFound(err) {
-				resource := &networkingv1alpha1.DNSName{
-					ObjectMeta: metav1.ObjectMeta{
-						Name:      resourceName,
-						Namespace: "default",
+			piHole = fake.NewPiHole()
+			controllerReconciler = &DNSNameReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				PiHoles: &PiHoleClients{
+					Client:       k8sClient,
+					SecretReader: k8sClient,
+					NewClient: func(pihole.Config) pihole.Client {
+						return piHole
 					},
-				}
-				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+				},
+				Registry: &OwnershipRegistry{
+					Client:    k8sClient,
+					Reader:    k8sClient,
+					Namespace: "default",
+				},
+			}
+
+			By("creating the Pi-hole instance")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				StringData: map[string]string{"password": "secret"},
 			}
+			err := k8sClient.Create(ctx, secret)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			instance := &networkingv1alpha1.PiHoleInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+				Spec: networkingv1alpha1.PiHoleInstanceSpec{
+					URL:                  "http://pi.hole/api",
+					AppPasswordSecretRef: networkingv1alpha1.SecretKeyReference{Name: instanceName, Key: "password"},
+				},
+			}
+			err = k8sClient.Create(ctx, instance)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("creating the custom resource for the Kind DNSName")
+			resource := &networkingv1alpha1.DNSName{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: networkingv1alpha1.DNSNameSpec{
+					InstanceRef: &networkingv1alpha1.InstanceReference{Name: instanceName},
+					Type:        networkingv1alpha1.A,
+					Domain:      "foobar.com",
+					TargetIP:    target("192.168.178.1"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
 		})
 
 		AfterEach(func() {
+			By("Cleanup the ownership registry")
+			Expect(k8sClient.DeleteAllOf(ctx, &corev1.ConfigMap{}, client.InNamespace("default"),
+				client.MatchingLabels{"app.kubernetes.io/managed-by": "pihole-operator"})).To(Succeed())
+
 			// Cleanup logic after each test, like removing the resource instance.
 			resource := &networkingv1alpha1.DNSName{}
 			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			if errors.IsNotFound(err) {
+				return
+			}
 			Expect(err).NotTo(HaveOccurred())
 
 			By("Cleanup the specific resource instance DNSName")
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
 			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
 		})
-		It("should successfully reconcile the resource", func() {
-			By("Reconciling the created resource")
-			controllerReconciler := &DNSNameReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+
+		It("should create the DNS record", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Records()).To(ConsistOf(pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "192.168.178.1",
+				Type:   networkingv1alpha1.A,
+			}))
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Finalizers).To(ContainElement(finalizerName))
+			Expect(resource.Status.Instances).To(ConsistOf(networkingv1alpha1.InstanceStatus{
+				InstanceReference: networkingv1alpha1.InstanceReference{
+					Kind: networkingv1alpha1.PiHoleInstanceKind,
+					Name: instanceName,
+				},
+				Synced:    true,
+				Addresses: []networkingv1alpha1.IPAddressStr{"192.168.178.1"},
+				Records: []networkingv1alpha1.RecordStatus{
+					{Type: networkingv1alpha1.A, Target: "192.168.178.1"},
+				},
+			}))
+		})
+
+		It("should report a synced DNSName as ready", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ObservedGeneration).To(Equal(resource.Generation))
+			Expect(resource.Status.LastSyncedTime).NotTo(BeNil())
+			Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, "Ready")).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(resource.Status.Conditions, "Synced")).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(resource.Status.Conditions, "Degraded")).To(BeTrue())
+
+			for _, condition := range resource.Status.Conditions {
+				Expect(condition.ObservedGeneration).To(Equal(resource.Generation))
 			}
+		})
 
-			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-				NamespacedName: typeNamespacedName,
+		It("should report records of the domain it doesn't manage", func() {
+			Expect(piHole.CreateDNSRecord(ctx, pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "2001:db8::1",
+				Type:   networkingv1alpha1.AAAA,
+			})).To(Succeed())
+			Expect(piHole.CreateDNSRecord(ctx, pihole.DNSRecord{
+				Domain: "other.com",
+				Target: "192.168.178.9",
+				Type:   networkingv1alpha1.A,
+			})).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Instances).To(HaveLen(1))
+			Expect(resource.Status.Instances[0].Addresses).To(ConsistOf(networkingv1alpha1.IPAddressStr("192.168.178.1")))
+			Expect(resource.Status.Instances[0].Records).To(ConsistOf(
+				networkingv1alpha1.RecordStatus{Type: networkingv1alpha1.AAAA, Target: "2001:db8::1"},
+				networkingv1alpha1.RecordStatus{Type: networkingv1alpha1.A, Target: "192.168.178.1"},
+			))
+		})
+
+		It("should report an invalid spec without retrying", func() {
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Type = networkingv1alpha1.CName
+			resource.Spec.TargetIP = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Records()).To(BeEmpty())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ObservedGeneration).To(Equal(resource.Generation))
+			condition := meta.FindStatusCondition(resource.Status.Conditions, "Ready")
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal("InvalidSpec"))
+			Expect(condition.Message).To(ContainSubstring("target is required"))
+		})
+
+		DescribeTable("reporting the reason of a failure",
+			func(err error, reason string) {
+				piHole.SetError(fake.GetDNSRecords, err)
+
+				Expect(reconcileDNSName()).To(HaveOccurred())
+
+				resource := &networkingv1alpha1.DNSName{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				for _, conditionType := range []string{"Ready", "Synced"} {
+					condition := meta.FindStatusCondition(resource.Status.Conditions, conditionType)
+					Expect(condition).NotTo(BeNil())
+					Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+					Expect(condition.Reason).To(Equal(reason))
+				}
+				Expect(resource.Status.LastSyncedTime).To(BeNil())
+			},
+			Entry("wrong password", fmt.Errorf("%w: wrong app password", pihole.ErrUnauthorized), "AuthFailed"),
+			Entry("missing TOTP secret", pihole.ErrTOTPRequired, "AuthFailed"),
+			Entry("connection refused",
+				&url.Error{Op: "Get", URL: "http://pi.hole/api/config/dns/hosts", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+				"PiHoleUnreachable"),
+			Entry("Pi-hole restarting", &pihole.APIError{StatusCode: http.StatusServiceUnavailable}, "PiHoleUnreachable"),
+			Entry("anything else", fmt.Errorf("pi-hole is down"), "SyncFailed"),
+		)
+
+		DescribeTable("retrying a failed sync",
+			func(err error, requeue bool, fails bool, reason string) {
+				piHole.SetError(fake.CreateDNSRecord, err)
+
+				result, reconcileErr := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				if fails {
+					Expect(reconcileErr).To(MatchError(err))
+				} else {
+					Expect(reconcileErr).NotTo(HaveOccurred())
+				}
+				Expect(result.Requeue).To(Equal(requeue))
+
+				resource := &networkingv1alpha1.DNSName{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				condition := meta.FindStatusCondition(resource.Status.Conditions, "Ready")
+				Expect(condition).NotTo(BeNil())
+				Expect(condition.Reason).To(Equal(reason))
+			},
+			Entry("backs off while the Pi-hole is unavailable",
+				&pihole.APIError{StatusCode: http.StatusServiceUnavailable}, false, true, "PiHoleUnreachable"),
+			Entry("requeues when the records changed in the meantime",
+				fmt.Errorf("failed to create DNS record: %w", pihole.ErrConflict), true, false, "SyncFailed"),
+			Entry("gives up on records the Pi-hole rejects",
+				&pihole.APIError{StatusCode: http.StatusBadRequest, Message: "Invalid value"}, false, false, "InvalidSpec"),
+			Entry("backs off on wrong credentials",
+				fmt.Errorf("%w: wrong app password", pihole.ErrUnauthorized), false, true, "AuthFailed"),
+		)
+
+		It("should override the retry policy of the operator per instance", func() {
+			var config pihole.Config
+			controllerReconciler.PiHoles.Retry = pihole.DefaultRetryPolicy
+			controllerReconciler.PiHoles.NewClient = func(c pihole.Config) pihole.Client {
+				config = c
+				return piHole
+			}
+
+			instance := &networkingv1alpha1.PiHoleInstance{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceName, Namespace: "default"}, instance)).To(Succeed())
+			maxAttempts := int32(5)
+			instance.Spec.Retry = &networkingv1alpha1.RetryPolicy{MaxAttempts: &maxAttempts}
+			Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceName, Namespace: "default"}, instance)).To(Succeed())
+				instance.Spec.Retry = nil
+				Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+			})
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(config.Retry.MaxAttempts).To(Equal(5))
+			Expect(config.Retry.InitialBackoff).To(Equal(pihole.DefaultRetryPolicy.InitialBackoff))
+			Expect(config.Retry.BudgetPercent).To(Equal(pihole.DefaultRetryPolicy.BudgetPercent))
+		})
+
+		It("should report a DNSName synced to some of its instances as degraded", func() {
+			const otherInstanceName = "other-instance"
+
+			other := fake.NewPiHole()
+			other.SetError(fake.GetDNSRecords, fmt.Errorf("pi-hole is down"))
+			controllerReconciler.PiHoles.NewClient = func(config pihole.Config) pihole.Client {
+				if config.URL == "http://other.pi.hole/api" {
+					return other
+				}
+				return piHole
+			}
+
+			instance := &networkingv1alpha1.PiHoleInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: otherInstanceName, Namespace: "default"},
+				Spec: networkingv1alpha1.PiHoleInstanceSpec{
+					URL:                  "http://other.pi.hole/api",
+					AppPasswordSecretRef: networkingv1alpha1.SecretKeyReference{Name: instanceName, Key: "password"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Delete(ctx, instance)).To(Succeed())
+			})
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.InstanceRefs = []networkingv1alpha1.InstanceReference{{Name: otherInstanceName}}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(MatchError(ContainSubstring("pi-hole is down")))
+
+			Expect(piHole.Records()).To(HaveLen(1))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(meta.IsStatusConditionFalse(resource.Status.Conditions, "Ready")).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(resource.Status.Conditions, "Synced")).To(BeTrue())
+			condition := meta.FindStatusCondition(resource.Status.Conditions, "Degraded")
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Message).To(ContainSubstring("1 of 2"))
+		})
+
+		It("should not touch an existing record", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Calls(fake.CreateDNSRecord)).To(Equal(1))
+			Expect(piHole.Calls(fake.DeleteDNSRecord)).To(Equal(0))
+		})
+
+		It("should update the DNS record when the spec changes", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.TargetIP = target("192.168.178.2")
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Records()).To(ConsistOf(pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "192.168.178.2",
+				Type:   networkingv1alpha1.A,
+			}))
+		})
+
+		It("should keep the old record when replacing it fails", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.TargetIP = target("192.168.178.2")
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			piHole.SetError(fake.ReplaceDNSRecords, fmt.Errorf("pi-hole is down"))
+			Expect(reconcileDNSName()).To(MatchError(ContainSubstring("pi-hole is down")))
+
+			// the domain still resolves to the old address
+			Expect(piHole.Records()).To(ConsistOf(pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "192.168.178.1",
+				Type:   networkingv1alpha1.A,
+			}))
+			Expect(piHole.Calls(fake.DeleteDNSRecord)).To(Equal(0))
+		})
+
+		adopt := func() {
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.AdoptionPolicy = networkingv1alpha1.AdoptionPolicyAdopt
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+		}
+
+		It("should not touch a conflicting record it didn't create", func() {
+			existing := pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "10.0.0.1",
+				Type:   networkingv1alpha1.A,
+			}
+			Expect(piHole.CreateDNSRecord(ctx, existing)).To(Succeed())
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Requeue).To(BeFalse())
+
+			Expect(piHole.Records()).To(ConsistOf(existing))
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			condition := meta.FindStatusCondition(resource.Status.Conditions, "Conflict")
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Message).To(ContainSubstring("adoptionPolicy"))
+			Expect(meta.FindStatusCondition(resource.Status.Conditions, "Ready").Reason).To(Equal("Conflict"))
+		})
+
+		It("should replace a conflicting record when adopting it", func() {
+			Expect(piHole.CreateDNSRecord(ctx, pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "10.0.0.1",
+				Type:   networkingv1alpha1.A,
+			})).To(Succeed())
+			adopt()
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Records()).To(ConsistOf(pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "192.168.178.1",
+				Type:   networkingv1alpha1.A,
+			}))
+		})
+
+		It("should not take over a record claimed concurrently by another DNSName", func() {
+			other := Owner{UID: "other-uid", Namespace: "default", Name: "other"}
+			record := pihole.DNSRecord{Domain: "foobar.com", Target: "192.168.178.1", Type: networkingv1alpha1.A}
+
+			// the other DNSName claims the record right after the ownership has been read
+			controllerReconciler.Registry.Reader = &racingReader{Reader: k8sClient, race: func() {
+				defer GinkgoRecover()
+
+				Expect(controllerReconciler.Registry.Update(ctx, instanceKey("default", networkingv1alpha1.InstanceReference{Name: instanceName}),
+					func(ownership Ownership) error {
+						ownership.Claim(record, other)
+
+						return nil
+					})).To(Succeed())
+			}}
+
+			Expect(reconcileDNSName()).To(Succeed())
+			Expect(piHole.Records()).To(BeEmpty())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Instances).To(HaveLen(1))
+			Expect(resource.Status.Instances[0].Error).To(ContainSubstring("has been claimed by DNSName default/other"))
+		})
+
+		It("should keep an AAAA record of the same domain", func() {
+			aaaa := pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "2001:db8::1",
+				Type:   networkingv1alpha1.AAAA,
+			}
+			Expect(piHole.CreateDNSRecord(ctx, aaaa)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Records()).To(ConsistOf(aaaa, pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "192.168.178.1",
+				Type:   networkingv1alpha1.A,
+			}))
+		})
+
+		It("should only change the differing addresses of a DNSName with targetIPs", func() {
+			Expect(piHole.CreateDNSRecord(ctx, pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "192.168.178.1",
+				Type:   networkingv1alpha1.A,
+			})).To(Succeed())
+			Expect(piHole.CreateDNSRecord(ctx, pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "192.168.178.3",
+				Type:   networkingv1alpha1.A,
+			})).To(Succeed())
+			adopt()
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.TargetIP = nil
+			resource.Spec.TargetIPs = []networkingv1alpha1.IPAddressStr{"192.168.178.1", "192.168.178.2", "2001:db8::1"}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Records()).To(ConsistOf(
+				pihole.DNSRecord{Domain: "foobar.com", Target: "192.168.178.1", Type: networkingv1alpha1.A},
+				pihole.DNSRecord{Domain: "foobar.com", Target: "192.168.178.2", Type: networkingv1alpha1.A},
+				pihole.DNSRecord{Domain: "foobar.com", Target: "2001:db8::1", Type: networkingv1alpha1.AAAA},
+			))
+			// the existing address is kept, the outdated one is replaced by two new ones at once
+			Expect(piHole.Calls(fake.ReplaceDNSRecords)).To(Equal(1))
+			Expect(piHole.Calls(fake.DeleteDNSRecord)).To(Equal(0))
+			Expect(piHole.Calls(fake.CreateDNSRecord)).To(Equal(2))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Instances).To(HaveLen(1))
+			Expect(resource.Status.Instances[0].Addresses).To(ConsistOf(
+				networkingv1alpha1.IPAddressStr("192.168.178.1"),
+				networkingv1alpha1.IPAddressStr("192.168.178.2"),
+				networkingv1alpha1.IPAddressStr("2001:db8::1"),
+			))
+		})
+
+		It("should delete the records of an address family removed from targetIPs", func() {
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.TargetIP = nil
+			resource.Spec.TargetIPs = []networkingv1alpha1.IPAddressStr{"192.168.178.1", "2001:db8::1"}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+			Expect(piHole.Records()).To(HaveLen(2))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.TargetIPs = []networkingv1alpha1.IPAddressStr{"192.168.178.1"}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+			Expect(piHole.Records()).To(ConsistOf(
+				pihole.DNSRecord{Domain: "foobar.com", Target: "192.168.178.1", Type: networkingv1alpha1.A},
+			))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.TargetIPs = []networkingv1alpha1.IPAddressStr{"2001:db8::2"}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+			Expect(piHole.Records()).To(ConsistOf(
+				pihole.DNSRecord{Domain: "foobar.com", Target: "2001:db8::2", Type: networkingv1alpha1.AAAA},
+			))
+		})
+
+		It("should let the oldest DNSName win a conflict", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+
+			By("creating a second DNSName for the same domain")
+			loser := &networkingv1alpha1.DNSName{
+				ObjectMeta: metav1.ObjectMeta{Name: "conflicting", Namespace: "default"},
+				Spec: networkingv1alpha1.DNSNameSpec{
+					InstanceRef: &networkingv1alpha1.InstanceReference{Name: instanceName},
+					Type:        networkingv1alpha1.A,
+					Domain:      "foobar.com",
+					TargetIP:    target("192.168.178.2"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, loser)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(loser), loser)).To(Succeed())
+				loser.Finalizers = nil
+				Expect(k8sClient.Update(ctx, loser)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, loser)).To(Succeed())
 			})
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(loser)})
 			Expect(err).NotTo(HaveOccurred())
-			// Add more specific assertions depending on your controller's reconciliation logic.
-			// Example: If you expect a certain status condition after reconciliation, verify it here.
+
+			Expect(piHole.Records()).To(ConsistOf(pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "192.168.178.1",
+				Type:   networkingv1alpha1.A,
+			}))
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(loser), loser)).To(Succeed())
+			condition := meta.FindStatusCondition(loser.Status.Conditions, "Conflict")
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Message).To(ContainSubstring("default/" + resourceName))
+
+			By("reconciling the winner again")
+			Expect(reconcileDNSName()).To(Succeed())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(meta.IsStatusConditionFalse(resource.Status.Conditions, "Conflict")).To(BeTrue())
+		})
+
+		It("should not conflict with a DNSName using a namesake instance in another namespace", func() {
+			By("creating an instance of the same name in another namespace")
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+			err := k8sClient.Create(ctx, namespace)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "other"},
+				StringData: map[string]string{"password": "secret"},
+			}
+			err = k8sClient.Create(ctx, secret)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			instance := &networkingv1alpha1.PiHoleInstance{
+				ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "other"},
+				Spec: networkingv1alpha1.PiHoleInstanceSpec{
+					URL:                  "http://other.pi.hole/api",
+					AppPasswordSecretRef: networkingv1alpha1.SecretKeyReference{Name: instanceName, Key: "password"},
+				},
+			}
+			err = k8sClient.Create(ctx, instance)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("creating a DNSName for the same domain in the other namespace")
+			other := &networkingv1alpha1.DNSName{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "other"},
+				Spec: networkingv1alpha1.DNSNameSpec{
+					InstanceRef: &networkingv1alpha1.InstanceReference{Name: instanceName},
+					Type:        networkingv1alpha1.A,
+					Domain:      "foobar.com",
+					TargetIP:    target("192.168.178.2"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, other)).To(Succeed())
+			DeferCleanup(func() {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+				other.Finalizers = nil
+				Expect(k8sClient.Update(ctx, other)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, other)).To(Succeed())
+			})
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(other)})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(piHole.Records()).To(ConsistOf(pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "192.168.178.2",
+				Type:   networkingv1alpha1.A,
+			}))
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+			Expect(meta.IsStatusConditionFalse(other.Status.Conditions, "Conflict")).To(BeTrue())
+		})
+
+		It("should restore a record deleted outside of the operator", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+
+			record := pihole.DNSRecord{Domain: "foobar.com", Target: "192.168.178.1", Type: networkingv1alpha1.A}
+			Expect(piHole.DeleteDNSRecord(ctx, record)).To(Succeed())
+			corrections := testutil.ToFloat64(driftCorrections.WithLabelValues("PiHoleInstance/default/" + instanceName))
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Records()).To(ConsistOf(record))
+			Expect(testutil.ToFloat64(driftCorrections.WithLabelValues("PiHoleInstance/default/" + instanceName))).To(Equal(corrections + 1))
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			condition := meta.FindStatusCondition(resource.Status.Conditions, "Drifted")
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+			Expect(condition.Reason).To(Equal("DriftCorrected"))
+		})
+
+		It("should not report the initial sync as drift", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(meta.IsStatusConditionFalse(resource.Status.Conditions, "Drifted")).To(BeTrue())
+		})
+
+		It("should report a failing Pi-hole in the status", func() {
+			piHole.SetError(fake.CreateDNSRecord, fmt.Errorf("pi-hole is down"))
+
+			Expect(reconcileDNSName()).To(MatchError(ContainSubstring("pi-hole is down")))
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Instances).To(HaveLen(1))
+			Expect(resource.Status.Instances[0].Synced).To(BeFalse())
+			Expect(resource.Status.Instances[0].Error).To(ContainSubstring("pi-hole is down"))
+		})
+
+		It("should delete the DNS record when the resource is deleted", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Records()).To(BeEmpty())
+			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should keep the DNS record while the password Secret of the instance is missing", func() {
+			Expect(reconcileDNSName()).To(Succeed())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			// recreated by the next BeforeEach
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"}}
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+
+			Expect(reconcileDNSName()).NotTo(Succeed())
+
+			Expect(piHole.Records()).To(HaveLen(1))
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Finalizers).To(ContainElement(finalizerName))
+		})
+
+		It("should only delete its own records when the resource is deleted", func() {
+			aaaa := pihole.DNSRecord{
+				Domain: "foobar.com",
+				Target: "2001:db8::1",
+				Type:   networkingv1alpha1.AAAA,
+			}
+			Expect(piHole.CreateDNSRecord(ctx, aaaa)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			Expect(reconcileDNSName()).To(Succeed())
+
+			Expect(piHole.Records()).To(ConsistOf(aaaa))
+		})
+
+		It("should report a timeout when the Pi-hole doesn't answer", func() {
+			piHole.SetError(fake.GetDNSRecords, fmt.Errorf("failed to get DNS records: %w", context.DeadlineExceeded))
+
+			Expect(reconcileDNSName()).To(MatchError(context.DeadlineExceeded))
+
+			resource := &networkingv1alpha1.DNSName{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			condition := meta.FindStatusCondition(resource.Status.Conditions, "Synced")
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal("Timeout"))
 		})
 	})
 })
+
+// racingReader calls race once after the first ConfigMap has been read, as if
+// another reconciliation changed the ownership right after it was read
+type racingReader struct {
+	client.Reader
+	race func()
+}
+
+func (r *racingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	err := r.Reader.Get(ctx, key, obj, opts...)
+	if _, ok := obj.(*corev1.ConfigMap); ok && r.race != nil {
+		race := r.race
+		r.race = nil
+		race()
+	}
+
+	return err
+}
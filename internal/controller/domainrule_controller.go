@@ -0,0 +1,374 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	kerrors "errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const domainRuleFinalizerName = "domainrule.networking.liebler.dev/finalizer"
+
+// DomainRuleReconciler reconciles a DomainRule object
+type DomainRuleReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	PiHoles  *PiHoleClients
+	// Registry records which DomainRule created or adopted which entry on a Pi-hole
+	Registry *OwnershipRegistry
+
+	// ResyncInterval is the interval in which rules changed in the Pi-hole UI are restored, 0 disables it
+	ResyncInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=domainrules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=domainrules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=domainrules/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=groups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=clusterpiholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;update
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// Reconcile adds the domain of a DomainRule to the allow or deny list of its
+// Pi-hole. Regular expressions are validated before they are sent, FTL would
+// accept a broken one and only complain in its log. An entry with the same domain,
+// kind and match that already exists on the Pi-hole is only taken over and removed
+// with the DomainRule if its adoption policy allows it.
+func (r *DomainRuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	rule := &networkingv1alpha1.DomainRule{}
+	err := r.Get(ctx, req.NamespacedName, rule)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("DomainRule resource not found. Ignoring since object must be deleted.")
+
+			return ctrl.Result{}, nil
+		}
+
+		reqLogger.Error(err, "Failed to get DomainRule")
+		return ctrl.Result{}, err
+	}
+
+	reqLogger.Info("Reconciling DomainRule", "Name", rule.Name)
+
+	if rule.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(rule, domainRuleFinalizerName) {
+			controllerutil.AddFinalizer(rule, domainRuleFinalizerName)
+			err = r.Update(ctx, rule)
+			if err != nil {
+				reqLogger.Error(err, "Failed to update DomainRule with finalizer")
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if controllerutil.ContainsFinalizer(rule, domainRuleFinalizerName) {
+			reqLogger.Info("Deleting domain rule")
+
+			err = r.cleanupDomain(ctx, rule)
+			if err != nil {
+				reqLogger.Error(err, "Failed to cleanup domain rule")
+				return ctrl.Result{}, err
+			}
+		}
+
+		// Stop reconciliation as the item is being deleted
+		return ctrl.Result{}, nil
+	}
+
+	rule.Status.ObservedGeneration = rule.Generation
+
+	domain, syncErr := r.syncDomain(ctx, rule)
+	if syncErr != nil {
+		reqLogger.Error(syncErr, "Failed to sync domain rule")
+		r.Recorder.Eventf(rule, "Warning", "SyncFailed", "Failed to sync domain rule to %s %s: %v", rule.Spec.InstanceRef.Kind, rule.Spec.InstanceRef.Name, syncErr)
+	} else {
+		rule.Status.ID = int32(domain.ID)
+		rule.Status.Applied = appliedDomainRule(domain)
+	}
+
+	setConflict(&rule.Status.Conditions, rule.Generation, syncErr, "Domain rule is managed by the operator")
+	setReady(&rule.Status.Conditions, rule.Generation, syncErr,
+		fmt.Sprintf("Domain rule is active in %s %s", rule.Spec.InstanceRef.Kind, rule.Spec.InstanceRef.Name))
+
+	err = r.Status().Update(ctx, rule)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update DomainRule status")
+		return ctrl.Result{}, err
+	}
+
+	return resourceSyncResult(syncErr, r.ResyncInterval)
+}
+
+// syncDomain creates or updates the entry of the rule on the Pi-hole and returns it
+// as stored there. An entry applied for a previous domain, kind or match is removed
+// once the new one has been created.
+func (r *DomainRuleReconciler) syncDomain(ctx context.Context, rule *networkingv1alpha1.DomainRule) (pihole.Domain, error) {
+	ref := rule.Spec.InstanceRef
+
+	wanted := pihole.Domain{
+		Domain:  rule.Spec.Domain,
+		Type:    domainType(rule.Spec.Kind),
+		Kind:    domainKind(rule.Spec.Match),
+		Comment: rule.Spec.Comment,
+		Enabled: rule.Spec.Enabled == nil || *rule.Spec.Enabled,
+	}
+
+	// a broken regex must never reach FTL
+	if err := pihole.ValidateDomain(wanted.Domain, wanted.Kind); err != nil {
+		return pihole.Domain{}, err
+	}
+
+	domains, err := r.PiHoles.Domains(ctx, rule.Namespace, ref)
+	if err != nil {
+		return pihole.Domain{}, err
+	}
+
+	wanted.Groups, err = resolveGroups(ctx, r.PiHoles, rule.Namespace, ref, rule.Spec.Groups)
+	if err != nil {
+		return pihole.Domain{}, err
+	}
+
+	existing, err := domains.GetDomains(ctx)
+	if err != nil {
+		return pihole.Domain{}, err
+	}
+
+	instance := instanceKey(rule.Namespace, ref)
+	key := domainKey(wanted)
+
+	var synced pihole.Domain
+	i := slices.IndexFunc(existing, func(d pihole.Domain) bool { return sameDomain(d, wanted) })
+	if i < 0 {
+		// claim the entry before creating it, so it is never left behind unowned
+		if err := r.Registry.claim(ctx, instance, key, rule, "DomainRule"); err != nil {
+			return pihole.Domain{}, fmt.Errorf("domain %q: %w", wanted.Domain, err)
+		}
+
+		synced, err = domains.CreateDomain(ctx, wanted)
+		if err != nil {
+			return pihole.Domain{}, err
+		}
+
+		r.Recorder.Eventf(rule, "Normal", "Created", "Successfully created %s %s domain %q in %s %s", wanted.Kind, wanted.Type, wanted.Domain, ref.Kind, ref.Name)
+	} else {
+		owned, err := r.Registry.owns(ctx, instance, key, rule)
+		if err != nil {
+			return pihole.Domain{}, err
+		}
+
+		if !owned {
+			if rule.Spec.AdoptionPolicy != networkingv1alpha1.AdoptionPolicyAdopt {
+				return pihole.Domain{}, fmt.Errorf("%w: %s %s domain %q in %s %s was not created by the operator, set adoptionPolicy to Adopt to take it over",
+					errNotOwned, wanted.Kind, wanted.Type, wanted.Domain, ref.Kind, ref.Name)
+			}
+
+			if err := r.Registry.claim(ctx, instance, key, rule, "DomainRule"); err != nil {
+				return pihole.Domain{}, fmt.Errorf("domain %q: %w", wanted.Domain, err)
+			}
+
+			r.Recorder.Eventf(rule, "Normal", "Adopted", "Adopted existing %s %s domain %q in %s %s", wanted.Kind, wanted.Type, wanted.Domain, ref.Kind, ref.Name)
+		}
+
+		synced = existing[i]
+		if !domainUpToDate(existing[i], wanted) {
+			synced, err = domains.UpdateDomain(ctx, wanted)
+			if err != nil {
+				return pihole.Domain{}, err
+			}
+		}
+	}
+
+	if previous := rule.Status.Applied; previous != nil && !appliedEquals(previous, wanted) {
+		err = r.deleteOwnedDomain(ctx, domains, rule, previous)
+		if err != nil && !kerrors.Is(err, pihole.ErrNotFound) {
+			return synced, fmt.Errorf("failed to remove the previous entry of the rule: %w", err)
+		}
+	}
+
+	return synced, nil
+}
+
+// cleanupDomain removes the entry of the rule from the Pi-hole and the finalizer from the DomainRule.
+// Only the entry recorded in the status is removed, and only if it was created or adopted by the rule.
+func (r *DomainRuleReconciler) cleanupDomain(ctx context.Context, rule *networkingv1alpha1.DomainRule) error {
+	domains, err := r.PiHoles.Domains(ctx, rule.Namespace, rule.Spec.InstanceRef)
+	if err == nil && rule.Status.Applied != nil {
+		err = r.deleteOwnedDomain(ctx, domains, rule, rule.Status.Applied)
+	}
+
+	switch {
+	case isInstanceNotFound(err):
+		// the instance is gone, there is nothing left to clean up
+	case kerrors.Is(err, pihole.ErrNotFound), kerrors.Is(err, pihole.ErrUnsupported), kerrors.Is(err, pihole.ErrInvalid):
+		// the entry has already been removed or was never created by the rule
+	case err != nil:
+		return err
+	}
+
+	// forget the claims of the rule, including entries whose creation failed
+	if err := r.Registry.releaseAll(ctx, instanceKey(rule.Namespace, rule.Spec.InstanceRef), rule); err != nil {
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(rule, domainRuleFinalizerName)
+
+	return r.Update(ctx, rule)
+}
+
+// deleteOwnedDomain deletes an entry applied by the rule from the Pi-hole and forgets its owner if
+// it has been created or adopted by the rule, it fails with pihole.ErrNotFound otherwise
+func (r *DomainRuleReconciler) deleteOwnedDomain(ctx context.Context, domains pihole.DomainClient, rule *networkingv1alpha1.DomainRule, applied *networkingv1alpha1.AppliedDomainRule) error {
+	instance := instanceKey(rule.Namespace, rule.Spec.InstanceRef)
+	entry := pihole.Domain{Domain: applied.Domain, Type: domainType(applied.Kind), Kind: domainKind(applied.Match)}
+	key := domainKey(entry)
+
+	owned, err := r.Registry.owns(ctx, instance, key, rule)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return pihole.ErrNotFound
+	}
+
+	err = domains.DeleteDomain(ctx, entry.Domain, entry.Type, entry.Kind)
+	if err != nil && !kerrors.Is(err, pihole.ErrNotFound) {
+		return err
+	}
+
+	if releaseErr := r.Registry.releaseKey(ctx, instance, key); releaseErr != nil {
+		return releaseErr
+	}
+
+	return err
+}
+
+// domainKey identifies an entry of a DomainRule in the ownership registry
+func domainKey(domain pihole.Domain) string {
+	return fmt.Sprintf("DomainRule %s %s %s", domain.Type, domain.Kind, domain.Domain)
+}
+
+// domainType converts the kind of a DomainRule to the domain type used by the Pi-hole API
+func domainType(kind networkingv1alpha1.DomainRuleKind) pihole.DomainType {
+	if kind == networkingv1alpha1.DomainRuleAllow {
+		return pihole.DomainTypeAllow
+	}
+
+	return pihole.DomainTypeDeny
+}
+
+// domainKind converts the match of a DomainRule to the domain kind used by the Pi-hole API
+func domainKind(match networkingv1alpha1.DomainRuleMatch) pihole.DomainKind {
+	if match == networkingv1alpha1.DomainRuleMatchRegex {
+		return pihole.DomainKindRegex
+	}
+
+	return pihole.DomainKindExact
+}
+
+// appliedDomainRule returns the identity of an entry as stored in the status of a DomainRule
+func appliedDomainRule(domain pihole.Domain) *networkingv1alpha1.AppliedDomainRule {
+	applied := &networkingv1alpha1.AppliedDomainRule{
+		Kind:   networkingv1alpha1.DomainRuleDeny,
+		Match:  networkingv1alpha1.DomainRuleMatchExact,
+		Domain: domain.Domain,
+	}
+	if domain.Type == pihole.DomainTypeAllow {
+		applied.Kind = networkingv1alpha1.DomainRuleAllow
+	}
+	if domain.Kind == pihole.DomainKindRegex {
+		applied.Match = networkingv1alpha1.DomainRuleMatchRegex
+	}
+
+	return applied
+}
+
+// appliedEquals is true if the applied entry is the one of domain
+func appliedEquals(applied *networkingv1alpha1.AppliedDomainRule, domain pihole.Domain) bool {
+	return applied != nil && *applied == *appliedDomainRule(domain)
+}
+
+// sameDomain is true if both entries have the same identity on the Pi-hole
+func sameDomain(a pihole.Domain, b pihole.Domain) bool {
+	return a.Domain == b.Domain && a.Type == b.Type && a.Kind == b.Kind
+}
+
+// domainUpToDate is true if the Pi-hole doesn't need to be updated for the entry to match wanted
+func domainUpToDate(current pihole.Domain, wanted pihole.Domain) bool {
+	return current.Comment == wanted.Comment &&
+		current.Enabled == wanted.Enabled &&
+		sameGroups(current.Groups, wanted.Groups)
+}
+
+// domainRulesForInstance maps a PiHoleInstance or ClusterPiHoleInstance to all DomainRules referencing it.
+func (r *DomainRuleReconciler) domainRulesForInstance(ctx context.Context, obj client.Object) []reconcile.Request {
+	ref := instanceRefOf(obj)
+
+	var opts []client.ListOption
+	if obj.GetNamespace() != "" {
+		opts = append(opts, client.InNamespace(obj.GetNamespace()))
+	}
+
+	rules := &networkingv1alpha1.DomainRuleList{}
+	if err := r.List(ctx, rules, opts...); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list DomainRules")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, rule := range rules.Items {
+		if !sameInstance(rule.Spec.InstanceRef, ref) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&rule)})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DomainRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1alpha1.DomainRule{}, builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.PiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.domainRulesForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.ClusterPiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.domainRulesForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.Group{}, handler.EnqueueRequestsFromMapFunc(
+			resourcesForGroup(r, func() client.ObjectList { return &networkingv1alpha1.DomainRuleList{} }))).
+		Complete(r)
+}
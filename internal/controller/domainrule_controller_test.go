@@ -0,0 +1,272 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+var _ = Describe("DomainRule Controller", func() {
+	const resourceName = "test-domainrule"
+	const instanceName = "domainrule-instance"
+
+	ctx := context.Background()
+
+	typeNamespacedName := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+	var piHole *fake.PiHole
+	var controllerReconciler *DomainRuleReconciler
+
+	reconcileDomainRule := func() (reconcile.Result, error) {
+		return controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+	}
+
+	getDomainRule := func() *networkingv1alpha1.DomainRule {
+		rule := &networkingv1alpha1.DomainRule{}
+		Expect(k8sClient.Get(ctx, typeNamespacedName, rule)).To(Succeed())
+
+		return rule
+	}
+
+	readyCondition := func() *metav1.Condition {
+		condition := meta.FindStatusCondition(getDomainRule().Status.Conditions, "Ready")
+		Expect(condition).NotTo(BeNil())
+
+		return condition
+	}
+
+	updateSpec := func(update func(spec *networkingv1alpha1.DomainRuleSpec)) {
+		rule := getDomainRule()
+		update(&rule.Spec)
+		Expect(k8sClient.Update(ctx, rule)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		piHole = fake.NewPiHole()
+		controllerReconciler = &DomainRuleReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+			PiHoles:  fakePiHoles(piHole),
+			Registry: newRegistry(),
+		}
+
+		createInstance(ctx, instanceName)
+
+		rule := &networkingv1alpha1.DomainRule{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			Spec: networkingv1alpha1.DomainRuleSpec{
+				InstanceRef: networkingv1alpha1.InstanceReference{Name: instanceName},
+				Kind:        networkingv1alpha1.DomainRuleDeny,
+				Match:       networkingv1alpha1.DomainRuleMatchRegex,
+				Domain:      `(\.|^)tracker\.com$`,
+				Comment:     "trackers",
+			},
+		}
+		Expect(k8sClient.Create(ctx, rule)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		deleteOwnership(ctx)
+
+		rule := &networkingv1alpha1.DomainRule{}
+		err := k8sClient.Get(ctx, typeNamespacedName, rule)
+		if errors.IsNotFound(err) {
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+
+		rule.Finalizers = nil
+		Expect(k8sClient.Update(ctx, rule)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, rule)).To(Succeed())
+	})
+
+	It("should add the rule to the Pi-hole", func() {
+		_, err := reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		domains := piHole.Domains()
+		Expect(domains).To(HaveLen(1))
+		Expect(domains[0].Domain).To(Equal(`(\.|^)tracker\.com$`))
+		Expect(domains[0].Type).To(Equal(pihole.DomainTypeDeny))
+		Expect(domains[0].Kind).To(Equal(pihole.DomainKindRegex))
+		Expect(domains[0].Comment).To(Equal("trackers"))
+		Expect(domains[0].Groups).To(Equal([]int{pihole.DefaultGroupID}))
+		Expect(domains[0].Enabled).To(BeTrue())
+
+		rule := getDomainRule()
+		Expect(rule.Finalizers).To(ContainElement(domainRuleFinalizerName))
+		Expect(rule.Status.ID).To(Equal(int32(domains[0].ID)))
+		Expect(rule.Status.Applied).To(Equal(&networkingv1alpha1.AppliedDomainRule{
+			Kind:   networkingv1alpha1.DomainRuleDeny,
+			Match:  networkingv1alpha1.DomainRuleMatchRegex,
+			Domain: `(\.|^)tracker\.com$`,
+		}))
+		Expect(meta.IsStatusConditionTrue(rule.Status.Conditions, "Ready")).To(BeTrue())
+	})
+
+	It("should never send a broken regex to the Pi-hole", func() {
+		updateSpec(func(spec *networkingv1alpha1.DomainRuleSpec) { spec.Domain = `(tracker\.com` })
+
+		_, err := reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Calls(fake.CreateDomain)).To(BeZero())
+
+		condition := readyCondition()
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("InvalidSpec"))
+	})
+
+	It("should not take over an existing entry it didn't create", func() {
+		piHole.SetDomains(pihole.Domain{Domain: `(\.|^)tracker\.com$`, Type: pihole.DomainTypeDeny, Kind: pihole.DomainKindRegex, Comment: "by hand", Groups: []int{0}})
+
+		result, err := reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		Expect(piHole.Domains()[0].Comment).To(Equal("by hand"))
+		Expect(readyCondition().Reason).To(Equal("Conflict"))
+		Expect(meta.IsStatusConditionTrue(getDomainRule().Status.Conditions, "Conflict")).To(BeTrue())
+		Expect(getDomainRule().Status.Applied).To(BeNil())
+
+		Expect(k8sClient.Delete(ctx, getDomainRule())).To(Succeed())
+		_, err = reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Domains()).To(HaveLen(1))
+		Expect(piHole.Calls(fake.DeleteDomain)).To(BeZero())
+	})
+
+	It("should not delete an entry added by hand after the rule failed to create it", func() {
+		piHole.SetError(fake.CreateDomain, fmt.Errorf("pi-hole is down"))
+
+		_, err := reconcileDomainRule()
+		Expect(err).To(HaveOccurred())
+		Expect(getDomainRule().Status.Applied).To(BeNil())
+
+		piHole.SetError(fake.CreateDomain, nil)
+		piHole.SetDomains(pihole.Domain{Domain: `(\.|^)tracker\.com$`, Type: pihole.DomainTypeDeny, Kind: pihole.DomainKindRegex, Groups: []int{0}})
+
+		Expect(k8sClient.Delete(ctx, getDomainRule())).To(Succeed())
+		_, err = reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Domains()).To(HaveLen(1))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.DomainRule{}))).To(BeTrue())
+	})
+
+	It("should adopt an existing entry", func() {
+		piHole.SetDomains(pihole.Domain{Domain: `(\.|^)tracker\.com$`, Type: pihole.DomainTypeDeny, Kind: pihole.DomainKindRegex, Groups: []int{0}})
+		updateSpec(func(spec *networkingv1alpha1.DomainRuleSpec) {
+			spec.AdoptionPolicy = networkingv1alpha1.AdoptionPolicyAdopt
+		})
+
+		_, err := reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		domains := piHole.Domains()
+		Expect(domains).To(HaveLen(1))
+		Expect(domains[0].Comment).To(Equal("trackers"))
+		Expect(domains[0].Enabled).To(BeTrue())
+		Expect(piHole.Calls(fake.CreateDomain)).To(BeZero())
+	})
+
+	It("should replace the entry when the domain changes", func() {
+		_, err := reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		updateSpec(func(spec *networkingv1alpha1.DomainRuleSpec) {
+			spec.Kind = networkingv1alpha1.DomainRuleAllow
+			spec.Match = networkingv1alpha1.DomainRuleMatchExact
+			spec.Domain = "cdn.tracker.com"
+		})
+
+		_, err = reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		domains := piHole.Domains()
+		Expect(domains).To(HaveLen(1))
+		Expect(domains[0].Domain).To(Equal("cdn.tracker.com"))
+		Expect(domains[0].Type).To(Equal(pihole.DomainTypeAllow))
+		Expect(domains[0].Kind).To(Equal(pihole.DomainKindExact))
+		Expect(getDomainRule().Status.Applied.Domain).To(Equal("cdn.tracker.com"))
+	})
+
+	It("should disable the rule and assign it to groups by name", func() {
+		piHole.SetGroups(pihole.Group{ID: 4, Name: "kids"})
+		enabled := false
+		updateSpec(func(spec *networkingv1alpha1.DomainRuleSpec) {
+			spec.Enabled = &enabled
+			spec.Groups = []string{"kids"}
+		})
+
+		_, err := reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		domains := piHole.Domains()
+		Expect(domains[0].Enabled).To(BeFalse())
+		Expect(domains[0].Groups).To(Equal([]int{4}))
+	})
+
+	It("should restore a rule changed in the Pi-hole UI", func() {
+		_, err := reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		domain := piHole.Domains()[0]
+		domain.Enabled = false
+		piHole.SetDomains(domain)
+
+		_, err = reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Domains()[0].Enabled).To(BeTrue())
+	})
+
+	It("should wait for groups that don't exist", func() {
+		updateSpec(func(spec *networkingv1alpha1.DomainRuleSpec) { spec.Groups = []string{"guests"} })
+
+		result, err := reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(groupRetryInterval))
+		Expect(readyCondition().Reason).To(Equal("GroupNotFound"))
+	})
+
+	It("should remove the entry from the Pi-hole when the rule is deleted", func() {
+		_, err := reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, getDomainRule())).To(Succeed())
+		_, err = reconcileDomainRule()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Domains()).To(BeEmpty())
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.DomainRule{}))).To(BeTrue())
+	})
+})
@@ -0,0 +1,194 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+// DriftDetector periodically compares the records of all Pi-holes with the DNSNames
+// synced to them. DNSNames whose records were changed outside of the operator, e.g.
+// deleted in the Pi-hole UI, are enqueued so the reconciler restores them.
+type DriftDetector struct {
+	// Client is used to list DNSNames
+	Client client.Reader
+	// PiHoles resolves the instances the DNSNames are synced to
+	PiHoles *PiHoleClients
+	// Interval is the time between two checks
+	Interval time.Duration
+
+	events chan event.GenericEvent
+}
+
+// NewDriftDetector returns a DriftDetector checking for drift every interval
+func NewDriftDetector(c client.Reader, piHoles *PiHoleClients, interval time.Duration) *DriftDetector {
+	return &DriftDetector{
+		Client:   c,
+		PiHoles:  piHoles,
+		Interval: interval,
+		events:   make(chan event.GenericEvent),
+	}
+}
+
+// Source returns the source the drifted DNSNames are enqueued from
+func (d *DriftDetector) Source() source.Source {
+	return source.Channel(d.events, &handler.EnqueueRequestForObject{})
+}
+
+// NeedLeaderElection makes sure only the leader checks for drift, the others
+// wouldn't be able to act on it anyway
+func (d *DriftDetector) NeedLeaderElection() bool {
+	return true
+}
+
+// Start checks for drift every interval until ctx is done
+func (d *DriftDetector) Start(ctx context.Context) error {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		drifted, err := d.Detect(ctx)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "Failed to check DNSNames for drift")
+			continue
+		}
+
+		for i := range drifted {
+			select {
+			case d.events <- event.GenericEvent{Object: &drifted[i]}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// Detect returns all DNSNames that are synced according to their status, but
+// whose records are missing on at least one of their instances. Instances that
+// can't be reached are skipped, the reconciler would fail on them as well.
+func (d *DriftDetector) Detect(ctx context.Context) ([]networkingv1alpha1.DNSName, error) {
+	logger := log.FromContext(ctx)
+
+	dnsNames := &networkingv1alpha1.DNSNameList{}
+	if err := d.Client.List(ctx, dnsNames); err != nil {
+		return nil, err
+	}
+
+	// the records of every instance are only fetched once per check
+	records := map[string][]pihole.DNSRecord{}
+	unreachable := map[string]bool{}
+
+	recordsOf := func(namespace string, ref networkingv1alpha1.InstanceReference) ([]pihole.DNSRecord, bool) {
+		key := instanceKey(namespace, ref)
+		if unreachable[key] {
+			return nil, false
+		}
+		if r, ok := records[key]; ok {
+			return r, true
+		}
+
+		piHole, err := d.PiHoles.Get(ctx, namespace, ref)
+		if err == nil {
+			records[key], err = freshDNSRecords(ctx, piHole)
+		}
+		if err != nil {
+			logger.Error(err, "Failed to get DNS records", "Instance", key)
+			unreachable[key] = true
+
+			return nil, false
+		}
+
+		return records[key], true
+	}
+
+	var drifted []networkingv1alpha1.DNSName
+	for _, dnsName := range dnsNames.Items {
+		if !dnsName.DeletionTimestamp.IsZero() || !isSynced(&dnsName) {
+			// the reconciler is still working on it
+			continue
+		}
+
+		wanted, err := pihole.NewDNSRecordsFromSpec(dnsName.Spec)
+		if err != nil {
+			continue
+		}
+
+		for _, instance := range dnsName.Status.Instances {
+			present, ok := recordsOf(dnsName.Namespace, instance.InstanceReference)
+			if !ok {
+				continue
+			}
+
+			if missingDNSRecords(present, wanted) > 0 {
+				logger.Info("DNSName drifted", "DNSName", client.ObjectKeyFromObject(&dnsName), "Instance", instance.Name)
+				drifted = append(drifted, dnsName)
+				break
+			}
+		}
+	}
+
+	return drifted, nil
+}
+
+// freshDNSRecords reads the records from the Pi-hole bypassing a cache, the cache
+// is refreshed along the way
+func freshDNSRecords(ctx context.Context, piHole pihole.Client) ([]pihole.DNSRecord, error) {
+	if refresher, ok := piHole.(pihole.Refresher); ok {
+		return refresher.Refresh(ctx)
+	}
+
+	return piHole.GetDNSRecords(ctx)
+}
+
+// isSynced is true if the current generation of the DNSName was synced to all of its instances
+func isSynced(dnsName *networkingv1alpha1.DNSName) bool {
+	condition := meta.FindStatusCondition(dnsName.Status.Conditions, conditionSynced)
+
+	return condition != nil &&
+		condition.Status == metav1.ConditionTrue &&
+		condition.ObservedGeneration == dnsName.Generation
+}
+
+// missingDNSRecords counts the wanted records that are not present
+func missingDNSRecords(present []pihole.DNSRecord, wanted []pihole.DNSRecord) int {
+	missing := 0
+	for _, record := range wanted {
+		if !containsDNSRecord(present, record) {
+			missing++
+		}
+	}
+
+	return missing
+}
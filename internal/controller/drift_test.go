@@ -0,0 +1,158 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+var _ = Describe("DriftDetector", func() {
+	const instanceName = "drift-instance"
+
+	ctx := context.Background()
+
+	var piHole *fake.PiHole
+	var detector *DriftDetector
+
+	record := pihole.DNSRecord{Domain: "drift.com", Target: "192.168.178.1", Type: networkingv1alpha1.A}
+
+	createDNSName := func(name string, synced bool) *networkingv1alpha1.DNSName {
+		target := networkingv1alpha1.IPAddressStr(record.Target)
+		dnsName := &networkingv1alpha1.DNSName{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: networkingv1alpha1.DNSNameSpec{
+				InstanceRef: &networkingv1alpha1.InstanceReference{Name: instanceName},
+				Type:        networkingv1alpha1.A,
+				Domain:      record.Domain,
+				TargetIP:    &target,
+			},
+		}
+		Expect(k8sClient.Create(ctx, dnsName)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, dnsName))).To(Succeed())
+		})
+
+		status := metav1.ConditionTrue
+		if !synced {
+			status = metav1.ConditionFalse
+		}
+
+		dnsName.Status.Instances = []networkingv1alpha1.InstanceStatus{{
+			InstanceReference: networkingv1alpha1.InstanceReference{Kind: networkingv1alpha1.PiHoleInstanceKind, Name: instanceName},
+			Synced:            synced,
+		}}
+		meta.SetStatusCondition(&dnsName.Status.Conditions, metav1.Condition{
+			Type:               "Synced",
+			Status:             status,
+			Reason:             "Synced",
+			ObservedGeneration: dnsName.Generation,
+		})
+		Expect(k8sClient.Status().Update(ctx, dnsName)).To(Succeed())
+
+		return dnsName
+	}
+
+	names := func(dnsNames []networkingv1alpha1.DNSName) []string {
+		var result []string
+		for _, dnsName := range dnsNames {
+			result = append(result, dnsName.Name)
+		}
+
+		return result
+	}
+
+	BeforeEach(func() {
+		piHole = fake.NewPiHole()
+		piHoles := &PiHoleClients{
+			Client:       k8sClient,
+			SecretReader: k8sClient,
+			NewClient: func(pihole.Config) pihole.Client {
+				return piHole
+			},
+		}
+		detector = NewDriftDetector(k8sClient, piHoles, time.Minute)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+			StringData: map[string]string{"password": "secret"},
+		}
+		err := k8sClient.Create(ctx, secret)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		instance := &networkingv1alpha1.PiHoleInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+			Spec: networkingv1alpha1.PiHoleInstanceSpec{
+				URL:                  "http://pi.hole/api",
+				AppPasswordSecretRef: networkingv1alpha1.SecretKeyReference{Name: instanceName, Key: "password"},
+			},
+		}
+		err = k8sClient.Create(ctx, instance)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	It("should not report DNSNames whose records are present", func() {
+		Expect(piHole.CreateDNSRecord(ctx, record)).To(Succeed())
+		createDNSName("drift-present", true)
+
+		drifted, err := detector.Detect(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(drifted)).NotTo(ContainElement("drift-present"))
+	})
+
+	It("should report DNSNames whose records are missing", func() {
+		createDNSName("drift-missing", true)
+
+		drifted, err := detector.Detect(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(drifted)).To(ContainElement("drift-missing"))
+	})
+
+	It("should ignore DNSNames that are not synced yet", func() {
+		createDNSName("drift-pending", false)
+
+		drifted, err := detector.Detect(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(drifted)).NotTo(ContainElement("drift-pending"))
+	})
+
+	It("should skip Pi-holes that can't be reached", func() {
+		piHole.SetError(fake.GetDNSRecords, fmt.Errorf("pi-hole is down"))
+		createDNSName("drift-unreachable", true)
+
+		drifted, err := detector.Detect(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(drifted)).NotTo(ContainElement("drift-unreachable"))
+	})
+})
@@ -0,0 +1,484 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/cron"
+)
+
+// Condition types of a PiHoleInstance or ClusterPiHoleInstance
+const (
+	// conditionGravityUpdated is true if the last gravity update succeeded
+	conditionGravityUpdated = "GravityUpdated"
+	// conditionGravityScheduled is true if gravity is updated on a valid schedule
+	conditionGravityScheduled = "GravityScheduled"
+)
+
+// Condition reasons of gravity updates
+const (
+	reasonRunning     = "Running"
+	reasonSucceeded   = "Succeeded"
+	reasonFailed      = "Failed"
+	reasonInterrupted = "Interrupted"
+	reasonScheduled   = "Scheduled"
+)
+
+// gravityOutputLines is the number of lines of the output of gravity kept in a status
+const gravityOutputLines = 50
+
+// GravityScheduler updates gravity on the Pi-holes whose instance configures a
+// schedule or updates after adlist changes. It also runs the updates requested by
+// GravityUpdates, so a Pi-hole never runs more than one update at a time. The last
+// update of every Pi-hole is recorded in the status of its instance.
+type GravityScheduler struct {
+	// Client is used to read and update instances
+	Client client.Client
+	// Recorder records the outcome of updates as events of the instances
+	Recorder record.EventRecorder
+	// PiHoles resolves the instances gravity is updated on
+	PiHoles *PiHoleClients
+	// Debounce is the time waited after an adlist change before gravity is updated,
+	// every further change of the instance's adlists restarts it
+	Debounce time.Duration
+
+	now func() time.Time
+
+	mu sync.Mutex
+	// running holds a semaphore per instance, an update holds it while it runs
+	running map[string]chan struct{}
+	// pending are the debounced updates after adlist changes by instance
+	pending map[string]*time.Timer
+	// due are the updates after adlist changes whose debounce has passed
+	due  []gravityTarget
+	wake chan struct{}
+}
+
+// gravityTarget is an instance gravity is updated on
+type gravityTarget struct {
+	namespace string
+	ref       networkingv1alpha1.InstanceReference
+}
+
+// NewGravityScheduler returns a GravityScheduler updating gravity debounce after the last adlist change
+func NewGravityScheduler(c client.Client, recorder record.EventRecorder, piHoles *PiHoleClients, debounce time.Duration) *GravityScheduler {
+	return &GravityScheduler{
+		Client:   c,
+		Recorder: recorder,
+		PiHoles:  piHoles,
+		Debounce: debounce,
+		now:      time.Now,
+		running:  map[string]chan struct{}{},
+		pending:  map[string]*time.Timer{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// NeedLeaderElection makes sure only the leader updates gravity, otherwise every
+// replica would run the scheduled updates
+func (s *GravityScheduler) NeedLeaderElection() bool {
+	return true
+}
+
+// Start runs the scheduled updates at the start of every minute and the updates
+// after adlist changes once their debounce has passed, until ctx is done
+func (s *GravityScheduler) Start(ctx context.Context) error {
+	timer := time.NewTimer(s.untilNextMinute())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			s.runScheduled(ctx)
+			timer.Reset(s.untilNextMinute())
+		case <-s.wake:
+			s.mu.Lock()
+			due := s.due
+			s.due = nil
+			s.mu.Unlock()
+
+			for _, target := range due {
+				go s.runAfterAdlistChange(ctx, target)
+			}
+		}
+	}
+}
+
+// AdlistsChanged requests a gravity update of an instance once its adlists haven't
+// changed for the debounce time. It does nothing if s is nil, the update only runs
+// if the instance enables it.
+func (s *GravityScheduler) AdlistsChanged(namespace string, ref networkingv1alpha1.InstanceReference) {
+	if s == nil {
+		return
+	}
+
+	target := gravityTarget{namespace: namespace, ref: ref}
+	key := instanceKey(namespace, ref)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.pending[key]; ok {
+		timer.Stop()
+	}
+
+	s.pending[key] = time.AfterFunc(s.Debounce, func() {
+		s.mu.Lock()
+		delete(s.pending, key)
+		s.due = append(s.due, target)
+		s.mu.Unlock()
+
+		select {
+		case s.wake <- struct{}{}:
+		default:
+			// Start is already woken up and collects this update as well
+		}
+	})
+}
+
+// Update updates gravity on an instance, waiting for an update already running on
+// it to finish first. progress receives each line of the output of gravity. cause
+// describes why gravity is updated in the events of the instance.
+func (s *GravityScheduler) Update(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference, cause string, progress func(line string)) error {
+	release, err := s.acquire(ctx, instanceKey(namespace, ref))
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	run := networkingv1alpha1.GravityRun{Phase: networkingv1alpha1.GravityUpdateRunning, StartTime: s.timestamp()}
+	output := &gravityOutput{}
+
+	gravity, err := s.PiHoles.Gravity(ctx, namespace, ref)
+	if err == nil {
+		err = gravity.UpdateGravity(ctx, func(line string) {
+			output.add(line)
+			if progress != nil {
+				progress(line)
+			}
+		})
+	}
+
+	run.Phase = networkingv1alpha1.GravityUpdateSucceeded
+	if err != nil {
+		run.Phase = networkingv1alpha1.GravityUpdateFailed
+	}
+	run.CompletionTime = s.timestamp()
+	run.Output = output.String()
+
+	if recordErr := s.record(ctx, gravityTarget{namespace: namespace, ref: ref}, run, cause, err); recordErr != nil {
+		log.FromContext(ctx).Error(recordErr, "Failed to record gravity update", "Instance", instanceKey(namespace, ref))
+	}
+
+	return err
+}
+
+// acquire waits until no other update runs on the instance and returns the
+// function ending the update
+func (s *GravityScheduler) acquire(ctx context.Context, key string) (func(), error) {
+	s.mu.Lock()
+	semaphore, ok := s.running[key]
+	if !ok {
+		semaphore = make(chan struct{}, 1)
+		s.running[key] = semaphore
+	}
+	s.mu.Unlock()
+
+	select {
+	case semaphore <- struct{}{}:
+		return func() { <-semaphore }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runScheduled updates gravity on all instances whose schedule is due and records
+// the next scheduled update of every instance with a schedule
+func (s *GravityScheduler) runScheduled(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	targets, err := s.scheduledTargets(ctx)
+	if err != nil {
+		logger.Error(err, "Failed to list instances for scheduled gravity updates")
+		return
+	}
+
+	now := s.now()
+	for _, target := range targets {
+		_, err := s.updateInstanceStatus(ctx, target.gravityTarget, func(spec *networkingv1alpha1.PiHoleInstanceSpec, status *networkingv1alpha1.PiHoleInstanceStatus, generation int64) {
+			setSchedule(spec.Gravity, status, generation, now)
+		})
+		if err != nil {
+			logger.Error(err, "Failed to update gravity schedule", "Instance", instanceKey(target.namespace, target.ref))
+		}
+	}
+
+	for _, target := range targets {
+		schedule, location, err := parseGravitySchedule(target.config)
+		if err != nil || !schedule.Due(now.In(location)) {
+			continue
+		}
+
+		go func() {
+			err := s.Update(ctx, target.namespace, target.ref, "scheduled", nil)
+			if err != nil {
+				logger.Error(err, "Scheduled gravity update failed", "Instance", instanceKey(target.namespace, target.ref))
+			}
+		}()
+	}
+}
+
+// scheduledGravityTarget is an instance with a gravity schedule
+type scheduledGravityTarget struct {
+	gravityTarget
+	config *networkingv1alpha1.GravityConfig
+}
+
+// scheduledTargets returns all PiHoleInstances and ClusterPiHoleInstances with a gravity schedule
+func (s *GravityScheduler) scheduledTargets(ctx context.Context) ([]scheduledGravityTarget, error) {
+	var targets []scheduledGravityTarget
+
+	instances := &networkingv1alpha1.PiHoleInstanceList{}
+	if err := s.Client.List(ctx, instances); err != nil {
+		return nil, err
+	}
+
+	for _, instance := range instances.Items {
+		if hasGravitySchedule(&instance.Spec, &instance.Status) {
+			targets = append(targets, scheduledGravityTarget{
+				gravityTarget: gravityTarget{namespace: instance.Namespace, ref: instanceRefOf(&instance)},
+				config:        instance.Spec.Gravity,
+			})
+		}
+	}
+
+	clusterInstances := &networkingv1alpha1.ClusterPiHoleInstanceList{}
+	if err := s.Client.List(ctx, clusterInstances); err != nil {
+		return nil, err
+	}
+
+	for _, instance := range clusterInstances.Items {
+		if hasGravitySchedule(&instance.Spec, &instance.Status) {
+			targets = append(targets, scheduledGravityTarget{
+				gravityTarget: gravityTarget{ref: instanceRefOf(&instance)},
+				config:        instance.Spec.Gravity,
+			})
+		}
+	}
+
+	return targets, nil
+}
+
+// hasGravitySchedule is true if the instance has a schedule or had one the status still reports
+func hasGravitySchedule(spec *networkingv1alpha1.PiHoleInstanceSpec, status *networkingv1alpha1.PiHoleInstanceStatus) bool {
+	return (spec.Gravity != nil && spec.Gravity.Schedule != "") ||
+		meta.FindStatusCondition(status.Conditions, conditionGravityScheduled) != nil
+}
+
+// parseGravitySchedule parses the schedule of an instance and loads its time zone
+func parseGravitySchedule(config *networkingv1alpha1.GravityConfig) (*cron.Schedule, *time.Location, error) {
+	if config == nil || config.Schedule == "" {
+		return nil, nil, fmt.Errorf("no schedule")
+	}
+
+	schedule, err := cron.Parse(config.Schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	location := time.UTC
+	if config.TimeZone != "" {
+		location, err = time.LoadLocation(config.TimeZone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid time zone %q: %w", config.TimeZone, err)
+		}
+	}
+
+	return schedule, location, nil
+}
+
+// setSchedule reports the next scheduled update of an instance in its status, or
+// why its schedule is invalid
+func setSchedule(config *networkingv1alpha1.GravityConfig, status *networkingv1alpha1.PiHoleInstanceStatus, generation int64, now time.Time) {
+	if config == nil || config.Schedule == "" {
+		meta.RemoveStatusCondition(&status.Conditions, conditionGravityScheduled)
+		status.NextGravityUpdate = nil
+
+		return
+	}
+
+	schedule, location, err := parseGravitySchedule(config)
+	if err != nil {
+		setStatusCondition(&status.Conditions, generation, conditionGravityScheduled, metav1.ConditionFalse, reasonInvalidSpec, err.Error())
+		status.NextGravityUpdate = nil
+
+		return
+	}
+
+	next := schedule.Next(now.In(location))
+	if next.IsZero() {
+		setStatusCondition(&status.Conditions, generation, conditionGravityScheduled, metav1.ConditionFalse, reasonInvalidSpec,
+			fmt.Sprintf("Schedule %q is never due", config.Schedule))
+		status.NextGravityUpdate = nil
+
+		return
+	}
+
+	nextUpdate := metav1.NewTime(next)
+	status.NextGravityUpdate = &nextUpdate
+	setStatusCondition(&status.Conditions, generation, conditionGravityScheduled, metav1.ConditionTrue, reasonScheduled,
+		fmt.Sprintf("Gravity is updated on schedule %q", config.Schedule))
+}
+
+// runAfterAdlistChange updates gravity on an instance whose adlists have changed,
+// if the instance enables it
+func (s *GravityScheduler) runAfterAdlistChange(ctx context.Context, target gravityTarget) {
+	logger := log.FromContext(ctx)
+
+	obj, spec, _ := instanceObject(target.namespace, target.ref)
+	if err := s.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		logger.Error(client.IgnoreNotFound(err), "Failed to get instance for gravity update", "Instance", instanceKey(target.namespace, target.ref))
+		return
+	}
+
+	if spec.Gravity == nil || !spec.Gravity.UpdateOnAdlistChange {
+		return
+	}
+
+	if err := s.Update(ctx, target.namespace, target.ref, "after adlist changes", nil); err != nil {
+		logger.Error(err, "Gravity update after adlist changes failed", "Instance", instanceKey(target.namespace, target.ref))
+	}
+}
+
+// record stores the outcome of an update in the status of the instance and as an event
+func (s *GravityScheduler) record(ctx context.Context, target gravityTarget, run networkingv1alpha1.GravityRun, cause string, runErr error) error {
+	instance, err := s.updateInstanceStatus(ctx, target, func(_ *networkingv1alpha1.PiHoleInstanceSpec, status *networkingv1alpha1.PiHoleInstanceStatus, generation int64) {
+		status.Gravity = &run
+		if runErr != nil {
+			setStatusCondition(&status.Conditions, generation, conditionGravityUpdated, metav1.ConditionFalse, gravityFailureReason(runErr), runErr.Error())
+		} else {
+			setStatusCondition(&status.Conditions, generation, conditionGravityUpdated, metav1.ConditionTrue, reasonSucceeded,
+				fmt.Sprintf("Gravity was updated %s", cause))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if runErr != nil {
+		s.Recorder.Eventf(instance, "Warning", "GravityUpdateFailed", "Gravity update %s failed: %v", cause, runErr)
+	} else {
+		s.Recorder.Eventf(instance, "Normal", "GravityUpdated", "Gravity was updated %s", cause)
+	}
+
+	return nil
+}
+
+// updateInstanceStatus applies mutate to the status of an instance and writes it
+// if it changed, conflicts are retried. It returns the updated instance.
+func (s *GravityScheduler) updateInstanceStatus(ctx context.Context, target gravityTarget,
+	mutate func(spec *networkingv1alpha1.PiHoleInstanceSpec, status *networkingv1alpha1.PiHoleInstanceStatus, generation int64)) (client.Object, error) {
+	var instance client.Object
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, spec, status := instanceObject(target.namespace, target.ref)
+		if err := s.Client.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return err
+		}
+		instance = obj
+
+		before := status.DeepCopy()
+		mutate(spec, status, obj.GetGeneration())
+		if equality.Semantic.DeepEqual(before, status) {
+			return nil
+		}
+
+		return s.Client.Status().Update(ctx, obj)
+	})
+
+	return instance, err
+}
+
+// instanceObject returns an empty PiHoleInstance or ClusterPiHoleInstance for ref
+// along with its spec and status
+func instanceObject(namespace string, ref networkingv1alpha1.InstanceReference) (client.Object, *networkingv1alpha1.PiHoleInstanceSpec, *networkingv1alpha1.PiHoleInstanceStatus) {
+	if ref.Kind == networkingv1alpha1.ClusterPiHoleInstanceKind {
+		instance := &networkingv1alpha1.ClusterPiHoleInstance{ObjectMeta: metav1.ObjectMeta{Name: ref.Name}}
+
+		return instance, &instance.Spec, &instance.Status
+	}
+
+	instance := &networkingv1alpha1.PiHoleInstance{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: ref.Name}}
+
+	return instance, &instance.Spec, &instance.Status
+}
+
+// gravityFailureReason classifies the error of a failed gravity update
+func gravityFailureReason(err error) string {
+	reason := failureReason(err)
+	if reason == reasonSyncFailed {
+		return reasonFailed
+	}
+
+	return reason
+}
+
+// untilNextMinute returns the time until the start of the next minute
+func (s *GravityScheduler) untilNextMinute() time.Duration {
+	now := s.now()
+
+	return now.Truncate(time.Minute).Add(time.Minute).Sub(now)
+}
+
+// timestamp returns the current time as a timestamp of a status
+func (s *GravityScheduler) timestamp() *metav1.Time {
+	now := metav1.NewTime(s.now())
+
+	return &now
+}
+
+// gravityOutput keeps the last lines of the output of gravity
+type gravityOutput struct {
+	lines []string
+}
+
+func (o *gravityOutput) add(line string) {
+	o.lines = append(o.lines, line)
+	if len(o.lines) > gravityOutputLines {
+		o.lines = o.lines[len(o.lines)-gravityOutputLines:]
+	}
+}
+
+func (o *gravityOutput) String() string {
+	return strings.Join(o.lines, "\n")
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+var _ = Describe("GravityScheduler", func() {
+	const instanceName = "gravity-instance"
+
+	ctx := context.Background()
+
+	ref := networkingv1alpha1.InstanceReference{Kind: networkingv1alpha1.PiHoleInstanceKind, Name: instanceName}
+
+	var piHole *fake.PiHole
+	var scheduler *GravityScheduler
+
+	getInstance := func() *networkingv1alpha1.PiHoleInstance {
+		instance := &networkingv1alpha1.PiHoleInstance{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceName, Namespace: "default"}, instance)).To(Succeed())
+
+		return instance
+	}
+
+	setGravity := func(config *networkingv1alpha1.GravityConfig) {
+		instance := getInstance()
+		instance.Spec.Gravity = config
+		Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+	}
+
+	// start runs the scheduler until the end of the test
+	start := func() {
+		ctx, cancel := context.WithCancel(ctx)
+		DeferCleanup(cancel)
+
+		go func() {
+			defer GinkgoRecover()
+			Expect(scheduler.Start(ctx)).To(Succeed())
+		}()
+	}
+
+	BeforeEach(func() {
+		piHole = fake.NewPiHole()
+		piHoles := &PiHoleClients{
+			Client:       k8sClient,
+			SecretReader: k8sClient,
+			NewClient: func(pihole.Config) pihole.Client {
+				return piHole
+			},
+		}
+		scheduler = NewGravityScheduler(k8sClient, record.NewFakeRecorder(100), piHoles, 50*time.Millisecond)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+			StringData: map[string]string{"password": "secret"},
+		}
+		err := k8sClient.Create(ctx, secret)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		instance := &networkingv1alpha1.PiHoleInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+			Spec: networkingv1alpha1.PiHoleInstanceSpec{
+				URL:                  "http://pi.hole/api",
+				AppPasswordSecretRef: networkingv1alpha1.SecretKeyReference{Name: instanceName, Key: "password"},
+			},
+		}
+		err = k8sClient.Create(ctx, instance)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+		setGravity(nil)
+	})
+
+	It("should update gravity when the schedule is due", func() {
+		setGravity(&networkingv1alpha1.GravityConfig{Schedule: "* * * * *"})
+
+		scheduler.runScheduled(ctx)
+
+		Eventually(func() int { return piHole.Calls(fake.UpdateGravity) }).Should(Equal(1))
+		Eventually(func() *networkingv1alpha1.GravityRun { return getInstance().Status.Gravity }).ShouldNot(BeNil())
+
+		instance := getInstance()
+		Expect(instance.Status.NextGravityUpdate).NotTo(BeNil())
+		Expect(instance.Status.NextGravityUpdate.Time).To(BeTemporally(">", time.Now()))
+
+		condition := meta.FindStatusCondition(instance.Status.Conditions, "GravityScheduled")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+	})
+
+	It("should not update gravity before the schedule is due", func() {
+		setGravity(&networkingv1alpha1.GravityConfig{Schedule: "0 3 * * *", TimeZone: "Europe/Berlin"})
+		scheduler.now = func() time.Time { return time.Date(2025, time.January, 15, 3, 0, 0, 0, time.UTC) }
+
+		scheduler.runScheduled(ctx)
+
+		Consistently(func() int { return piHole.Calls(fake.UpdateGravity) }, "200ms").Should(BeZero())
+		Expect(getInstance().Status.NextGravityUpdate.Time).To(BeTemporally("==", time.Date(2025, time.January, 16, 2, 0, 0, 0, time.UTC)))
+	})
+
+	It("should report an invalid schedule", func() {
+		setGravity(&networkingv1alpha1.GravityConfig{Schedule: "every sunday"})
+
+		scheduler.runScheduled(ctx)
+
+		condition := meta.FindStatusCondition(getInstance().Status.Conditions, "GravityScheduled")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("InvalidSpec"))
+		Expect(piHole.Calls(fake.UpdateGravity)).To(BeZero())
+	})
+
+	It("should forget a removed schedule", func() {
+		setGravity(&networkingv1alpha1.GravityConfig{Schedule: "0 3 * * *"})
+		scheduler.runScheduled(ctx)
+
+		setGravity(nil)
+		scheduler.runScheduled(ctx)
+
+		instance := getInstance()
+		Expect(instance.Status.NextGravityUpdate).To(BeNil())
+		Expect(meta.FindStatusCondition(instance.Status.Conditions, "GravityScheduled")).To(BeNil())
+	})
+
+	It("should update gravity once after several adlist changes", func() {
+		setGravity(&networkingv1alpha1.GravityConfig{UpdateOnAdlistChange: true})
+		start()
+
+		for i := 0; i < 3; i++ {
+			scheduler.AdlistsChanged("default", ref)
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		Eventually(func() int { return piHole.Calls(fake.UpdateGravity) }).Should(Equal(1))
+		Consistently(func() int { return piHole.Calls(fake.UpdateGravity) }, "200ms").Should(Equal(1))
+	})
+
+	It("should not update gravity after adlist changes unless enabled", func() {
+		start()
+
+		scheduler.AdlistsChanged("default", ref)
+
+		Consistently(func() int { return piHole.Calls(fake.UpdateGravity) }, "200ms").Should(BeZero())
+	})
+
+	It("should run one update at a time per Pi-hole", func() {
+		piHole.SetLatency(fake.UpdateGravity, 100*time.Millisecond)
+
+		done := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				done <- scheduler.Update(ctx, "default", ref, "by a test", nil)
+			}()
+		}
+
+		began := time.Now()
+		Expect(<-done).To(Succeed())
+		Expect(<-done).To(Succeed())
+		Expect(time.Since(began)).To(BeNumerically(">=", 200*time.Millisecond))
+	})
+})
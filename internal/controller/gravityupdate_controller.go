@@ -0,0 +1,204 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+)
+
+// conditionSucceeded is the condition of a GravityUpdate, it is unknown while the update runs
+const conditionSucceeded = "Succeeded"
+
+// gravityUpdateConcurrency is the number of GravityUpdates run in parallel, updates
+// of the same Pi-hole always run one after the other
+const gravityUpdateConcurrency = 4
+
+// defaultProgressInterval is the default time between two status updates with the output of gravity
+const defaultProgressInterval = 2 * time.Second
+
+// GravityUpdateReconciler reconciles a GravityUpdate object
+type GravityUpdateReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Gravity  *GravityScheduler
+
+	// ProgressInterval is the minimum time between two status updates with the output
+	// of a running update, it defaults to 2 seconds
+	ProgressInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=gravityupdates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=gravityupdates/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=gravityupdates/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeinstances/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=clusterpiholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=clusterpiholeinstances/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// Reconcile updates gravity on the Pi-hole referenced by a GravityUpdate once. The
+// output of gravity is streamed into its status while the update runs. A finished
+// GravityUpdate is never run again, a new one has to be created for that.
+func (r *GravityUpdateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	update := &networkingv1alpha1.GravityUpdate{}
+	err := r.Get(ctx, req.NamespacedName, update)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("GravityUpdate resource not found. Ignoring since object must be deleted.")
+
+			return ctrl.Result{}, nil
+		}
+
+		reqLogger.Error(err, "Failed to get GravityUpdate")
+		return ctrl.Result{}, err
+	}
+
+	if !update.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	switch update.Status.Phase {
+	case networkingv1alpha1.GravityUpdateSucceeded, networkingv1alpha1.GravityUpdateFailed:
+		return ctrl.Result{}, nil
+	case networkingv1alpha1.GravityUpdateRunning:
+		// the operator was restarted while the update was running, its outcome is unknown
+		r.Recorder.Event(update, "Warning", reasonInterrupted, "Gravity update was interrupted by a restart of the operator")
+		r.finish(update, fmt.Errorf("the update was interrupted by a restart of the operator"), reasonInterrupted)
+
+		return ctrl.Result{}, r.Status().Update(ctx, update)
+	}
+
+	ref := update.Spec.InstanceRef
+	reqLogger.Info("Updating gravity", "Name", update.Name, "Instance", instanceKey(update.Namespace, ref))
+
+	now := metav1.Now()
+	update.Status.Phase = networkingv1alpha1.GravityUpdateRunning
+	update.Status.StartTime = &now
+	setStatusCondition(&update.Status.Conditions, update.Generation, conditionSucceeded, metav1.ConditionUnknown, reasonRunning,
+		fmt.Sprintf("Gravity is being updated on %s %s", ref.Kind, ref.Name))
+
+	// the update only starts if no other reconciliation has started it in the meantime
+	if err := r.Status().Update(ctx, update); err != nil {
+		reqLogger.Error(err, "Failed to update GravityUpdate status")
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(update, "Normal", "Started", "Started gravity update on %s %s", ref.Kind, ref.Name)
+
+	progress := r.progress(ctx, update)
+	updateErr := r.Gravity.Update(ctx, update.Namespace, ref, fmt.Sprintf("for GravityUpdate %s/%s", update.Namespace, update.Name), progress.add)
+
+	// the progress has been written without the resource version, so is the outcome
+	base := update.DeepCopy()
+	update.Status.Output = progress.output.String()
+	if updateErr != nil {
+		reqLogger.Error(updateErr, "Failed to update gravity")
+		r.Recorder.Eventf(update, "Warning", reasonFailed, "Gravity update on %s %s failed: %v", ref.Kind, ref.Name, updateErr)
+		r.finish(update, updateErr, gravityFailureReason(updateErr))
+	} else {
+		r.Recorder.Eventf(update, "Normal", reasonSucceeded, "Gravity was updated on %s %s", ref.Kind, ref.Name)
+		r.finish(update, nil, reasonSucceeded)
+	}
+
+	err = r.Status().Patch(ctx, update, client.MergeFrom(base))
+	if client.IgnoreNotFound(err) != nil {
+		reqLogger.Error(err, "Failed to update GravityUpdate status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// finish completes the status of a GravityUpdate with the outcome of the update
+func (r *GravityUpdateReconciler) finish(update *networkingv1alpha1.GravityUpdate, err error, reason string) {
+	now := metav1.Now()
+	update.Status.CompletionTime = &now
+
+	if err != nil {
+		update.Status.Phase = networkingv1alpha1.GravityUpdateFailed
+		setStatusCondition(&update.Status.Conditions, update.Generation, conditionSucceeded, metav1.ConditionFalse, reason, err.Error())
+
+		return
+	}
+
+	ref := update.Spec.InstanceRef
+	update.Status.Phase = networkingv1alpha1.GravityUpdateSucceeded
+	setStatusCondition(&update.Status.Conditions, update.Generation, conditionSucceeded, metav1.ConditionTrue, reason,
+		fmt.Sprintf("Gravity was updated on %s %s", ref.Kind, ref.Name))
+}
+
+// gravityProgress writes the output of a running update to the status of its GravityUpdate
+type gravityProgress struct {
+	ctx      context.Context
+	client   client.StatusClient
+	update   *networkingv1alpha1.GravityUpdate
+	output   gravityOutput
+	interval time.Duration
+	written  time.Time
+}
+
+// progress returns the progress of an update that is about to start
+func (r *GravityUpdateReconciler) progress(ctx context.Context, update *networkingv1alpha1.GravityUpdate) *gravityProgress {
+	interval := r.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+
+	return &gravityProgress{ctx: ctx, client: r, update: update, interval: interval, written: time.Now()}
+}
+
+// add adds a line of output and writes the status if the last write is older than the interval
+func (p *gravityProgress) add(line string) {
+	p.output.add(line)
+
+	if time.Since(p.written) < p.interval {
+		return
+	}
+	p.written = time.Now()
+
+	base := p.update.DeepCopy()
+	p.update.Status.Output = p.output.String()
+	if err := p.client.Status().Patch(p.ctx, p.update, client.MergeFrom(base)); err != nil {
+		// the output is written again with the next line or once the update is done
+		log.FromContext(p.ctx).Error(err, "Failed to update GravityUpdate output")
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GravityUpdateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1alpha1.GravityUpdate{}, builder.WithPredicates(ignoreStatusUpdates)).
+		WithOptions(controller.Options{MaxConcurrentReconciles: gravityUpdateConcurrency}).
+		Complete(r)
+}
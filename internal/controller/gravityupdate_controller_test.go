@@ -0,0 +1,183 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+var _ = Describe("GravityUpdate Controller", func() {
+	const resourceName = "test-gravityupdate"
+	const instanceName = "gravityupdate-instance"
+
+	ctx := context.Background()
+
+	typeNamespacedName := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+	var piHole *fake.PiHole
+	var controllerReconciler *GravityUpdateReconciler
+
+	reconcileUpdate := func() (reconcile.Result, error) {
+		return controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+	}
+
+	getUpdate := func() *networkingv1alpha1.GravityUpdate {
+		update := &networkingv1alpha1.GravityUpdate{}
+		Expect(k8sClient.Get(ctx, typeNamespacedName, update)).To(Succeed())
+
+		return update
+	}
+
+	succeededCondition := func() *metav1.Condition {
+		condition := meta.FindStatusCondition(getUpdate().Status.Conditions, "Succeeded")
+		Expect(condition).NotTo(BeNil())
+
+		return condition
+	}
+
+	getInstance := func() *networkingv1alpha1.PiHoleInstance {
+		instance := &networkingv1alpha1.PiHoleInstance{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceName, Namespace: "default"}, instance)).To(Succeed())
+
+		return instance
+	}
+
+	BeforeEach(func() {
+		piHole = fake.NewPiHole()
+		piHole.SetGravityOutput("[i] Neutrino emissions detected...", "[✓] Done.")
+
+		recorder := record.NewFakeRecorder(100)
+		controllerReconciler = &GravityUpdateReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: recorder,
+			Gravity:  NewGravityScheduler(k8sClient, recorder, fakePiHoles(piHole), 0),
+		}
+
+		createInstance(ctx, instanceName)
+
+		update := &networkingv1alpha1.GravityUpdate{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			Spec: networkingv1alpha1.GravityUpdateSpec{
+				InstanceRef: networkingv1alpha1.InstanceReference{Name: instanceName},
+			},
+		}
+		Expect(k8sClient.Create(ctx, update)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		update := &networkingv1alpha1.GravityUpdate{}
+		err := k8sClient.Get(ctx, typeNamespacedName, update)
+		if errors.IsNotFound(err) {
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+		Expect(k8sClient.Delete(ctx, update)).To(Succeed())
+	})
+
+	It("should update gravity and report its output", func() {
+		_, err := reconcileUpdate()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Calls(fake.UpdateGravity)).To(Equal(1))
+
+		update := getUpdate()
+		Expect(update.Status.Phase).To(Equal(networkingv1alpha1.GravityUpdateSucceeded))
+		Expect(update.Status.StartTime).NotTo(BeNil())
+		Expect(update.Status.CompletionTime).NotTo(BeNil())
+		Expect(update.Status.Output).To(Equal("[i] Neutrino emissions detected...\n[✓] Done."))
+
+		condition := succeededCondition()
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("Succeeded"))
+	})
+
+	It("should record the update on the instance", func() {
+		_, err := reconcileUpdate()
+		Expect(err).NotTo(HaveOccurred())
+
+		instance := getInstance()
+		Expect(instance.Status.Gravity).NotTo(BeNil())
+		Expect(instance.Status.Gravity.Phase).To(Equal(networkingv1alpha1.GravityUpdateSucceeded))
+
+		condition := meta.FindStatusCondition(instance.Status.Conditions, "GravityUpdated")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring("GravityUpdate default/" + resourceName))
+	})
+
+	It("should report a failed update", func() {
+		piHole.SetError(fake.UpdateGravity, fmt.Errorf("%w: Status: Not found", pihole.ErrGravityFailed))
+
+		_, err := reconcileUpdate()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(getUpdate().Status.Phase).To(Equal(networkingv1alpha1.GravityUpdateFailed))
+
+		condition := succeededCondition()
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("Failed"))
+		Expect(condition.Message).To(ContainSubstring("Status: Not found"))
+
+		condition = meta.FindStatusCondition(getInstance().Status.Conditions, "GravityUpdated")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+	})
+
+	It("should report Pi-holes not supporting gravity updates", func() {
+		piHole.SetError(fake.UpdateGravity, fmt.Errorf("gravity updates are %w", pihole.ErrUnsupported))
+
+		_, err := reconcileUpdate()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(succeededCondition().Reason).To(Equal("Unsupported"))
+	})
+
+	It("should not run an update again", func() {
+		_, err := reconcileUpdate()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = reconcileUpdate()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Calls(fake.UpdateGravity)).To(Equal(1))
+	})
+
+	It("should fail an update interrupted by a restart", func() {
+		update := getUpdate()
+		update.Status.Phase = networkingv1alpha1.GravityUpdateRunning
+		Expect(k8sClient.Status().Update(ctx, update)).To(Succeed())
+
+		_, err := reconcileUpdate()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Calls(fake.UpdateGravity)).To(BeZero())
+
+		Expect(getUpdate().Status.Phase).To(Equal(networkingv1alpha1.GravityUpdateFailed))
+		Expect(succeededCondition().Reason).To(Equal("Interrupted"))
+	})
+})
@@ -0,0 +1,366 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	kerrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const groupFinalizerName = "group.networking.liebler.dev/finalizer"
+
+// GroupReconciler reconciles a Group object
+type GroupReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	PiHoles  *PiHoleClients
+
+	// Registry records which Group created or adopted which group on a Pi-hole
+	Registry *OwnershipRegistry
+
+	// ResyncInterval is the interval in which groups are checked for manual changes, 0 disables it
+	ResyncInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=groups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=groups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=groups/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=adlists,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=domainrules,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeclients,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=clusterpiholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;update
+
+// Reconcile creates the group named like a Group on its Pi-hole. A group with that
+// name that already exists is only taken over if the adoption policy of the Group
+// allows it, otherwise the Group gets a Conflict condition. The group is only removed
+// from the Pi-hole once no Adlist, DomainRule or PiHoleClient is assigned to it
+// anymore, as the Pi-hole would silently remove it from all of them.
+func (r *GroupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	group := &networkingv1alpha1.Group{}
+	err := r.Get(ctx, req.NamespacedName, group)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("Group resource not found. Ignoring since object must be deleted.")
+
+			return ctrl.Result{}, nil
+		}
+
+		reqLogger.Error(err, "Failed to get Group")
+		return ctrl.Result{}, err
+	}
+
+	reqLogger.Info("Reconciling Group", "Name", group.Name)
+
+	if group.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(group, groupFinalizerName) {
+			controllerutil.AddFinalizer(group, groupFinalizerName)
+			err = r.Update(ctx, group)
+			if err != nil {
+				reqLogger.Error(err, "Failed to update Group with finalizer")
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if controllerutil.ContainsFinalizer(group, groupFinalizerName) {
+			return r.deleteGroup(ctx, group)
+		}
+
+		// Stop reconciliation as the item is being deleted
+		return ctrl.Result{}, nil
+	}
+
+	group.Status.ObservedGeneration = group.Generation
+
+	synced, syncErr := r.syncGroup(ctx, group)
+	if syncErr != nil {
+		reqLogger.Error(syncErr, "Failed to sync group")
+		r.Recorder.Eventf(group, "Warning", "SyncFailed", "Failed to sync group to %s %s: %v", group.Spec.InstanceRef.Kind, group.Spec.InstanceRef.Name, syncErr)
+	} else {
+		group.Status.ID = int32(synced.ID)
+	}
+
+	setConflict(&group.Status.Conditions, group.Generation, syncErr, "Group is managed by the operator")
+	setReady(&group.Status.Conditions, group.Generation, syncErr,
+		fmt.Sprintf("Group exists in %s %s", group.Spec.InstanceRef.Kind, group.Spec.InstanceRef.Name))
+
+	err = r.Status().Update(ctx, group)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update Group status")
+		return ctrl.Result{}, err
+	}
+
+	return resourceSyncResult(syncErr, r.ResyncInterval)
+}
+
+// syncGroup creates or updates the group on the Pi-hole and returns it as stored there
+func (r *GroupReconciler) syncGroup(ctx context.Context, group *networkingv1alpha1.Group) (pihole.Group, error) {
+	ref := group.Spec.InstanceRef
+
+	groups, err := r.PiHoles.Groups(ctx, group.Namespace, ref)
+	if err != nil {
+		return pihole.Group{}, err
+	}
+
+	wanted := pihole.Group{
+		Name:    group.Name,
+		Comment: group.Spec.Comment,
+		Enabled: group.Spec.Enabled == nil || *group.Spec.Enabled,
+	}
+
+	existing, err := groups.GetGroups(ctx)
+	if err != nil {
+		return pihole.Group{}, err
+	}
+
+	instance := instanceKey(group.Namespace, ref)
+	key := groupKey(group)
+
+	i := slices.IndexFunc(existing, func(g pihole.Group) bool { return g.Name == wanted.Name })
+	if i < 0 {
+		// claim the group before creating it, so it is never left behind unowned
+		if err := r.Registry.claim(ctx, instance, key, group, "Group"); err != nil {
+			return pihole.Group{}, fmt.Errorf("group %s: %w", group.Name, err)
+		}
+
+		created, err := groups.CreateGroup(ctx, wanted)
+		if err != nil {
+			return pihole.Group{}, err
+		}
+
+		r.Recorder.Eventf(group, "Normal", "Created", "Successfully created group in %s %s", ref.Kind, ref.Name)
+
+		return created, nil
+	}
+
+	current := existing[i]
+	owned, err := r.Registry.owns(ctx, instance, key, group)
+	if err != nil {
+		return pihole.Group{}, err
+	}
+
+	if !owned {
+		// a Group of the same name in another namespace may manage it in a ClusterPiHoleInstance
+		if err := r.Registry.checkClaim(ctx, instance, key, group, "Group"); err != nil {
+			return pihole.Group{}, fmt.Errorf("group %d in %s %s: %w", current.ID, ref.Kind, ref.Name, err)
+		}
+
+		if group.Spec.AdoptionPolicy != networkingv1alpha1.AdoptionPolicyAdopt {
+			return pihole.Group{}, fmt.Errorf("%w: group %d in %s %s was not created by the operator, set adoptionPolicy to Adopt to take it over",
+				errNotOwned, current.ID, ref.Kind, ref.Name)
+		}
+
+		if err := r.Registry.claim(ctx, instance, key, group, "Group"); err != nil {
+			return pihole.Group{}, fmt.Errorf("group %d in %s %s: %w", current.ID, ref.Kind, ref.Name, err)
+		}
+
+		r.Recorder.Eventf(group, "Normal", "Adopted", "Adopted existing group %d in %s %s", current.ID, ref.Kind, ref.Name)
+	}
+
+	if current.Comment == wanted.Comment && current.Enabled == wanted.Enabled {
+		return current, nil
+	}
+
+	return groups.UpdateGroup(ctx, wanted)
+}
+
+// deleteGroup removes the group from the Pi-hole and the finalizer from the Group,
+// unless resources are still assigned to it. The Group is reconciled again once
+// they are deleted or assigned to other groups.
+func (r *GroupReconciler) deleteGroup(ctx context.Context, group *networkingv1alpha1.Group) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	references, err := referencesTo(ctx, r, group)
+	if err != nil {
+		reqLogger.Error(err, "Failed to list resources assigned to Group")
+		return ctrl.Result{}, err
+	}
+
+	if len(references) > 0 {
+		names := make([]string, 0, len(references))
+		for _, reference := range references {
+			names = append(names, reference.String())
+		}
+		message := fmt.Sprintf("Group can't be deleted while it is referenced by %s", strings.Join(names, ", "))
+
+		reqLogger.Info("Group is still referenced", "References", names)
+		r.Recorder.Event(group, "Warning", "DeletionBlocked", message)
+		setStatusCondition(&group.Status.Conditions, group.Generation, conditionReady, metav1.ConditionFalse, reasonInUse, message)
+
+		err = r.Status().Update(ctx, group)
+		if err != nil {
+			reqLogger.Error(err, "Failed to update Group status")
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	reqLogger.Info("Deleting group")
+
+	err = r.cleanupGroup(ctx, group)
+	if err != nil {
+		reqLogger.Error(err, "Failed to cleanup group")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// cleanupGroup removes the group from the Pi-hole if the Group created or adopted
+// it, and the finalizer from the Group
+func (r *GroupReconciler) cleanupGroup(ctx context.Context, group *networkingv1alpha1.Group) error {
+	groups, err := r.PiHoles.Groups(ctx, group.Namespace, group.Spec.InstanceRef)
+	if err == nil {
+		err = r.deleteOwnedGroup(ctx, groups, group)
+	}
+
+	switch {
+	case isInstanceNotFound(err):
+		// the instance is gone, there is nothing left to clean up
+	case kerrors.Is(err, pihole.ErrNotFound), kerrors.Is(err, pihole.ErrUnsupported):
+		// the group has already been removed, was never created or isn't owned by the Group
+	case err != nil:
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(group, groupFinalizerName)
+
+	return r.Update(ctx, group)
+}
+
+// deleteOwnedGroup deletes the group from the Pi-hole and forgets its owner if it has
+// been created or adopted by the Group, it fails with pihole.ErrNotFound otherwise
+func (r *GroupReconciler) deleteOwnedGroup(ctx context.Context, groups pihole.GroupClient, group *networkingv1alpha1.Group) error {
+	instance := instanceKey(group.Namespace, group.Spec.InstanceRef)
+	key := groupKey(group)
+
+	owned, err := r.Registry.owns(ctx, instance, key, group)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return pihole.ErrNotFound
+	}
+
+	err = groups.DeleteGroup(ctx, group.Name)
+	if err != nil && !kerrors.Is(err, pihole.ErrNotFound) {
+		return err
+	}
+
+	if releaseErr := r.Registry.releaseKey(ctx, instance, key); releaseErr != nil {
+		return releaseErr
+	}
+
+	return err
+}
+
+// groupKey identifies the group of a Group in the ownership registry
+func groupKey(group *networkingv1alpha1.Group) string {
+	return fmt.Sprintf("Group %s", group.Name)
+}
+
+// groupsForInstance maps a PiHoleInstance or ClusterPiHoleInstance to all Groups referencing it.
+func (r *GroupReconciler) groupsForInstance(ctx context.Context, obj client.Object) []reconcile.Request {
+	ref := instanceRefOf(obj)
+
+	var opts []client.ListOption
+	if obj.GetNamespace() != "" {
+		opts = append(opts, client.InNamespace(obj.GetNamespace()))
+	}
+
+	groups := &networkingv1alpha1.GroupList{}
+	if err := r.List(ctx, groups, opts...); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list Groups")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, group := range groups.Items {
+		if !sameInstance(group.Spec.InstanceRef, ref) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&group)})
+	}
+
+	return requests
+}
+
+// groupsForResource maps an Adlist, DomainRule or PiHoleClient to the deleted Groups
+// it is assigned to, so they are removed once they aren't referenced anymore.
+func (r *GroupReconciler) groupsForResource(ctx context.Context, obj client.Object) []reconcile.Request {
+	reference, ok := groupReferenceOf(obj)
+	if !ok {
+		return nil
+	}
+
+	groups := &networkingv1alpha1.GroupList{}
+	if err := r.List(ctx, groups); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list Groups")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, group := range groups.Items {
+		if group.DeletionTimestamp.IsZero() || !reference.references(&group) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&group)})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *GroupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1alpha1.Group{}, builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.PiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.groupsForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.ClusterPiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.groupsForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.Adlist{}, handler.EnqueueRequestsFromMapFunc(r.groupsForResource)).
+		Watches(&networkingv1alpha1.DomainRule{}, handler.EnqueueRequestsFromMapFunc(r.groupsForResource)).
+		Watches(&networkingv1alpha1.PiHoleClient{}, handler.EnqueueRequestsFromMapFunc(r.groupsForResource)).
+		Complete(r)
+}
@@ -0,0 +1,336 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+var _ = Describe("Group Controller", func() {
+	const resourceName = "kids"
+	const instanceName = "group-instance"
+
+	ctx := context.Background()
+
+	typeNamespacedName := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+	var piHole *fake.PiHole
+	var controllerReconciler *GroupReconciler
+
+	reconcileGroup := func() (reconcile.Result, error) {
+		return controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+	}
+
+	getGroup := func() *networkingv1alpha1.Group {
+		group := &networkingv1alpha1.Group{}
+		Expect(k8sClient.Get(ctx, typeNamespacedName, group)).To(Succeed())
+
+		return group
+	}
+
+	groupNames := func() []string {
+		var names []string
+		for _, group := range piHole.Groups() {
+			names = append(names, group.Name)
+		}
+
+		return names
+	}
+
+	// createAdlist creates an Adlist assigned to the group and removes it without
+	// waiting for its controller once the test is done
+	createAdlist := func(name string) *networkingv1alpha1.Adlist {
+		adlist := &networkingv1alpha1.Adlist{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Finalizers: []string{adlistFinalizerName}},
+			Spec: networkingv1alpha1.AdlistSpec{
+				InstanceRef: networkingv1alpha1.InstanceReference{Name: instanceName},
+				URL:         "https://example.com/" + name + ".txt",
+				Groups:      []string{resourceName},
+			},
+		}
+		Expect(k8sClient.Create(ctx, adlist)).To(Succeed())
+		DeferCleanup(func() {
+			removeAdlist(ctx, adlist)
+		})
+
+		return adlist
+	}
+
+	BeforeEach(func() {
+		piHole = fake.NewPiHole()
+		controllerReconciler = &GroupReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+			PiHoles:  fakePiHoles(piHole),
+			Registry: newRegistry(),
+		}
+
+		createInstance(ctx, instanceName)
+
+		group := &networkingv1alpha1.Group{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			Spec: networkingv1alpha1.GroupSpec{
+				InstanceRef: networkingv1alpha1.InstanceReference{Name: instanceName},
+				Comment:     "stricter blocking",
+			},
+		}
+		Expect(k8sClient.Create(ctx, group)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		deleteOwnership(ctx)
+
+		group := &networkingv1alpha1.Group{}
+		err := k8sClient.Get(ctx, typeNamespacedName, group)
+		if errors.IsNotFound(err) {
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+
+		group.Finalizers = nil
+		Expect(k8sClient.Update(ctx, group)).To(Succeed())
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, group))).To(Succeed())
+	})
+
+	It("should create the group on the Pi-hole", func() {
+		_, err := reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+
+		groups := piHole.Groups()
+		Expect(groups).To(HaveLen(2))
+		Expect(groups[1].Name).To(Equal("kids"))
+		Expect(groups[1].Comment).To(Equal("stricter blocking"))
+		Expect(groups[1].Enabled).To(BeTrue())
+
+		group := getGroup()
+		Expect(group.Finalizers).To(ContainElement(groupFinalizerName))
+		Expect(group.Status.ID).To(Equal(int32(groups[1].ID)))
+		Expect(meta.IsStatusConditionTrue(group.Status.Conditions, "Ready")).To(BeTrue())
+	})
+
+	It("should not take over an existing group it didn't create", func() {
+		piHole.SetGroups(pihole.Group{ID: 3, Name: "kids", Comment: "by hand", Enabled: false})
+
+		result, err := reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		Expect(piHole.Groups()[1]).To(Equal(pihole.Group{ID: 3, Name: "kids", Comment: "by hand", Enabled: false}))
+		group := getGroup()
+		Expect(group.Status.ID).To(BeZero())
+		condition := meta.FindStatusCondition(group.Status.Conditions, "Conflict")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring("adoptionPolicy"))
+
+		Expect(k8sClient.Delete(ctx, group)).To(Succeed())
+		_, err = reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Calls(fake.DeleteGroup)).To(BeZero())
+		Expect(groupNames()).To(ContainElement("kids"))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.Group{}))).To(BeTrue())
+	})
+
+	It("should not take over a group managed by a Group in another namespace", func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+		err := k8sClient.Create(ctx, namespace)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		instance := &networkingv1alpha1.ClusterPiHoleInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName},
+			Spec: networkingv1alpha1.PiHoleInstanceSpec{
+				URL: "http://pi.hole/api",
+				AppPasswordSecretRef: networkingv1alpha1.SecretKeyReference{
+					Name: instanceName, Namespace: "default", Key: "password",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, instance))).To(Succeed())
+		})
+
+		ref := networkingv1alpha1.InstanceReference{Kind: networkingv1alpha1.ClusterPiHoleInstanceKind, Name: instanceName}
+		var shared []*networkingv1alpha1.Group
+		for _, ns := range []string{"default", "other"} {
+			group := &networkingv1alpha1.Group{
+				ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: ns},
+				Spec: networkingv1alpha1.GroupSpec{
+					InstanceRef:    ref,
+					AdoptionPolicy: networkingv1alpha1.AdoptionPolicyAdopt,
+				},
+			}
+			Expect(k8sClient.Create(ctx, group)).To(Succeed())
+			DeferCleanup(func() {
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(group), group)
+				if errors.IsNotFound(err) {
+					return
+				}
+				Expect(err).NotTo(HaveOccurred())
+
+				group.Finalizers = nil
+				Expect(k8sClient.Update(ctx, group)).To(Succeed())
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, group))).To(Succeed())
+			})
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(group)})
+			Expect(err).NotTo(HaveOccurred())
+			shared = append(shared, group)
+		}
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(shared[0]), shared[0])).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(shared[0].Status.Conditions, "Ready")).To(BeTrue())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(shared[1]), shared[1])).To(Succeed())
+		condition := meta.FindStatusCondition(shared[1].Status.Conditions, "Conflict")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring("Group default/shared"))
+
+		Expect(k8sClient.Delete(ctx, shared[1])).To(Succeed())
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(shared[1])})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groupNames()).To(ContainElement("shared"))
+	})
+
+	It("should adopt and update an existing group", func() {
+		piHole.SetGroups(pihole.Group{ID: 3, Name: "kids", Enabled: false})
+		group := getGroup()
+		group.Spec.AdoptionPolicy = networkingv1alpha1.AdoptionPolicyAdopt
+		Expect(k8sClient.Update(ctx, group)).To(Succeed())
+
+		_, err := reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Calls(fake.CreateGroup)).To(BeZero())
+		Expect(piHole.Groups()[1]).To(Equal(pihole.Group{ID: 3, Name: "kids", Comment: "stricter blocking", Enabled: true}))
+		Expect(getGroup().Status.ID).To(Equal(int32(3)))
+	})
+
+	It("should disable the group", func() {
+		group := getGroup()
+		enabled := false
+		group.Spec.Enabled = &enabled
+		Expect(k8sClient.Update(ctx, group)).To(Succeed())
+
+		_, err := reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Groups()[1].Enabled).To(BeFalse())
+	})
+
+	It("should report Pi-holes without group support", func() {
+		controllerReconciler.PiHoles.NewClient = func(pihole.Config) pihole.Client {
+			return pihole.NewLegacyPiHole("http://pi.hole/admin/api.php", "secret")
+		}
+
+		_, err := reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+
+		condition := meta.FindStatusCondition(getGroup().Status.Conditions, "Ready")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("Unsupported"))
+	})
+
+	It("should remove the group from the Pi-hole when it is deleted", func() {
+		_, err := reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, getGroup())).To(Succeed())
+		_, err = reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(groupNames()).To(Equal([]string{"Default"}))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.Group{}))).To(BeTrue())
+	})
+
+	It("should block the deletion while an adlist is assigned to the group", func() {
+		_, err := reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+
+		adlist := createAdlist("group-adlist")
+
+		Expect(k8sClient.Delete(ctx, getGroup())).To(Succeed())
+		_, err = reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(groupNames()).To(ContainElement("kids"))
+		group := getGroup()
+		Expect(group.Finalizers).To(ContainElement(groupFinalizerName))
+		condition := meta.FindStatusCondition(group.Status.Conditions, "Ready")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Reason).To(Equal("InUse"))
+		Expect(condition.Message).To(ContainSubstring("Adlist default/group-adlist"))
+
+		Expect(controllerReconciler.groupsForResource(ctx, adlist)).To(ConsistOf(reconcile.Request{NamespacedName: typeNamespacedName}))
+
+		removeAdlist(ctx, adlist)
+		_, err = reconcileGroup()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(groupNames()).To(Equal([]string{"Default"}))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.Group{}))).To(BeTrue())
+	})
+
+	It("should ignore resources assigned to a group of the same name on another Pi-hole", func() {
+		adlist := createAdlist("other-instance-adlist")
+		adlist.Spec.InstanceRef = networkingv1alpha1.InstanceReference{Kind: networkingv1alpha1.ClusterPiHoleInstanceKind, Name: instanceName}
+
+		reference, ok := groupReferenceOf(adlist)
+		Expect(ok).To(BeTrue())
+		Expect(reference.references(getGroup())).To(BeFalse())
+	})
+
+	It("should enqueue the resources assigned to a group", func() {
+		adlist := createAdlist("enqueued-adlist")
+
+		mapFunc := resourcesForGroup(k8sClient, func() client.ObjectList { return &networkingv1alpha1.AdlistList{} })
+		Expect(mapFunc(ctx, getGroup())).To(ContainElement(reconcile.Request{NamespacedName: client.ObjectKeyFromObject(adlist)}))
+	})
+})
+
+// removeAdlist deletes an Adlist without waiting for its controller to remove its finalizer
+func removeAdlist(ctx context.Context, adlist *networkingv1alpha1.Adlist) {
+	current := &networkingv1alpha1.Adlist{}
+	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(adlist), current)
+	if errors.IsNotFound(err) {
+		return
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	current.Finalizers = nil
+	Expect(k8sClient.Update(ctx, current)).To(Succeed())
+	Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, current))).To(Succeed())
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+// groupRetryInterval is the interval in which a resource referencing a group that
+// doesn't exist yet is reconciled again
+const groupRetryInterval = time.Minute
+
+// errGroupNotFound is returned if a resource references a group that doesn't exist on its Pi-hole
+var errGroupNotFound = errors.New("group not found")
+
+// resolveGroups returns the ids of the named groups on the referenced instance, no
+// names select the Default group
+func resolveGroups(ctx context.Context, piHoles *PiHoleClients, namespace string, ref networkingv1alpha1.InstanceReference, names []string) ([]int, error) {
+	if len(names) == 0 {
+		return []int{pihole.DefaultGroupID}, nil
+	}
+
+	groupClient, err := piHoles.Groups(ctx, namespace, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := groupClient.GetGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := pihole.GroupIDs(groups, names)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errGroupNotFound, err)
+	}
+
+	return ids, nil
+}
+
+// groupReference is a resource assigned to Pi-hole groups by their names
+type groupReference struct {
+	kind   string
+	object client.Object
+	// instance identifies the Pi-hole the resource is managed in
+	instance string
+	groups   []string
+}
+
+func (r groupReference) String() string {
+	return fmt.Sprintf("%s %s", r.kind, client.ObjectKeyFromObject(r.object))
+}
+
+// references is true if the resource is assigned to the group
+func (r groupReference) references(group *networkingv1alpha1.Group) bool {
+	return r.instance == instanceKey(group.Namespace, group.Spec.InstanceRef) && slices.Contains(r.groups, group.Name)
+}
+
+// groupReferenceOf returns the group assignments of an Adlist, DomainRule or PiHoleClient
+func groupReferenceOf(obj client.Object) (groupReference, bool) {
+	var kind string
+	var ref networkingv1alpha1.InstanceReference
+	var groups []string
+
+	switch o := obj.(type) {
+	case *networkingv1alpha1.Adlist:
+		kind, ref, groups = "Adlist", o.Spec.InstanceRef, o.Spec.Groups
+	case *networkingv1alpha1.DomainRule:
+		kind, ref, groups = "DomainRule", o.Spec.InstanceRef, o.Spec.Groups
+	case *networkingv1alpha1.PiHoleClient:
+		kind, ref, groups = "PiHoleClient", o.Spec.InstanceRef, o.Spec.Groups
+	default:
+		return groupReference{}, false
+	}
+
+	return groupReference{kind: kind, object: obj, instance: instanceKey(obj.GetNamespace(), ref), groups: groups}, true
+}
+
+// listGroupReferences returns the group assignments of all resources in the given lists
+func listGroupReferences(ctx context.Context, c client.Reader, lists ...client.ObjectList) ([]groupReference, error) {
+	var references []groupReference
+	for _, list := range lists {
+		if err := c.List(ctx, list); err != nil {
+			return nil, err
+		}
+
+		err := meta.EachListItem(list, func(obj runtime.Object) error {
+			if reference, ok := groupReferenceOf(obj.(client.Object)); ok {
+				references = append(references, reference)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return references, nil
+}
+
+// referencesTo returns the resources assigned to a group
+func referencesTo(ctx context.Context, c client.Reader, group *networkingv1alpha1.Group) ([]groupReference, error) {
+	references, err := listGroupReferences(ctx, c,
+		&networkingv1alpha1.AdlistList{}, &networkingv1alpha1.DomainRuleList{}, &networkingv1alpha1.PiHoleClientList{})
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(references, func(r groupReference) bool { return !r.references(group) }), nil
+}
+
+// resourcesForGroup returns a map func enqueuing the resources in list that are
+// assigned to a Group, so they are synced as soon as their group exists
+func resourcesForGroup(c client.Reader, list func() client.ObjectList) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		group, ok := obj.(*networkingv1alpha1.Group)
+		if !ok {
+			return nil
+		}
+
+		references, err := listGroupReferences(ctx, c, list())
+		if err != nil {
+			log.FromContext(ctx).Error(err, "Failed to list resources assigned to Group", "Group", group.Name)
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, reference := range references {
+			if reference.references(group) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(reference.object)})
+			}
+		}
+
+		return requests
+	}
+}
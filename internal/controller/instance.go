@@ -0,0 +1,434 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+// PiHoleClients resolves PiHoleInstances and ClusterPiHoleInstances and caches one
+// Pi-hole client per instance. A cached client is replaced as soon as the spec of
+// the instance or one of the Secrets it references changes.
+type PiHoleClients struct {
+	// Client is used to read instances
+	Client client.Reader
+	// SecretReader is used to read Secrets, it should not be backed by a cache to
+	// avoid watching all Secrets in the cluster
+	SecretReader client.Reader
+	// NewClient creates the client for an instance, defaults to pihole.NewClient
+	NewClient func(config pihole.Config) pihole.Client
+	// Timeout is the request timeout of instances that don't configure their own
+	Timeout time.Duration
+	// Retry is the retry policy of instances that don't configure their own
+	Retry pihole.RetryPolicy
+	// CacheMaxAge is the time the records of an instance are cached, 0 disables the cache
+	CacheMaxAge time.Duration
+	// BatchWindow is the time writes to an instance are collected to be applied at once,
+	// 0 applies every write on its own
+	BatchWindow time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*cachedPiHole
+}
+
+type cachedPiHole struct {
+	version string
+	piHole  pihole.Client
+	// api is the client without caching and batching, it implements the feature
+	// interfaces supported by the instance
+	api pihole.Client
+}
+
+// Get returns the Pi-hole client for the instance referenced from an object in the given namespace.
+func (c *PiHoleClients) Get(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference) (pihole.Client, error) {
+	cached, err := c.get(ctx, namespace, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return cached.piHole, nil
+}
+
+// Lists returns the adlist API of the instance referenced from an object in the given namespace.
+func (c *PiHoleClients) Lists(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference) (pihole.ListClient, error) {
+	return featureClient[pihole.ListClient](ctx, c, namespace, ref, "adlists")
+}
+
+// Groups returns the group API of the instance referenced from an object in the given namespace.
+func (c *PiHoleClients) Groups(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference) (pihole.GroupClient, error) {
+	return featureClient[pihole.GroupClient](ctx, c, namespace, ref, "groups")
+}
+
+// Domains returns the domain API of the instance referenced from an object in the given namespace.
+func (c *PiHoleClients) Domains(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference) (pihole.DomainClient, error) {
+	return featureClient[pihole.DomainClient](ctx, c, namespace, ref, "domain rules")
+}
+
+// Clients returns the client API of the instance referenced from an object in the given namespace.
+func (c *PiHoleClients) Clients(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference) (pihole.ClientClient, error) {
+	return featureClient[pihole.ClientClient](ctx, c, namespace, ref, "clients")
+}
+
+// Gravity returns the gravity API of the instance referenced from an object in the given namespace.
+func (c *PiHoleClients) Gravity(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference) (pihole.GravityClient, error) {
+	return featureClient[pihole.GravityClient](ctx, c, namespace, ref, "gravity updates")
+}
+
+// Blocking returns the blocking API of the instance referenced from an object in the given namespace.
+func (c *PiHoleClients) Blocking(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference) (pihole.BlockingClient, error) {
+	return featureClient[pihole.BlockingClient](ctx, c, namespace, ref, "blocking schedules")
+}
+
+// featureClient returns the client of an instance as feature interface T, it fails
+// with pihole.ErrUnsupported if the client doesn't implement it
+func featureClient[T any](ctx context.Context, c *PiHoleClients, namespace string, ref networkingv1alpha1.InstanceReference, feature string) (T, error) {
+	var zero T
+
+	cached, err := c.get(ctx, namespace, ref)
+	if err != nil {
+		return zero, err
+	}
+
+	client, ok := cached.api.(T)
+	if !ok {
+		return zero, fmt.Errorf("%s are %w", feature, pihole.ErrUnsupported)
+	}
+
+	return client, nil
+}
+
+// get returns the cached clients of an instance and creates them if the instance changed
+func (c *PiHoleClients) get(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference) (*cachedPiHole, error) {
+	spec, key, version, err := c.resolve(ctx, namespace, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	secretNamespace := namespace
+	if ref.Kind == networkingv1alpha1.ClusterPiHoleInstanceKind {
+		secretNamespace = ""
+	}
+
+	password, passwordVersion, err := c.readSecret(ctx, secretNamespace, spec.AppPasswordSecretRef)
+	if err != nil {
+		return nil, err
+	}
+	version += "/" + passwordVersion
+
+	var totpSecret []byte
+	if spec.TOTPSecretRef != nil {
+		var totpVersion string
+		totpSecret, totpVersion, err = c.readSecret(ctx, secretNamespace, *spec.TOTPSecretRef)
+		if err != nil {
+			return nil, err
+		}
+		version += "/" + totpVersion
+	}
+
+	var caBundle []byte
+	if spec.TLS != nil && spec.TLS.CASecretRef != nil {
+		var caVersion string
+		caBundle, caVersion, err = c.readSecret(ctx, secretNamespace, *spec.TLS.CASecretRef)
+		if err != nil {
+			return nil, err
+		}
+		version += "/" + caVersion
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.clients == nil {
+		c.clients = map[string]*cachedPiHole{}
+	}
+
+	if cached, ok := c.clients[key]; ok {
+		if cached.version == version {
+			return cached, nil
+		}
+
+		// the instance changed, the old session is not needed anymore
+		_ = cached.piHole.Close(ctx)
+	}
+
+	httpClient, err := newHTTPClient(spec.TLS, caBundle)
+	if err != nil {
+		return nil, err
+	}
+
+	newClient := c.NewClient
+	if newClient == nil {
+		newClient = pihole.NewClient
+	}
+
+	timeout := c.Timeout
+	if spec.Timeout != nil {
+		timeout = spec.Timeout.Duration
+	}
+
+	api := newClient(pihole.Config{
+		URL:         spec.URL,
+		Version:     pihole.Version(spec.Version),
+		AppPassword: string(password),
+		TOTPSecret:  string(totpSecret),
+		HTTPClient:  httpClient,
+		Timeout:     timeout,
+		Retry:       retryPolicy(c.Retry, spec.Retry),
+	})
+
+	piHole := api
+	if c.BatchWindow > 0 {
+		piHole = pihole.NewBatchingClient(piHole, c.BatchWindow)
+	}
+	if c.CacheMaxAge > 0 {
+		piHole = pihole.NewCachingClient(piHole, c.CacheMaxAge)
+	}
+
+	cached := &cachedPiHole{version: version, piHole: piHole, api: api}
+	c.clients[key] = cached
+
+	return cached, nil
+}
+
+// Close closes the sessions of all cached clients.
+func (c *PiHoleClients) Close(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for key, cached := range c.clients {
+		if err := cached.piHole.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close session for %s: %w", key, err))
+		}
+		delete(c.clients, key)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Select returns references to all PiHoleInstances in the given namespace and all
+// ClusterPiHoleInstances matching the selector.
+func (c *PiHoleClients) Select(ctx context.Context, namespace string, selector *metav1.LabelSelector) ([]networkingv1alpha1.InstanceReference, error) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []networkingv1alpha1.InstanceReference
+
+	instances := &networkingv1alpha1.PiHoleInstanceList{}
+	if err := c.Client.List(ctx, instances, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: labelSelector}); err != nil {
+		return nil, err
+	}
+
+	for _, instance := range instances.Items {
+		refs = append(refs, networkingv1alpha1.InstanceReference{Kind: networkingv1alpha1.PiHoleInstanceKind, Name: instance.Name})
+	}
+
+	clusterInstances := &networkingv1alpha1.ClusterPiHoleInstanceList{}
+	if err := c.Client.List(ctx, clusterInstances, client.MatchingLabelsSelector{Selector: labelSelector}); err != nil {
+		return nil, err
+	}
+
+	for _, instance := range clusterInstances.Items {
+		refs = append(refs, networkingv1alpha1.InstanceReference{Kind: networkingv1alpha1.ClusterPiHoleInstanceKind, Name: instance.Name})
+	}
+
+	return refs, nil
+}
+
+func (c *PiHoleClients) resolve(ctx context.Context, namespace string, ref networkingv1alpha1.InstanceReference) (*networkingv1alpha1.PiHoleInstanceSpec, string, string, error) {
+	switch ref.Kind {
+	case networkingv1alpha1.PiHoleInstanceKind, "":
+		instance := &networkingv1alpha1.PiHoleInstance{}
+		if err := c.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, instance); err != nil {
+			return nil, "", "", instanceError(fmt.Errorf("failed to get PiHoleInstance %s/%s: %w", namespace, ref.Name, err))
+		}
+
+		return &instance.Spec, instanceKey(namespace, ref), specVersion(instance), nil
+	case networkingv1alpha1.ClusterPiHoleInstanceKind:
+		instance := &networkingv1alpha1.ClusterPiHoleInstance{}
+		if err := c.Client.Get(ctx, types.NamespacedName{Name: ref.Name}, instance); err != nil {
+			return nil, "", "", instanceError(fmt.Errorf("failed to get ClusterPiHoleInstance %s: %w", ref.Name, err))
+		}
+
+		return &instance.Spec, instanceKey(namespace, ref), specVersion(instance), nil
+	}
+
+	return nil, "", "", fmt.Errorf("invalid instance kind %s", ref.Kind)
+}
+
+// instanceNotFoundError reports that a referenced instance doesn't exist. Like a
+// missing Secret of an existing instance it is a NotFound error, but only a missing
+// instance means that there is nothing left to clean up on the Pi-hole.
+type instanceNotFoundError struct {
+	err error
+}
+
+func (e *instanceNotFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *instanceNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// instanceError marks err as an instanceNotFoundError if the instance doesn't exist
+func instanceError(err error) error {
+	if apierrors.IsNotFound(err) {
+		return &instanceNotFoundError{err: err}
+	}
+
+	return err
+}
+
+// isInstanceNotFound is true if err was caused by a referenced instance that doesn't exist
+func isInstanceNotFound(err error) bool {
+	var notFound *instanceNotFoundError
+
+	return errors.As(err, &notFound)
+}
+
+// specVersion changes whenever the spec of an instance changes or it is recreated. Its
+// status is written regularly, e.g. after gravity updates, which must not replace
+// the client and with it the session of the instance.
+func specVersion(instance client.Object) string {
+	return fmt.Sprintf("%s/%d", instance.GetUID(), instance.GetGeneration())
+}
+
+// instanceKey identifies the instance referenced from an object in the given namespace
+func instanceKey(namespace string, ref networkingv1alpha1.InstanceReference) string {
+	if ref.Kind == networkingv1alpha1.ClusterPiHoleInstanceKind {
+		return fmt.Sprintf("%s/%s", networkingv1alpha1.ClusterPiHoleInstanceKind, ref.Name)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", networkingv1alpha1.PiHoleInstanceKind, namespace, ref.Name)
+}
+
+// instanceRefOf returns a reference to a PiHoleInstance or ClusterPiHoleInstance
+func instanceRefOf(obj client.Object) networkingv1alpha1.InstanceReference {
+	if obj.GetNamespace() == "" {
+		return networkingv1alpha1.InstanceReference{Kind: networkingv1alpha1.ClusterPiHoleInstanceKind, Name: obj.GetName()}
+	}
+
+	return networkingv1alpha1.InstanceReference{Kind: networkingv1alpha1.PiHoleInstanceKind, Name: obj.GetName()}
+}
+
+// sameInstance is true if both references point to the same instance, a reference
+// without a kind points to a PiHoleInstance
+func sameInstance(a networkingv1alpha1.InstanceReference, b networkingv1alpha1.InstanceReference) bool {
+	if a.Kind == "" {
+		a.Kind = networkingv1alpha1.PiHoleInstanceKind
+	}
+	if b.Kind == "" {
+		b.Kind = networkingv1alpha1.PiHoleInstanceKind
+	}
+
+	return a == b
+}
+
+// readSecret reads the referenced key from a Secret. An empty namespace means the
+// namespace given in the reference is used, which is the case for cluster scoped instances.
+func (c *PiHoleClients) readSecret(ctx context.Context, namespace string, ref networkingv1alpha1.SecretKeyReference) ([]byte, string, error) {
+	if namespace == "" {
+		namespace = ref.Namespace
+	}
+	if namespace == "" {
+		return nil, "", fmt.Errorf("namespace is required for secret %s", ref.Name)
+	}
+
+	key := ref.Key
+	if key == "" {
+		key = "password"
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.SecretReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: ref.Name}, secret); err != nil {
+		return nil, "", fmt.Errorf("failed to get secret %s/%s: %w", namespace, ref.Name, err)
+	}
+
+	value, ok := secret.Data[key]
+	if !ok {
+		return nil, "", fmt.Errorf("secret %s/%s has no key %s", namespace, ref.Name, key)
+	}
+
+	return value, secret.ResourceVersion, nil
+}
+
+func newHTTPClient(config *networkingv1alpha1.TLSConfig, caBundle []byte) (*http.Client, error) {
+	if config == nil {
+		return &http.Client{}, nil
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.InsecureSkipVerify, //nolint:gosec // explicitly requested by the user
+		ServerName:         config.ServerName,
+	}
+
+	if len(caBundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBundle) {
+			return nil, fmt.Errorf("failed to parse CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// retryPolicy returns the retry policy of an instance, fields it doesn't set are
+// taken from the defaults
+func retryPolicy(defaults pihole.RetryPolicy, spec *networkingv1alpha1.RetryPolicy) pihole.RetryPolicy {
+	policy := defaults
+	if spec == nil {
+		return policy
+	}
+
+	if spec.MaxAttempts != nil {
+		policy.MaxAttempts = int(*spec.MaxAttempts)
+	}
+	if spec.InitialBackoff != nil {
+		policy.InitialBackoff = spec.InitialBackoff.Duration
+	}
+	if spec.MaxBackoff != nil {
+		policy.MaxBackoff = spec.MaxBackoff.Duration
+	}
+	if spec.BudgetPercent != nil {
+		policy.BudgetPercent = int(*spec.BudgetPercent)
+	}
+
+	return policy
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+var _ = Describe("PiHoleClients", func() {
+	const instanceName = "clients-instance"
+
+	ctx := context.Background()
+
+	ref := networkingv1alpha1.InstanceReference{Name: instanceName}
+
+	var piHoles *PiHoleClients
+	var created int
+
+	getInstance := func() *networkingv1alpha1.PiHoleInstance {
+		instance := &networkingv1alpha1.PiHoleInstance{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: instanceName, Namespace: "default"}, instance)).To(Succeed())
+
+		return instance
+	}
+
+	BeforeEach(func() {
+		created = 0
+		piHoles = &PiHoleClients{
+			Client:       k8sClient,
+			SecretReader: k8sClient,
+			NewClient: func(pihole.Config) pihole.Client {
+				created++
+				return fake.NewPiHole()
+			},
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+			StringData: map[string]string{"password": "secret"},
+		}
+		err := k8sClient.Create(ctx, secret)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		instance := &networkingv1alpha1.PiHoleInstance{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName, Namespace: "default"},
+			Spec: networkingv1alpha1.PiHoleInstanceSpec{
+				URL:                  "http://pi.hole/api",
+				AppPasswordSecretRef: networkingv1alpha1.SecretKeyReference{Name: instanceName, Key: "password"},
+			},
+		}
+		err = k8sClient.Create(ctx, instance)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	It("should keep the client when the status of the instance changes", func() {
+		first, err := piHoles.Get(ctx, "default", ref)
+		Expect(err).NotTo(HaveOccurred())
+
+		instance := getInstance()
+		next := metav1.NewTime(time.Now().Add(time.Hour).Truncate(time.Second))
+		instance.Status.NextGravityUpdate = &next
+		Expect(k8sClient.Status().Update(ctx, instance)).To(Succeed())
+
+		second, err := piHoles.Get(ctx, "default", ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).To(BeIdenticalTo(first))
+		Expect(created).To(Equal(1))
+	})
+
+	It("should replace the client when the spec of the instance changes", func() {
+		_, err := piHoles.Get(ctx, "default", ref)
+		Expect(err).NotTo(HaveOccurred())
+
+		instance := getInstance()
+		instance.Spec.Timeout = &metav1.Duration{Duration: 42 * time.Second}
+		Expect(k8sClient.Update(ctx, instance)).To(Succeed())
+
+		_, err = piHoles.Get(ctx, "default", ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(Equal(2))
+	})
+})
@@ -0,0 +1,32 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// driftCorrections counts the records restored after they were changed outside of the operator
+var driftCorrections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "pihole_operator_drift_corrections_total",
+	Help: "Number of DNS records restored after they were changed outside of the operator",
+}, []string{"pihole"})
+
+func init() {
+	metrics.Registry.MustRegister(driftCorrections)
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	kerrors "errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const piHoleClientFinalizerName = "piholeclient.networking.liebler.dev/finalizer"
+
+// PiHoleClientReconciler reconciles a PiHoleClient object
+type PiHoleClientReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	PiHoles  *PiHoleClients
+
+	// Registry records which PiHoleClient created or adopted which client on a Pi-hole
+	Registry *OwnershipRegistry
+
+	// ResyncInterval is the interval in which clients are checked for manual changes, 0 disables it
+	ResyncInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeclients,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeclients/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeclients/finalizers,verbs=update
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=groups,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=piholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.liebler.dev,resources=clusterpiholeinstances,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;create;update
+
+// Reconcile assigns the client of a PiHoleClient to its groups on the Pi-hole. A
+// client that already exists, e.g. because it was added in the Pi-hole UI, is only
+// taken over and removed with the PiHoleClient if its adoption policy allows it,
+// otherwise the PiHoleClient gets a Conflict condition.
+func (r *PiHoleClientReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+
+	piHoleClient := &networkingv1alpha1.PiHoleClient{}
+	err := r.Get(ctx, req.NamespacedName, piHoleClient)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			reqLogger.Info("PiHoleClient resource not found. Ignoring since object must be deleted.")
+
+			return ctrl.Result{}, nil
+		}
+
+		reqLogger.Error(err, "Failed to get PiHoleClient")
+		return ctrl.Result{}, err
+	}
+
+	reqLogger.Info("Reconciling PiHoleClient", "Name", piHoleClient.Name)
+
+	if piHoleClient.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(piHoleClient, piHoleClientFinalizerName) {
+			controllerutil.AddFinalizer(piHoleClient, piHoleClientFinalizerName)
+			err = r.Update(ctx, piHoleClient)
+			if err != nil {
+				reqLogger.Error(err, "Failed to update PiHoleClient with finalizer")
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		if controllerutil.ContainsFinalizer(piHoleClient, piHoleClientFinalizerName) {
+			reqLogger.Info("Deleting client")
+
+			err = r.cleanupClient(ctx, piHoleClient)
+			if err != nil {
+				reqLogger.Error(err, "Failed to cleanup client")
+				return ctrl.Result{}, err
+			}
+		}
+
+		// Stop reconciliation as the item is being deleted
+		return ctrl.Result{}, nil
+	}
+
+	piHoleClient.Status.ObservedGeneration = piHoleClient.Generation
+
+	synced, syncErr := r.syncClient(ctx, piHoleClient)
+	if syncErr != nil {
+		reqLogger.Error(syncErr, "Failed to sync client")
+		r.Recorder.Eventf(piHoleClient, "Warning", "SyncFailed", "Failed to sync client to %s %s: %v", piHoleClient.Spec.InstanceRef.Kind, piHoleClient.Spec.InstanceRef.Name, syncErr)
+	} else {
+		piHoleClient.Status.ID = int32(synced.ID)
+		piHoleClient.Status.Hostname = synced.Name
+	}
+
+	setConflict(&piHoleClient.Status.Conditions, piHoleClient.Generation, syncErr, "Client is managed by the operator")
+	setReady(&piHoleClient.Status.Conditions, piHoleClient.Generation, syncErr,
+		fmt.Sprintf("Client is assigned to its groups in %s %s", piHoleClient.Spec.InstanceRef.Kind, piHoleClient.Spec.InstanceRef.Name))
+
+	err = r.Status().Update(ctx, piHoleClient)
+	if err != nil {
+		reqLogger.Error(err, "Failed to update PiHoleClient status")
+		return ctrl.Result{}, err
+	}
+
+	return resourceSyncResult(syncErr, r.ResyncInterval)
+}
+
+// syncClient creates or updates the client on the Pi-hole and returns it as stored there
+func (r *PiHoleClientReconciler) syncClient(ctx context.Context, piHoleClient *networkingv1alpha1.PiHoleClient) (pihole.PiHoleClient, error) {
+	ref := piHoleClient.Spec.InstanceRef
+
+	if err := pihole.ValidateClient(piHoleClient.Spec.Client); err != nil {
+		return pihole.PiHoleClient{}, err
+	}
+
+	clients, err := r.PiHoles.Clients(ctx, piHoleClient.Namespace, ref)
+	if err != nil {
+		return pihole.PiHoleClient{}, err
+	}
+
+	groups, err := resolveGroups(ctx, r.PiHoles, piHoleClient.Namespace, ref, piHoleClient.Spec.Groups)
+	if err != nil {
+		return pihole.PiHoleClient{}, err
+	}
+
+	wanted := pihole.PiHoleClient{
+		Client:  piHoleClient.Spec.Client,
+		Comment: piHoleClient.Spec.Comment,
+		Groups:  groups,
+	}
+
+	existing, err := clients.GetClients(ctx)
+	if err != nil {
+		return pihole.PiHoleClient{}, err
+	}
+
+	instance := instanceKey(piHoleClient.Namespace, ref)
+	key := clientKey(piHoleClient)
+
+	i := slices.IndexFunc(existing, func(c pihole.PiHoleClient) bool { return c.Client == wanted.Client })
+	if i < 0 {
+		// claim the client before creating it, so it is never left behind unowned
+		if err := r.Registry.claim(ctx, instance, key, piHoleClient, "PiHoleClient"); err != nil {
+			return pihole.PiHoleClient{}, fmt.Errorf("client %s: %w", piHoleClient.Spec.Client, err)
+		}
+
+		created, err := clients.CreateClient(ctx, wanted)
+		if err != nil {
+			return pihole.PiHoleClient{}, err
+		}
+
+		r.Recorder.Eventf(piHoleClient, "Normal", "Created", "Successfully created client in %s %s", ref.Kind, ref.Name)
+
+		return created, nil
+	}
+
+	current := existing[i]
+	owned, err := r.Registry.owns(ctx, instance, key, piHoleClient)
+	if err != nil {
+		return pihole.PiHoleClient{}, err
+	}
+
+	if !owned {
+		// another PiHoleClient may manage it, e.g. one in another namespace using a ClusterPiHoleInstance
+		if err := r.Registry.checkClaim(ctx, instance, key, piHoleClient, "PiHoleClient"); err != nil {
+			return pihole.PiHoleClient{}, fmt.Errorf("client %d in %s %s: %w", current.ID, ref.Kind, ref.Name, err)
+		}
+
+		if piHoleClient.Spec.AdoptionPolicy != networkingv1alpha1.AdoptionPolicyAdopt {
+			return pihole.PiHoleClient{}, fmt.Errorf("%w: client %d in %s %s was not created by the operator, set adoptionPolicy to Adopt to take it over",
+				errNotOwned, current.ID, ref.Kind, ref.Name)
+		}
+
+		if err := r.Registry.claim(ctx, instance, key, piHoleClient, "PiHoleClient"); err != nil {
+			return pihole.PiHoleClient{}, fmt.Errorf("client %d in %s %s: %w", current.ID, ref.Kind, ref.Name, err)
+		}
+
+		r.Recorder.Eventf(piHoleClient, "Normal", "Adopted", "Adopted existing client %d in %s %s", current.ID, ref.Kind, ref.Name)
+	}
+
+	if current.Comment == wanted.Comment && sameGroups(current.Groups, wanted.Groups) {
+		return current, nil
+	}
+
+	return clients.UpdateClient(ctx, wanted)
+}
+
+// cleanupClient removes the client from the Pi-hole if the PiHoleClient created or
+// adopted it, and the finalizer from the PiHoleClient
+func (r *PiHoleClientReconciler) cleanupClient(ctx context.Context, piHoleClient *networkingv1alpha1.PiHoleClient) error {
+	clients, err := r.PiHoles.Clients(ctx, piHoleClient.Namespace, piHoleClient.Spec.InstanceRef)
+	if err == nil {
+		err = r.deleteOwnedClient(ctx, clients, piHoleClient)
+	}
+
+	switch {
+	case isInstanceNotFound(err):
+		// the instance is gone, there is nothing left to clean up
+	case kerrors.Is(err, pihole.ErrNotFound), kerrors.Is(err, pihole.ErrUnsupported), kerrors.Is(err, pihole.ErrInvalid):
+		// the client has already been removed, was never created or isn't owned by the PiHoleClient
+	case err != nil:
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(piHoleClient, piHoleClientFinalizerName)
+
+	return r.Update(ctx, piHoleClient)
+}
+
+// deleteOwnedClient deletes the client from the Pi-hole and forgets its owner if it has
+// been created or adopted by the PiHoleClient, it fails with pihole.ErrNotFound otherwise
+func (r *PiHoleClientReconciler) deleteOwnedClient(ctx context.Context, clients pihole.ClientClient, piHoleClient *networkingv1alpha1.PiHoleClient) error {
+	instance := instanceKey(piHoleClient.Namespace, piHoleClient.Spec.InstanceRef)
+	key := clientKey(piHoleClient)
+
+	owned, err := r.Registry.owns(ctx, instance, key, piHoleClient)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return pihole.ErrNotFound
+	}
+
+	err = clients.DeleteClient(ctx, piHoleClient.Spec.Client)
+	if err != nil && !kerrors.Is(err, pihole.ErrNotFound) {
+		return err
+	}
+
+	if releaseErr := r.Registry.releaseKey(ctx, instance, key); releaseErr != nil {
+		return releaseErr
+	}
+
+	return err
+}
+
+// clientKey identifies the client of a PiHoleClient in the ownership registry
+func clientKey(piHoleClient *networkingv1alpha1.PiHoleClient) string {
+	return fmt.Sprintf("Client %s", piHoleClient.Spec.Client)
+}
+
+// piHoleClientsForInstance maps a PiHoleInstance or ClusterPiHoleInstance to all PiHoleClients referencing it.
+func (r *PiHoleClientReconciler) piHoleClientsForInstance(ctx context.Context, obj client.Object) []reconcile.Request {
+	ref := instanceRefOf(obj)
+
+	var opts []client.ListOption
+	if obj.GetNamespace() != "" {
+		opts = append(opts, client.InNamespace(obj.GetNamespace()))
+	}
+
+	piHoleClients := &networkingv1alpha1.PiHoleClientList{}
+	if err := r.List(ctx, piHoleClients, opts...); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list PiHoleClients")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, piHoleClient := range piHoleClients.Items {
+		if !sameInstance(piHoleClient.Spec.InstanceRef, ref) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&piHoleClient)})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PiHoleClientReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&networkingv1alpha1.PiHoleClient{}, builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.PiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.piHoleClientsForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.ClusterPiHoleInstance{}, handler.EnqueueRequestsFromMapFunc(r.piHoleClientsForInstance),
+			builder.WithPredicates(ignoreStatusUpdates)).
+		Watches(&networkingv1alpha1.Group{}, handler.EnqueueRequestsFromMapFunc(
+			resourcesForGroup(r, func() client.ObjectList { return &networkingv1alpha1.PiHoleClientList{} }))).
+		Complete(r)
+}
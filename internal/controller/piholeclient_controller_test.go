@@ -0,0 +1,240 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+var _ = Describe("PiHoleClient Controller", func() {
+	const resourceName = "test-piholeclient"
+	const instanceName = "piholeclient-instance"
+
+	ctx := context.Background()
+
+	typeNamespacedName := types.NamespacedName{Name: resourceName, Namespace: "default"}
+
+	var piHole *fake.PiHole
+	var controllerReconciler *PiHoleClientReconciler
+
+	reconcileClient := func() (reconcile.Result, error) {
+		return controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+	}
+
+	getClient := func() *networkingv1alpha1.PiHoleClient {
+		piHoleClient := &networkingv1alpha1.PiHoleClient{}
+		Expect(k8sClient.Get(ctx, typeNamespacedName, piHoleClient)).To(Succeed())
+
+		return piHoleClient
+	}
+
+	readyCondition := func() *metav1.Condition {
+		condition := meta.FindStatusCondition(getClient().Status.Conditions, "Ready")
+		Expect(condition).NotTo(BeNil())
+
+		return condition
+	}
+
+	BeforeEach(func() {
+		piHole = fake.NewPiHole()
+		piHole.SetGroups(pihole.Group{ID: 4, Name: "kids", Enabled: true})
+		controllerReconciler = &PiHoleClientReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: record.NewFakeRecorder(100),
+			PiHoles:  fakePiHoles(piHole),
+			Registry: newRegistry(),
+		}
+
+		createInstance(ctx, instanceName)
+
+		piHoleClient := &networkingv1alpha1.PiHoleClient{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			Spec: networkingv1alpha1.PiHoleClientSpec{
+				InstanceRef: networkingv1alpha1.InstanceReference{Name: instanceName},
+				Client:      "192.168.178.0/24",
+				Comment:     "kids network",
+				Groups:      []string{"kids"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, piHoleClient)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		deleteOwnership(ctx)
+
+		piHoleClient := &networkingv1alpha1.PiHoleClient{}
+		err := k8sClient.Get(ctx, typeNamespacedName, piHoleClient)
+		if errors.IsNotFound(err) {
+			return
+		}
+		Expect(err).NotTo(HaveOccurred())
+
+		piHoleClient.Finalizers = nil
+		Expect(k8sClient.Update(ctx, piHoleClient)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, piHoleClient)).To(Succeed())
+	})
+
+	It("should assign the client to its groups", func() {
+		_, err := reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+
+		clients := piHole.Clients()
+		Expect(clients).To(HaveLen(1))
+		Expect(clients[0].Client).To(Equal("192.168.178.0/24"))
+		Expect(clients[0].Comment).To(Equal("kids network"))
+		Expect(clients[0].Groups).To(Equal([]int{4}))
+
+		piHoleClient := getClient()
+		Expect(piHoleClient.Finalizers).To(ContainElement(piHoleClientFinalizerName))
+		Expect(piHoleClient.Status.ID).To(Equal(int32(clients[0].ID)))
+		Expect(meta.IsStatusConditionTrue(piHoleClient.Status.Conditions, "Ready")).To(BeTrue())
+	})
+
+	It("should not take over an existing client it didn't create", func() {
+		piHole.SetClients(pihole.PiHoleClient{Client: "192.168.178.0/24", Comment: "by hand", Groups: []int{0}})
+
+		result, err := reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		Expect(piHole.Calls(fake.UpdateClient)).To(BeZero())
+		piHoleClient := getClient()
+		Expect(piHoleClient.Status.ID).To(BeZero())
+		condition := meta.FindStatusCondition(piHoleClient.Status.Conditions, "Conflict")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring("adoptionPolicy"))
+
+		Expect(k8sClient.Delete(ctx, piHoleClient)).To(Succeed())
+		_, err = reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Calls(fake.DeleteClient)).To(BeZero())
+		Expect(piHole.Clients()).To(HaveLen(1))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.PiHoleClient{}))).To(BeTrue())
+	})
+
+	It("should not take over a client managed by another PiHoleClient", func() {
+		_, err := reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+
+		other := &networkingv1alpha1.PiHoleClient{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-piholeclient", Namespace: "default"},
+			Spec: networkingv1alpha1.PiHoleClientSpec{
+				InstanceRef:    networkingv1alpha1.InstanceReference{Name: instanceName},
+				Client:         "192.168.178.0/24",
+				AdoptionPolicy: networkingv1alpha1.AdoptionPolicyAdopt,
+			},
+		}
+		Expect(k8sClient.Create(ctx, other)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+			other.Finalizers = nil
+			Expect(k8sClient.Update(ctx, other)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, other)).To(Succeed())
+		})
+
+		_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(other)})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(other), other)).To(Succeed())
+		condition := meta.FindStatusCondition(other.Status.Conditions, "Conflict")
+		Expect(condition).NotTo(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring("PiHoleClient default/" + resourceName))
+		Expect(piHole.Clients()[0].Comment).To(Equal("kids network"))
+	})
+
+	It("should adopt an existing client and report its hostname", func() {
+		piHole.SetClients(pihole.PiHoleClient{Client: "192.168.178.0/24", Name: "kids.fritz.box", Groups: []int{0}})
+		piHoleClient := getClient()
+		piHoleClient.Spec.AdoptionPolicy = networkingv1alpha1.AdoptionPolicyAdopt
+		Expect(k8sClient.Update(ctx, piHoleClient)).To(Succeed())
+
+		_, err := reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Calls(fake.CreateClient)).To(BeZero())
+		Expect(piHole.Clients()[0].Groups).To(Equal([]int{4}))
+		Expect(getClient().Status.Hostname).To(Equal("kids.fritz.box"))
+	})
+
+	It("should not update a client that is up to date", func() {
+		_, err := reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Calls(fake.UpdateClient)).To(BeZero())
+	})
+
+	It("should never send an invalid client to the Pi-hole", func() {
+		piHoleClient := getClient()
+		Expect(k8sClient.Delete(ctx, piHoleClient)).To(Succeed())
+
+		piHoleClient = &networkingv1alpha1.PiHoleClient{
+			ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			Spec: networkingv1alpha1.PiHoleClientSpec{
+				InstanceRef: networkingv1alpha1.InstanceReference{Name: instanceName},
+				Client:      "kids tablet",
+			},
+		}
+		Eventually(func() error { return k8sClient.Create(ctx, piHoleClient) }).Should(Succeed())
+
+		_, err := reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(piHole.Calls(fake.CreateClient)).To(BeZero())
+		Expect(readyCondition().Reason).To(Equal("InvalidSpec"))
+	})
+
+	It("should wait for groups that don't exist", func() {
+		piHole.SetGroups()
+
+		result, err := reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(groupRetryInterval))
+		Expect(readyCondition().Reason).To(Equal("GroupNotFound"))
+		Expect(piHole.Clients()).To(BeEmpty())
+	})
+
+	It("should remove the client from the Pi-hole when it is deleted", func() {
+		_, err := reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(k8sClient.Delete(ctx, getClient())).To(Succeed())
+		_, err = reconcileClient()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(piHole.Clients()).To(BeEmpty())
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, &networkingv1alpha1.PiHoleClient{}))).To(BeTrue())
+	})
+})
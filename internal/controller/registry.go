@@ -0,0 +1,262 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	kerrors "errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const (
+	ownershipKey        = "records.json"
+	instanceAnnotation  = "networking.liebler.dev/instance"
+	ownershipNamePrefix = "pihole-ownership-"
+)
+
+// errNotOwned is returned if something the operator didn't create is in the way of a
+// resource, it is only taken over once the adoption policy of the resource allows it
+var errNotOwned = kerrors.New("owned by someone else")
+
+// OwnershipRegistry records which DNSName owns which record on a Pi-hole, and which
+// resource owns which other entry, e.g. an adlist. The ownership of every instance is
+// persisted in a ConfigMap of its own, so entries that were created by hand are never
+// touched by the operator.
+type OwnershipRegistry struct {
+	// Client is used to write the ConfigMaps
+	Client client.Client
+	// Reader is used to read the ConfigMaps, it should not be backed by a cache
+	// to avoid watching all ConfigMaps and to always see the latest version
+	Reader client.Reader
+	// Namespace is the namespace the ConfigMaps are stored in
+	Namespace string
+}
+
+// Owner identifies the resource owning an entry
+type Owner struct {
+	UID       types.UID `json:"uid"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+}
+
+// Ownership maps the keys of records and other entries to their owners
+type Ownership map[string]Owner
+
+// OwnerOf returns the owner of a record.
+func (o Ownership) OwnerOf(record pihole.DNSRecord) (Owner, bool) {
+	return o.OwnerOfKey(record.Key())
+}
+
+// Claim makes owner the owner of a record.
+func (o Ownership) Claim(record pihole.DNSRecord, owner Owner) {
+	o[record.Key()] = owner
+}
+
+// Release forgets the owner of a record.
+func (o Ownership) Release(record pihole.DNSRecord) {
+	o.ReleaseKey(record.Key())
+}
+
+// OwnerOfKey returns the owner of the entry with the given key, e.g. an adlist.
+func (o Ownership) OwnerOfKey(key string) (Owner, bool) {
+	owner, ok := o[key]
+
+	return owner, ok
+}
+
+// ClaimKey makes owner the owner of the entry with the given key.
+func (o Ownership) ClaimKey(key string, owner Owner) {
+	o[key] = owner
+}
+
+// ReleaseKey forgets the owner of the entry with the given key.
+func (o Ownership) ReleaseKey(key string) {
+	delete(o, key)
+}
+
+// ReleaseAll forgets all records owned by the owner with the given UID.
+func (o Ownership) ReleaseAll(uid types.UID) {
+	for key, owner := range o {
+		if owner.UID == uid {
+			delete(o, key)
+		}
+	}
+}
+
+// ownerOf returns the Owner describing a DNSName or another resource
+func ownerOf(obj client.Object) Owner {
+	return Owner{UID: obj.GetUID(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+}
+
+// Get returns the ownership of all records on the given instance.
+func (r *OwnershipRegistry) Get(ctx context.Context, instance string) (Ownership, error) {
+	ownership, _, err := r.get(ctx, instance)
+
+	return ownership, err
+}
+
+// Update changes the ownership of records on the given instance. mutate is called
+// again with the latest state if the ConfigMap was changed concurrently, an error
+// returned by it aborts the update.
+func (r *OwnershipRegistry) Update(ctx context.Context, instance string, mutate func(Ownership) error) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		ownership, configMap, err := r.get(ctx, instance)
+		if err != nil {
+			return err
+		}
+
+		if err := mutate(ownership); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(ownership)
+		if err != nil {
+			return err
+		}
+
+		if configMap == nil {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ownershipName(instance),
+					Namespace: r.Namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/name":       "pihole-operator",
+						"app.kubernetes.io/managed-by": "pihole-operator",
+					},
+					Annotations: map[string]string{instanceAnnotation: instance},
+				},
+				Data: map[string]string{ownershipKey: string(data)},
+			}
+
+			err = r.Client.Create(ctx, configMap)
+			if errors.IsAlreadyExists(err) {
+				// created concurrently, retry with the existing one
+				return errors.NewConflict(corev1.Resource("configmaps"), configMap.Name, err)
+			}
+
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[ownershipKey] = string(data)
+
+		return r.Client.Update(ctx, configMap)
+	})
+}
+
+// owns is true if obj owns the entry with the given key on the instance.
+func (r *OwnershipRegistry) owns(ctx context.Context, instance string, key string, obj client.Object) (bool, error) {
+	ownership, err := r.Get(ctx, instance)
+	if err != nil {
+		return false, err
+	}
+
+	owner, ok := ownership.OwnerOfKey(key)
+
+	return ok && owner.UID == obj.GetUID(), nil
+}
+
+// claim makes obj the owner of the entry with the given key on the instance. It fails
+// with errNotOwned if the entry is owned by another resource, which is of the given kind.
+func (r *OwnershipRegistry) claim(ctx context.Context, instance string, key string, obj client.Object, kind string) error {
+	owner := ownerOf(obj)
+
+	return r.Update(ctx, instance, func(ownership Ownership) error {
+		if current, ok := ownership.OwnerOfKey(key); ok && current.UID != owner.UID {
+			return fmt.Errorf("%w: it is claimed by %s %s/%s", errNotOwned, kind, current.Namespace, current.Name)
+		}
+
+		ownership.ClaimKey(key, owner)
+
+		return nil
+	})
+}
+
+// checkClaim fails with errNotOwned if the entry with the given key on the instance is
+// owned by a resource other than obj, which is of the given kind.
+func (r *OwnershipRegistry) checkClaim(ctx context.Context, instance string, key string, obj client.Object, kind string) error {
+	ownership, err := r.Get(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	if current, ok := ownership.OwnerOfKey(key); ok && current.UID != obj.GetUID() {
+		return fmt.Errorf("%w: it is claimed by %s %s/%s", errNotOwned, kind, current.Namespace, current.Name)
+	}
+
+	return nil
+}
+
+// releaseAll forgets all entries owned by obj on the instance.
+func (r *OwnershipRegistry) releaseAll(ctx context.Context, instance string, obj client.Object) error {
+	return r.Update(ctx, instance, func(ownership Ownership) error {
+		ownership.ReleaseAll(obj.GetUID())
+
+		return nil
+	})
+}
+
+// releaseKey forgets the owner of the entry with the given key on the instance.
+func (r *OwnershipRegistry) releaseKey(ctx context.Context, instance string, key string) error {
+	return r.Update(ctx, instance, func(ownership Ownership) error {
+		ownership.ReleaseKey(key)
+
+		return nil
+	})
+}
+
+func (r *OwnershipRegistry) get(ctx context.Context, instance string) (Ownership, *corev1.ConfigMap, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.Reader.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: ownershipName(instance)}, configMap)
+	if errors.IsNotFound(err) {
+		return Ownership{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get ownership of records on %s: %w", instance, err)
+	}
+
+	ownership := Ownership{}
+	if data, ok := configMap.Data[ownershipKey]; ok {
+		if err := json.Unmarshal([]byte(data), &ownership); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse ownership of records on %s: %w", instance, err)
+		}
+	}
+
+	return ownership, configMap, nil
+}
+
+// ownershipName derives a valid name for the ConfigMap of an instance, the
+// instance itself is stored in an annotation
+func ownershipName(instance string) string {
+	sum := sha256.Sum256([]byte(instance))
+
+	return ownershipNamePrefix + hex.EncodeToString(sum[:8])
+}
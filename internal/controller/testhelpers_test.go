@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+	"github.com/domnikl/pihole-operator/internal/pihole/fake"
+)
+
+// createInstance creates a PiHoleInstance and the Secret of its app password in the
+// default namespace, unless a previous test already created them
+func createInstance(ctx context.Context, name string) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		StringData: map[string]string{"password": "secret"},
+	}
+	err := k8sClient.Create(ctx, secret)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	instance := &networkingv1alpha1.PiHoleInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: networkingv1alpha1.PiHoleInstanceSpec{
+			URL:                  "http://pi.hole/api",
+			AppPasswordSecretRef: networkingv1alpha1.SecretKeyReference{Name: name, Key: "password"},
+		},
+	}
+	err = k8sClient.Create(ctx, instance)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+// fakePiHoles returns PiHoleClients connecting to the fake for every instance
+func fakePiHoles(piHole *fake.PiHole) *PiHoleClients {
+	return &PiHoleClients{
+		Client:       k8sClient,
+		SecretReader: k8sClient,
+		NewClient: func(pihole.Config) pihole.Client {
+			return piHole
+		},
+	}
+}
+
+// newRegistry returns an OwnershipRegistry storing its ConfigMaps in the default namespace
+func newRegistry() *OwnershipRegistry {
+	return &OwnershipRegistry{
+		Client:    k8sClient,
+		Reader:    k8sClient,
+		Namespace: "default",
+	}
+}
+
+// deleteOwnership removes the ConfigMaps of the registry, so no test sees the entries
+// claimed by a previous one
+func deleteOwnership(ctx context.Context) {
+	Expect(k8sClient.DeleteAllOf(ctx, &corev1.ConfigMap{}, client.InNamespace("default"),
+		client.MatchingLabels{"app.kubernetes.io/managed-by": "pihole-operator"})).To(Succeed())
+}
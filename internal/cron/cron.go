@@ -0,0 +1,228 @@
+// Package cron parses cron schedules like "0 3 * * 0" and computes when they are
+// due. It supports the five fields of crontab(5) with names for months and days
+// of the week, as well as the @hourly, @daily, @weekly, @monthly and @yearly macros.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron schedule
+type Schedule struct {
+	spec string
+
+	minute, hour, dayOfMonth, month, dayOfWeek bits
+
+	// like cron, a day matches either field if both are restricted
+	dayOfMonthRestricted, dayOfWeekRestricted bool
+}
+
+// bits is the set of values a field matches
+type bits uint64
+
+func (b bits) has(value int) bool {
+	return b&(1<<uint(value)) != 0
+}
+
+// field describes the values of one field of a schedule
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minuteField     = field{name: "minute", min: 0, max: 59}
+	hourField       = field{name: "hour", min: 0, max: 23}
+	dayOfMonthField = field{name: "day of month", min: 1, max: 31}
+	monthField      = field{name: "month", min: 1, max: 12, names: []string{
+		"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec",
+	}}
+	// 7 is Sunday as well
+	dayOfWeekField = field{name: "day of week", min: 0, max: 7, names: []string{
+		"sun", "mon", "tue", "wed", "thu", "fri", "sat",
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a schedule of five fields: minute, hour, day of month, month and day of week
+func Parse(spec string) (*Schedule, error) {
+	expanded := strings.TrimSpace(spec)
+	if macro, ok := macros[strings.ToLower(expanded)]; ok {
+		expanded = macro
+	}
+
+	fields := strings.Fields(expanded)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	s := &Schedule{spec: spec}
+
+	var err error
+	parsers := []struct {
+		bits  *bits
+		field field
+	}{
+		{&s.minute, minuteField},
+		{&s.hour, hourField},
+		{&s.dayOfMonth, dayOfMonthField},
+		{&s.month, monthField},
+		{&s.dayOfWeek, dayOfWeekField},
+	}
+	for i, p := range parsers {
+		*p.bits, err = p.field.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+	}
+
+	if s.dayOfWeek.has(7) {
+		s.dayOfWeek |= 1
+	}
+	// like in cron, a field starting with * like */2 doesn't restrict the days
+	s.dayOfMonthRestricted = !strings.HasPrefix(fields[2], "*")
+	s.dayOfWeekRestricted = !strings.HasPrefix(fields[4], "*")
+
+	return s, nil
+}
+
+// String returns the schedule as it was parsed
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first time after t the schedule is due, in the location of t.
+// It returns the zero time if the schedule is never due, e.g. on February 30th.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !s.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hour.has(t.Hour()):
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case !s.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// Due is true if the schedule is due in the minute of t
+func (s *Schedule) Due(t time.Time) bool {
+	return s.month.has(int(t.Month())) && s.matchesDay(t) && s.hour.has(t.Hour()) && s.minute.has(t.Minute())
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth.has(t.Day())
+	dayOfWeek := s.dayOfWeek.has(int(t.Weekday()))
+
+	if s.dayOfMonthRestricted && s.dayOfWeekRestricted {
+		return dayOfMonth || dayOfWeek
+	}
+
+	return dayOfMonth && dayOfWeek
+}
+
+// parse parses a comma separated list of values, ranges and steps
+func (f field) parse(expr string) (bits, error) {
+	var result bits
+	for _, part := range strings.Split(expr, ",") {
+		b, err := f.parseRange(part)
+		if err != nil {
+			return 0, err
+		}
+		result |= b
+	}
+
+	return result, nil
+}
+
+// parseRange parses *, a value or a range, each optionally followed by a step like /5
+func (f field) parseRange(expr string) (bits, error) {
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+
+	step := 1
+	if hasStep {
+		var err error
+		step, err = strconv.Atoi(stepExpr)
+		if err != nil || step < 1 {
+			return 0, fmt.Errorf("invalid step %q in %s field", stepExpr, f.name)
+		}
+	}
+
+	var low, high int
+	switch lowExpr, highExpr, isRange := strings.Cut(rangeExpr, "-"); {
+	case rangeExpr == "*":
+		low, high = f.min, f.max
+		if f.max == 7 {
+			// Sunday is 0, 7 would match it twice
+			high = 6
+		}
+	case isRange:
+		var err error
+		if low, err = f.value(lowExpr); err != nil {
+			return 0, err
+		}
+		if high, err = f.value(highExpr); err != nil {
+			return 0, err
+		}
+		if low > high {
+			return 0, fmt.Errorf("invalid range %q in %s field", rangeExpr, f.name)
+		}
+	default:
+		var err error
+		if low, err = f.value(rangeExpr); err != nil {
+			return 0, err
+		}
+		high = low
+		if hasStep {
+			// like cron, a/n starts at a and runs to the end of the field
+			high = f.max
+		}
+	}
+
+	var b bits
+	for value := low; value <= high; value += step {
+		b |= 1 << uint(value)
+	}
+
+	return b, nil
+}
+
+// value parses a single number or name of the field
+func (f field) value(expr string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(expr, name) {
+			// months are counted from 1, days of the week from 0
+			return i + f.min, nil
+		}
+	}
+
+	value, err := strconv.Atoi(expr)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, expected %d-%d", expr, f.name, f.min, f.max)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,76 @@
+package cron
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schedule", func() {
+	// a Wednesday
+	now := time.Date(2025, time.January, 15, 10, 30, 45, 0, time.UTC)
+
+	DescribeTable("computing the next activation",
+		func(spec string, expected time.Time) {
+			schedule, err := Parse(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedule.Next(now)).To(Equal(expected))
+		},
+		Entry("every minute", "* * * * *", time.Date(2025, time.January, 15, 10, 31, 0, 0, time.UTC)),
+		Entry("later this hour", "45 * * * *", time.Date(2025, time.January, 15, 10, 45, 0, 0, time.UTC)),
+		Entry("the current minute is over", "30 10 * * *", time.Date(2025, time.January, 16, 10, 30, 0, 0, time.UTC)),
+		Entry("every 15 minutes", "*/15 * * * *", time.Date(2025, time.January, 15, 10, 45, 0, 0, time.UTC)),
+		Entry("a range with a step", "0 8-18/4 * * *", time.Date(2025, time.January, 15, 12, 0, 0, 0, time.UTC)),
+		Entry("a list", "0 3,22 * * *", time.Date(2025, time.January, 15, 22, 0, 0, 0, time.UTC)),
+		Entry("Sundays", "0 3 * * 0", time.Date(2025, time.January, 19, 3, 0, 0, 0, time.UTC)),
+		Entry("Sundays as 7", "0 3 * * 7", time.Date(2025, time.January, 19, 3, 0, 0, 0, time.UTC)),
+		Entry("weekdays by name", "0 7 * * mon-fri", time.Date(2025, time.January, 16, 7, 0, 0, 0, time.UTC)),
+		Entry("months by name", "0 0 1 Mar *", time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("either day of month or day of week", "0 0 1 * fri", time.Date(2025, time.January, 17, 0, 0, 0, 0, time.UTC)),
+		Entry("every other day of month on Mondays", "0 0 */2 * 1", time.Date(2025, time.January, 27, 0, 0, 0, 0, time.UTC)),
+		Entry("leap day", "0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)),
+		Entry("weekly macro", "@weekly", time.Date(2025, time.January, 19, 0, 0, 0, 0, time.UTC)),
+		Entry("hourly macro", "@hourly", time.Date(2025, time.January, 15, 11, 0, 0, 0, time.UTC)),
+	)
+
+	It("should never activate on a day that doesn't exist", func() {
+		schedule, err := Parse("0 0 30 2 *")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schedule.Next(now)).To(BeZero())
+	})
+
+	It("should compute activations in the location of the time", func() {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		if err != nil {
+			Skip("no time zone database")
+		}
+
+		schedule, err := Parse("30 2 * * *")
+		Expect(err).NotTo(HaveOccurred())
+
+		// 2:30 doesn't exist on the day the clocks are set forward
+		next := schedule.Next(time.Date(2025, time.March, 29, 12, 0, 0, 0, berlin))
+		Expect(next).To(Equal(time.Date(2025, time.March, 31, 2, 30, 0, 0, berlin)))
+	})
+
+	It("should report whether it is due", func() {
+		schedule, err := Parse("30 10 * * wed")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schedule.Due(now)).To(BeTrue())
+		Expect(schedule.Due(now.Add(time.Minute))).To(BeFalse())
+	})
+
+	DescribeTable("rejecting invalid schedules",
+		func(spec string, message string) {
+			_, err := Parse(spec)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("too few fields", "0 3 * *", "expected 5 fields"),
+		Entry("out of range", "60 * * * *", "invalid value \"60\" in minute field"),
+		Entry("unknown name", "0 0 * * funday", "in day of week field"),
+		Entry("reversed range", "0 18-8 * * *", "invalid range"),
+		Entry("zero step", "*/0 * * * *", "invalid step"),
+		Entry("unknown macro", "@fortnightly", "expected 5 fields"),
+	)
+})
@@ -0,0 +1,93 @@
+package pihole
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchingClient coalesces the writes issued within Window into a single call of
+// ReplaceDNSRecords. Every write makes Pi-hole restart its resolver, so creating
+// many records at once, e.g. when a cluster is bootstrapped, restarts it only once
+// per window instead of once per record. Writes block until their batch has been
+// applied. If a batch fails, each of its writes is retried on its own, so an
+// invalid record doesn't fail the writes it was batched with.
+type BatchingClient struct {
+	Client
+
+	// Window is the time writes are collected before they are applied
+	Window time.Duration
+
+	mu      sync.Mutex
+	pending *batch
+}
+
+// batch is a set of writes applied together, done is closed once it has been applied
+type batch struct {
+	oldRecords []DNSRecord
+	newRecords []DNSRecord
+	writes     int
+	done       chan struct{}
+	err        error
+}
+
+var _ Client = &BatchingClient{}
+
+// NewBatchingClient returns a client coalescing the writes to client within window
+func NewBatchingClient(client Client, window time.Duration) *BatchingClient {
+	return &BatchingClient{Client: client, Window: window}
+}
+
+func (c *BatchingClient) CreateDNSRecord(ctx context.Context, record DNSRecord) error {
+	return c.write(ctx, nil, []DNSRecord{record})
+}
+
+func (c *BatchingClient) DeleteDNSRecord(ctx context.Context, record DNSRecord) error {
+	return c.write(ctx, []DNSRecord{record}, nil)
+}
+
+func (c *BatchingClient) ReplaceDNSRecords(ctx context.Context, oldRecords []DNSRecord, newRecords []DNSRecord) error {
+	return c.write(ctx, oldRecords, newRecords)
+}
+
+// write adds the changes to the pending batch and waits until it has been applied
+func (c *BatchingClient) write(ctx context.Context, oldRecords []DNSRecord, newRecords []DNSRecord) error {
+	c.mu.Lock()
+	b := c.pending
+	if b == nil {
+		b = &batch{done: make(chan struct{})}
+		c.pending = b
+		time.AfterFunc(c.Window, func() { c.flush(b) })
+	}
+	b.oldRecords = append(b.oldRecords, oldRecords...)
+	b.newRecords = append(b.newRecords, newRecords...)
+	b.writes++
+	c.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		// the batch is applied anyway, but the caller doesn't know the outcome
+		return ctx.Err()
+	}
+
+	if b.err == nil || b.writes == 1 {
+		return b.err
+	}
+
+	// one of the writes might have spoiled the batch, find out if it was this one
+	return c.Client.ReplaceDNSRecords(ctx, oldRecords, newRecords)
+}
+
+// flush applies a batch, it runs once its window has passed
+func (c *BatchingClient) flush(b *batch) {
+	c.mu.Lock()
+	if c.pending == b {
+		c.pending = nil
+	}
+	c.mu.Unlock()
+
+	// the batch serves several callers, none of their contexts may cancel it
+	b.err = c.Client.ReplaceDNSRecords(context.Background(), b.oldRecords, b.newRecords)
+	close(b.done)
+}
@@ -0,0 +1,110 @@
+package pihole
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Batching Client", func() {
+	const password = "secret"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var batching *BatchingClient
+
+	record := func(i int) DNSRecord {
+		return DNSRecord{Domain: fmt.Sprintf("host%d.com", i), Target: "192.168.178.1", Type: v1alpha1.A}
+	}
+
+	// writeConcurrently calls write n times in parallel and returns the errors by index
+	writeConcurrently := func(n int, write func(i int) error) []error {
+		var wg sync.WaitGroup
+		errs := make([]error, n)
+
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = write(i)
+			}()
+		}
+		wg.Wait()
+
+		return errs
+	}
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		batching = NewBatchingClient(NewPiHole(server.URL+"/api", password), 50*time.Millisecond)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should apply concurrent writes at once", func() {
+		sim.SetHosts("192.168.178.1 host0.com")
+
+		errs := writeConcurrently(50, func(i int) error {
+			if i == 0 {
+				return batching.DeleteDNSRecord(ctx, record(0))
+			}
+
+			return batching.CreateDNSRecord(ctx, record(i))
+		})
+		for _, err := range errs {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(sim.Hosts()).To(HaveLen(49))
+		Expect(sim.Hosts()).NotTo(ContainElement("192.168.178.1 host0.com"))
+		Expect(sim.Writes()).To(Equal(1))
+	})
+
+	It("should only fail the write that spoiled a batch", func() {
+		sim.SetHosts("192.168.178.1 host0.com")
+
+		errs := writeConcurrently(10, func(i int) error {
+			return batching.CreateDNSRecord(ctx, record(i))
+		})
+
+		Expect(errs[0]).To(MatchError(ContainSubstring("already exists")))
+		for _, err := range errs[1:] {
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(sim.Hosts()).To(HaveLen(10))
+	})
+
+	It("should report the error of a single write", func() {
+		err := batching.DeleteDNSRecord(ctx, record(0))
+		Expect(err).To(MatchError(ContainSubstring("not found")))
+	})
+
+	It("should not lose concurrent writes without batching", func() {
+		piHole := NewPiHole(server.URL+"/api", password)
+
+		errs := writeConcurrently(20, func(i int) error {
+			if i%2 == 0 {
+				return piHole.CreateDNSRecord(ctx, record(i))
+			}
+
+			return piHole.ReplaceDNSRecords(ctx, nil, []DNSRecord{record(i)})
+		})
+		for _, err := range errs {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(sim.Hosts()).To(HaveLen(20))
+	})
+})
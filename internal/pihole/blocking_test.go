@@ -0,0 +1,97 @@
+package pihole
+
+import (
+	"context"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Pi-Hole Blocking", func() {
+	const password = "secret"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var piHole *PiHole
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		piHole = NewPiHole(server.URL+"/api", password)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should report enabled blocking without a timer", func() {
+		blocking, err := piHole.GetBlocking(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocking.State).To(Equal(BlockingEnabled))
+		Expect(blocking.Timer).To(BeZero())
+	})
+
+	It("should report the timer of disabled blocking", func() {
+		sim.SetBlocking(false, 10*time.Minute)
+
+		blocking, err := piHole.GetBlocking(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocking.State).To(Equal(BlockingDisabled))
+		Expect(blocking.Timer).To(BeNumerically("~", 10*time.Minute, time.Second))
+	})
+
+	It("should disable blocking for a while", func() {
+		blocking, err := piHole.SetBlocking(ctx, false, 90*time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocking.State).To(Equal(BlockingDisabled))
+		Expect(blocking.Timer).To(BeNumerically("~", 90*time.Second, time.Second))
+
+		enabled, timer := sim.Blocking()
+		Expect(enabled).To(BeFalse())
+		Expect(timer).To(BeNumerically("~", 90*time.Second, time.Second))
+	})
+
+	It("should round timers up to whole seconds", func() {
+		_, err := piHole.SetBlocking(ctx, false, 1500*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, timer := sim.Blocking()
+		Expect(timer).To(BeNumerically(">", 1500*time.Millisecond))
+	})
+
+	It("should revert blocking once the timer has passed", func() {
+		_, err := piHole.SetBlocking(ctx, false, time.Second)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() BlockingState {
+			blocking, err := piHole.GetBlocking(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			return blocking.State
+		}, "3s", "100ms").Should(Equal(BlockingEnabled))
+	})
+
+	It("should enable blocking permanently", func() {
+		sim.SetBlocking(false, time.Hour)
+
+		blocking, err := piHole.SetBlocking(ctx, true, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(blocking.State).To(Equal(BlockingEnabled))
+		Expect(blocking.Timer).To(BeZero())
+	})
+
+	It("should not support blocking on Pi-hole v5", func() {
+		server, _ := simulator.NewLegacyServer("token")
+		defer server.Close()
+
+		client := NewClient(Config{URL: server.URL + "/admin/api.php", AppPassword: "token"})
+
+		_, err := client.(BlockingClient).GetBlocking(ctx)
+		Expect(err).To(MatchError(ErrUnsupported))
+	})
+})
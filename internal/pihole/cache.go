@@ -0,0 +1,175 @@
+package pihole
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Refresher is implemented by clients that cache records, Refresh bypasses the cache
+type Refresher interface {
+	// Refresh reads all local DNS records from the Pi-hole and updates the cache
+	Refresh(ctx context.Context) ([]DNSRecord, error)
+}
+
+// CachingClient keeps a snapshot of the records of a Pi-hole, so reconciling many
+// DNSNames doesn't list all records of the Pi-hole for each of them. The snapshot
+// is read again once it is older than MaxAge and patched after successful writes.
+// A failed write discards it, as the state of the Pi-hole is unknown then.
+type CachingClient struct {
+	Client
+
+	// MaxAge is the time a snapshot is used before it is read again
+	MaxAge time.Duration
+
+	// fetchMu serializes reading the records, so concurrent callers share a single read
+	fetchMu sync.Mutex
+
+	mu        sync.Mutex
+	records   []DNSRecord
+	fetchedAt time.Time
+	valid     bool
+	// writes is incremented on every write, a snapshot read while writing is discarded
+	writes uint64
+	now    func() time.Time
+}
+
+var (
+	_ Client    = &CachingClient{}
+	_ Refresher = &CachingClient{}
+)
+
+// NewCachingClient returns a client caching the records of client for maxAge
+func NewCachingClient(client Client, maxAge time.Duration) *CachingClient {
+	return &CachingClient{Client: client, MaxAge: maxAge, now: time.Now}
+}
+
+// GetDNSRecords returns the cached records and reads them from the Pi-hole if the
+// snapshot is missing or outdated
+func (c *CachingClient) GetDNSRecords(ctx context.Context) ([]DNSRecord, error) {
+	if records, ok := c.cached(); ok {
+		return records, nil
+	}
+
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	// another caller might have read the records in the meantime
+	if records, ok := c.cached(); ok {
+		return records, nil
+	}
+
+	return c.fetch(ctx)
+}
+
+// Refresh reads the records from the Pi-hole regardless of the age of the snapshot
+func (c *CachingClient) Refresh(ctx context.Context) ([]DNSRecord, error) {
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	return c.fetch(ctx)
+}
+
+// Invalidate discards the snapshot, the records are read again on the next call
+func (c *CachingClient) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.valid = false
+	c.records = nil
+}
+
+func (c *CachingClient) CreateDNSRecord(ctx context.Context, record DNSRecord) error {
+	err := c.Client.CreateDNSRecord(ctx, record)
+
+	return c.patch(err, nil, []DNSRecord{record})
+}
+
+func (c *CachingClient) DeleteDNSRecord(ctx context.Context, record DNSRecord) error {
+	err := c.Client.DeleteDNSRecord(ctx, record)
+
+	return c.patch(err, []DNSRecord{record}, nil)
+}
+
+func (c *CachingClient) ReplaceDNSRecords(ctx context.Context, oldRecords []DNSRecord, newRecords []DNSRecord) error {
+	err := c.Client.ReplaceDNSRecords(ctx, oldRecords, newRecords)
+
+	return c.patch(err, oldRecords, newRecords)
+}
+
+func (c *CachingClient) Close(ctx context.Context) error {
+	c.Invalidate()
+
+	return c.Client.Close(ctx)
+}
+
+// patch applies a write to the snapshot, it is discarded if the write failed
+func (c *CachingClient) patch(err error, deleted []DNSRecord, created []DNSRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writes++
+
+	if err != nil {
+		c.valid = false
+		c.records = nil
+
+		return err
+	}
+
+	if c.valid {
+		for _, record := range deleted {
+			c.records = slices.DeleteFunc(c.records, func(r DNSRecord) bool {
+				return r.Equals(&record)
+			})
+		}
+		for _, record := range created {
+			// the record is already part of a snapshot read during the write
+			if !slices.ContainsFunc(c.records, func(r DNSRecord) bool { return r.Equals(&record) }) {
+				c.records = append(c.records, record)
+			}
+		}
+	}
+
+	return nil
+}
+
+// cached returns a copy of the snapshot if it is still fresh
+func (c *CachingClient) cached() ([]DNSRecord, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.valid || c.now().Sub(c.fetchedAt) >= c.MaxAge {
+		return nil, false
+	}
+
+	return slices.Clone(c.records), true
+}
+
+// fetch reads the records and stores them as the new snapshot, c.fetchMu must be held
+func (c *CachingClient) fetch(ctx context.Context) ([]DNSRecord, error) {
+	c.mu.Lock()
+	writes := c.writes
+	c.mu.Unlock()
+
+	records, err := c.Client.GetDNSRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// a concurrent write may or may not be part of the records read
+	if c.writes == writes {
+		c.records = slices.Clone(records)
+		c.fetchedAt = c.now()
+		c.valid = true
+	} else {
+		c.valid = false
+		c.records = nil
+	}
+
+	return records, nil
+}
@@ -0,0 +1,231 @@
+package pihole
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Caching Client", func() {
+	const password = "secret"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var cache *CachingClient
+	var now time.Time
+
+	record := DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A}
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		sim.SetHosts("192.168.178.2 bar.com")
+
+		now = time.Now()
+		cache = NewCachingClient(NewPiHole(server.URL+"/api", password), time.Minute)
+		cache.now = func() time.Time { return now }
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should read the records only once", func() {
+		for i := 0; i < 3; i++ {
+			records, err := cache.GetDNSRecords(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(1))
+		}
+
+		// hosts and cnameRecords
+		Expect(sim.Reads()).To(Equal(2))
+	})
+
+	It("should read the records again once they are outdated", func() {
+		_, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		sim.SetHosts()
+		now = now.Add(time.Minute)
+
+		records, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(BeEmpty())
+		Expect(sim.Reads()).To(Equal(4))
+	})
+
+	It("should patch the records after creating and deleting a record", func() {
+		_, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(cache.CreateDNSRecord(ctx, record)).To(Succeed())
+
+		records, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(ContainElement(record))
+
+		Expect(cache.DeleteDNSRecord(ctx, record)).To(Succeed())
+
+		records, err = cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).NotTo(ContainElement(record))
+		Expect(sim.Reads()).To(Equal(2))
+	})
+
+	It("should patch the records after replacing records", func() {
+		_, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		old := DNSRecord{Domain: "bar.com", Target: "192.168.178.2", Type: v1alpha1.A}
+		Expect(cache.ReplaceDNSRecords(ctx, []DNSRecord{old}, []DNSRecord{record})).To(Succeed())
+		reads := sim.Reads()
+
+		records, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(ConsistOf(record))
+		Expect(sim.Reads()).To(Equal(reads))
+	})
+
+	It("should read the records again after a failed write", func() {
+		_, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		// created in the Pi-hole UI
+		sim.SetHosts("192.168.178.2 bar.com", "192.168.178.1 foo.com")
+		Expect(cache.CreateDNSRecord(ctx, record)).NotTo(Succeed())
+
+		records, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(ContainElement(record))
+		Expect(sim.Reads()).To(Equal(4))
+	})
+
+	It("should bypass the cache when refreshing", func() {
+		_, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		sim.SetHosts()
+
+		records, err := cache.Refresh(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(BeEmpty())
+
+		records, err = cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(BeEmpty())
+		Expect(sim.Reads()).To(Equal(4))
+	})
+
+	It("should not hand out its snapshot", func() {
+		records, err := cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		records[0].Target = "10.0.0.1"
+
+		records, err = cache.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records[0].Target).To(Equal("192.168.178.2"))
+	})
+
+	It("should share a single read between concurrent callers", func() {
+		sim.Latency = 10 * time.Millisecond
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+
+				_, err := cache.GetDNSRecords(ctx)
+				Expect(err).NotTo(HaveOccurred())
+			}()
+		}
+		wg.Wait()
+
+		Expect(sim.Reads()).To(Equal(2))
+	})
+})
+
+// benchmarkRecords is the number of hosts and CNAME records on the simulated Pi-hole
+const benchmarkRecords = 2500
+
+// benchmarkPiHole starts a simulator holding benchmarkRecords hosts and CNAME records each
+func benchmarkPiHole(b *testing.B) (*PiHole, *simulator.Simulator) {
+	b.Helper()
+
+	server, sim := simulator.NewServer("secret")
+	b.Cleanup(server.Close)
+
+	hosts := make([]string, 0, benchmarkRecords)
+	cnameRecords := make([]string, 0, benchmarkRecords)
+	for i := 0; i < benchmarkRecords; i++ {
+		hosts = append(hosts, fmt.Sprintf("10.0.%d.%d host%d.example.com", i/256, i%256, i))
+		cnameRecords = append(cnameRecords, fmt.Sprintf("alias%d.example.com,host%d.example.com", i, i))
+	}
+	sim.SetHosts(hosts...)
+	sim.SetCNAMERecords(cnameRecords...)
+
+	return NewPiHole(server.URL+"/api", "secret"), sim
+}
+
+// BenchmarkGetDNSRecords lists all records, as every reconciliation did without a cache
+func BenchmarkGetDNSRecords(b *testing.B) {
+	piHole, sim := benchmarkPiHole(b)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := piHole.GetDNSRecords(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(sim.Reads())/float64(b.N), "reads/op")
+}
+
+// BenchmarkCachingClientGetDNSRecords lists all records through the cache
+func BenchmarkCachingClientGetDNSRecords(b *testing.B) {
+	piHole, sim := benchmarkPiHole(b)
+	cache := NewCachingClient(piHole, time.Minute)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := cache.GetDNSRecords(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(sim.Reads())/float64(b.N), "reads/op")
+}
+
+// BenchmarkCachingClientSync creates a record and reads all records afterwards, as
+// a reconciliation of a new DNSName does
+func BenchmarkCachingClientSync(b *testing.B) {
+	piHole, sim := benchmarkPiHole(b)
+	cache := NewCachingClient(piHole, time.Minute)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		record := DNSRecord{Domain: fmt.Sprintf("new%d.example.com", i), Target: "192.168.0.1", Type: v1alpha1.A}
+		if err := cache.CreateDNSRecord(ctx, record); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := cache.GetDNSRecords(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(sim.Reads())/float64(b.N), "reads/op")
+}
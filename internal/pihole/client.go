@@ -2,37 +2,142 @@ package pihole
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/domnikl/pihole-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/domnikl/pihole-operator/internal/pihole/totp"
 )
 
+// DefaultTimeout is the default timeout of a single request against the PiHole API
+const DefaultTimeout = 10 * time.Second
+
+// Client manages DNS records in a Pi-hole
+type Client interface {
+	// GetDNSRecords returns all local DNS records
+	GetDNSRecords(ctx context.Context) ([]DNSRecord, error)
+	// CreateDNSRecord creates a new local DNS record
+	CreateDNSRecord(ctx context.Context, record DNSRecord) error
+	// DeleteDNSRecord deletes an existing local DNS record
+	DeleteDNSRecord(ctx context.Context, record DNSRecord) error
+	// ReplaceDNSRecords replaces existing local DNS records by new ones, the
+	// domains keep resolving while they are replaced
+	ReplaceDNSRecords(ctx context.Context, oldRecords []DNSRecord, newRecords []DNSRecord) error
+	// Close releases the session held by the client
+	Close(ctx context.Context) error
+}
+
+// Config holds the connection details of a Pi-hole
+type Config struct {
+	// URL is the URL of the Pi-hole, the path of either API may be included
+	URL string
+	// Version is the API version of the Pi-hole, it is detected on connect if empty
+	Version Version
+	// AppPassword is the password to authenticate against the PiHole API,
+	// for Pi-hole v5 it is the API token
+	AppPassword string
+	// TOTPSecret is the base32 encoded secret used to generate the code for
+	// two-factor authentication, it is only needed if 2FA is enabled
+	TOTPSecret string
+	// HTTPClient is the client used to talk to the PiHole API
+	HTTPClient *http.Client
+	// Timeout is the timeout of a single request against the PiHole API
+	Timeout time.Duration
+	// Retry configures how failed requests are retried
+	Retry RetryPolicy
+}
+
+// NewClient returns a Client for the Pi-hole described by config.
+func NewClient(config Config) Client {
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{}
+	}
+
+	if config.Version == "" {
+		return &detectingClient{config: config}
+	}
+
+	return newClient(config, config.Version)
+}
+
+func newClient(config Config, version Version) Client {
+	if version == V5 {
+		p := NewLegacyPiHole(BaseURL(config.URL)+"/admin/api.php", config.AppPassword)
+		p.HTTPClient = config.HTTPClient
+		if config.Timeout > 0 {
+			p.Timeout = config.Timeout
+		}
+
+		return p
+	}
+
+	p := NewPiHole(BaseURL(config.URL)+"/api", config.AppPassword)
+	p.TOTPSecret = config.TOTPSecret
+	p.Retry = config.Retry
+	p.HTTPClient = config.HTTPClient
+	if config.Timeout > 0 {
+		p.Timeout = config.Timeout
+	}
+
+	return p
+}
+
+var _ Client = &PiHole{}
+
 type PiHole struct {
 	// URL is the URL of the PiHole API
 	URL string
 	// AppPassword is the password to authenticate against the PiHole API
 	AppPassword string
-	sid         string
+	// TOTPSecret is the base32 encoded secret used to generate the code for
+	// two-factor authentication, it is only needed if 2FA is enabled
+	TOTPSecret string
+	// HTTPClient is the client used to talk to the PiHole API
+	HTTPClient *http.Client
+	// Timeout is the timeout of a single request against the PiHole API
+	Timeout time.Duration
+	// Retry configures how failed requests are retried, they are not retried by default
+	Retry RetryPolicy
+
+	session *session
+	// lastTOTP is the start of the period of the last TOTP code used to log in,
+	// FTL rejects codes that have already been used. Logins are serialized by the session.
+	lastTOTP time.Time
+	now      func() time.Time
+	// budget limits the retries of the requests to this Pi-hole
+	budget retryBudget
+
+	// writeMu serializes writes, as ReplaceDNSRecords reads and writes the lists
+	// as a whole and would undo concurrent changes otherwise
+	writeMu sync.Mutex
 }
 
 func NewPiHole(url string, appPassword string) *PiHole {
 	return &PiHole{
 		URL:         url,
 		AppPassword: appPassword,
+		HTTPClient:  &http.Client{},
+		Timeout:     DefaultTimeout,
+		session:     newSession(),
+		now:         time.Now,
 	}
 }
 
-func (p *PiHole) GetDNSRecords() ([]DNSRecord, error) {
+func (p *PiHole) GetDNSRecords(ctx context.Context) ([]DNSRecord, error) {
 	var records []DNSRecord
 
 	// A Records
-	aRecords, err := p.getARecords()
+	aRecords, err := p.getARecords(ctx)
 	if err != nil {
 		return []DNSRecord{}, err
 	}
@@ -40,7 +145,7 @@ func (p *PiHole) GetDNSRecords() ([]DNSRecord, error) {
 	records = append(records, aRecords...)
 
 	// CNAME Records
-	cnameRecords, err := p.getCNames()
+	cnameRecords, err := p.getCNames(ctx)
 	if err != nil {
 		return []DNSRecord{}, err
 	}
@@ -50,262 +155,444 @@ func (p *PiHole) GetDNSRecords() ([]DNSRecord, error) {
 	return records, nil
 }
 
-func (p *PiHole) getCNames() ([]DNSRecord, error) {
-	resp, err := p.doAuthenticatedRequest(http.MethodGet, "/config/dns/cnameRecords", nil)
+func (p *PiHole) getCNames(ctx context.Context) ([]DNSRecord, error) {
+	entries, err := p.getConfigList(ctx, "cnameRecords")
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get DNS records with status code %d", resp.StatusCode)
-	}
+	var recordsList []DNSRecord
+	for _, entry := range entries {
+		record, err := parseCNAMERecord(entry)
+		if err != nil {
+			// an entry edited by hand must not hide all other records
+			log.FromContext(ctx).Error(err, "Skipping malformed entry")
+			continue
+		}
 
-	type response struct {
-		Config struct {
-			DNS struct {
-				CNameRecords []string `json:"cnameRecords"`
-			} `json:"dns"`
-		} `json:"config"`
+		recordsList = append(recordsList, record)
 	}
 
-	var records response
-	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
-		return nil, err
+	return recordsList, nil
+}
+
+func (p *PiHole) getARecords(ctx context.Context) ([]DNSRecord, error) {
+	entries, err := p.getConfigList(ctx, "hosts")
+	if err != nil {
+		return []DNSRecord{}, err
 	}
 
 	var recordsList []DNSRecord
-	for _, record := range records.Config.DNS.CNameRecords {
-		var ttl *int32
-		parts := strings.Split(record, ",")
-
-		// CNAME records have a TTL
-		if len(parts) == 3 {
-			x, err := strconv.ParseInt(parts[2], 10, 32)
-			if err != nil {
-				return nil, err
-			}
-
-			a := int32(x)
-			ttl = &a
+	for _, entry := range entries {
+		record, err := parseHost(entry)
+		if err != nil {
+			// an entry edited by hand must not hide all other records
+			log.FromContext(ctx).Error(err, "Skipping malformed entry")
+			continue
 		}
 
-		recordsList = append(recordsList, DNSRecord{
-			Domain: parts[0],
-			Target: parts[1],
-			TTL:    ttl,
-			Type:   v1alpha1.CName,
-		})
+		recordsList = append(recordsList, record)
 	}
 
 	return recordsList, nil
 }
 
-func (p *PiHole) getARecords() ([]DNSRecord, error) {
-	resp, err := p.doAuthenticatedRequest(http.MethodGet, "/config/dns/hosts", nil)
+// getConfigList returns the raw entries of dns.hosts or dns.cnameRecords
+func (p *PiHole) getConfigList(ctx context.Context, list string) ([]string, error) {
+	resp, err := p.doAuthenticatedRequest(ctx, http.MethodGet, apiPath("config", "dns", list), nil)
 	if err != nil {
-		return []DNSRecord{}, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return []DNSRecord{}, fmt.Errorf("failed to get DNS records with status code %d", resp.StatusCode)
+		return nil, fmt.Errorf("failed to get DNS records: %w", newAPIError(resp))
 	}
 
-	type response struct {
+	var response struct {
 		Config struct {
-			DNS struct {
-				Hosts []string `json:"hosts"`
-			} `json:"dns"`
+			DNS map[string][]string `json:"dns"`
 		} `json:"config"`
 	}
-
-	var records response
-	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
-		return []DNSRecord{}, err
-	}
-
-	var recordsList []DNSRecord
-	for _, record := range records.Config.DNS.Hosts {
-		parts := strings.Split(record, " ")
-
-		recordsList = append(recordsList, DNSRecord{
-			Target: parts[0],
-			Domain: parts[1],
-			Type:   v1alpha1.A,
-		})
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, err
 	}
 
-	return recordsList, nil
+	return response.Config.DNS[list], nil
 }
 
-func (p *PiHole) CreateDNSRecord(record DNSRecord) error {
-	if record.Type == v1alpha1.A {
-		return p.createDNSARecord(record.Domain, v1alpha1.IPAddressStr(record.Target))
-	} else if record.Type == v1alpha1.CName {
-		return p.createDNSCNAMERecord(record.Domain, record.Target, record.TTL)
-	}
+func (p *PiHole) CreateDNSRecord(ctx context.Context, record DNSRecord) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
 
-	return fmt.Errorf("invalid DNS record type %s", record.Type)
-}
-
-func (p *PiHole) createDNSCNAMERecord(domain string, target string, ttl *int32) error {
-	record := fmt.Sprintf("%s,%s", domain, target)
-	if ttl != nil {
-		record = fmt.Sprintf("%s,%s,%d", domain, target, *ttl)
+	list, entry, err := configEntry(record)
+	if err != nil {
+		return err
 	}
 
-	resp, err := p.doAuthenticatedRequest(http.MethodPut, fmt.Sprintf("/config/dns/cnameRecords/%s", record), nil)
+	resp, err := p.doAuthenticatedRequest(ctx, http.MethodPut, apiPath("config", "dns", list, entry), nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to create DNS CNAME record with status code %d", resp.StatusCode)
+		return fmt.Errorf("failed to create DNS %s record %q: %w", record.Type, entry, newAPIError(resp))
 	}
 
 	return nil
 }
 
-func (p *PiHole) createDNSARecord(domain string, ip v1alpha1.IPAddressStr) error {
-	resp, err := p.doAuthenticatedRequest(http.MethodPut, fmt.Sprintf("/config/dns/hosts/%s %s", ip, domain), nil)
+func (p *PiHole) DeleteDNSRecord(ctx context.Context, record DNSRecord) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	list, entry, err := configEntry(record)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.doAuthenticatedRequest(ctx, http.MethodDelete, apiPath("config", "dns", list, entry), nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to create DNS A record with status code %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to delete DNS %s record %q: %w", record.Type, entry, newAPIError(resp))
 	}
 
 	return nil
 }
 
-func (p *PiHole) DeleteDNSRecord(record DNSRecord) error {
-	var domain, path string
-	if record.Type == v1alpha1.A {
-		domain = fmt.Sprintf("%s %s", record.Target, record.Domain)
-		path = "hosts"
-	} else if record.Type == v1alpha1.CName {
-		if record.TTL != nil {
-			domain = fmt.Sprintf("%s,%s,%d", record.Domain, record.Target, *record.TTL)
-		} else {
-			domain = fmt.Sprintf("%s,%s", record.Domain, record.Target)
+// ReplaceDNSRecords replaces oldRecords by newRecords in a single request. The
+// lists are read and written back as a whole through PATCH /config, which FTL
+// applies at once, so there is no moment the domain doesn't resolve.
+func (p *PiHole) ReplaceDNSRecords(ctx context.Context, oldRecords []DNSRecord, newRecords []DNSRecord) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	lists := map[string][]string{}
+
+	entryOf := func(record DNSRecord) (string, string, error) {
+		list, entry, err := configEntry(record)
+		if err != nil {
+			return "", "", err
 		}
 
-		path = "cnameRecords"
-	} else {
-		return fmt.Errorf("invalid DNS record type %s", record.Type)
+		if _, ok := lists[list]; !ok {
+			entries, err := p.getConfigList(ctx, list)
+			if err != nil {
+				return "", "", err
+			}
+			lists[list] = entries
+		}
+
+		return list, entry, nil
+	}
+
+	for _, record := range oldRecords {
+		list, entry, err := entryOf(record)
+		if err != nil {
+			return err
+		}
+
+		i := slices.Index(lists[list], entry)
+		if i < 0 {
+			return fmt.Errorf("failed to replace DNS record %s %s: %w", record.Domain, record.Target, ErrNotFound)
+		}
+		lists[list] = slices.Delete(lists[list], i, i+1)
 	}
 
-	resp, err := p.doAuthenticatedRequest(http.MethodDelete, fmt.Sprintf("/config/dns/%s/%s", path, domain), nil)
+	for _, record := range newRecords {
+		list, entry, err := entryOf(record)
+		if err != nil {
+			return err
+		}
+
+		if slices.Contains(lists[list], entry) {
+			return fmt.Errorf("failed to replace DNS record %s %s: %w", record.Domain, record.Target, ErrConflict)
+		}
+		lists[list] = append(lists[list], entry)
+	}
+
+	if len(lists) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"config": map[string]any{"dns": lists},
+	})
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusNoContent {
-		fmt.Printf("failed to delete DNS record: %d - %s/%s", resp.StatusCode, path, domain)
+	resp, err := p.doAuthenticatedRequest(ctx, http.MethodPatch, apiPath("config"), data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-		return fmt.Errorf("failed to delete DNS record with status code %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to replace DNS records: %w", newAPIError(resp))
 	}
 
 	return nil
 }
 
-func (p *PiHole) authenticate() error {
+func (p *PiHole) authenticate(ctx context.Context) (string, time.Duration, error) {
 	type authRequest struct {
 		Password string `json:"password"`
+		TOTP     *int   `json:"totp,omitempty"`
 	}
 
 	request := authRequest{
 		Password: p.AppPassword,
 	}
 
+	if p.TOTPSecret != "" {
+		now, err := p.nextTOTPPeriod(ctx)
+		if err != nil {
+			return "", 0, err
+		}
+
+		code, err := totp.Generate(p.TOTPSecret, now)
+		if err != nil {
+			return "", 0, err
+		}
+		p.lastTOTP = now.Truncate(totp.Period)
+
+		// Pi-hole expects the code as a number
+		n, err := strconv.Atoi(code)
+		if err != nil {
+			return "", 0, err
+		}
+		request.TOTP = &n
+	}
+
 	data, err := json.Marshal(request)
 	if err != nil {
-		return err
+		return "", 0, err
 	}
 
-	resp, err := p.doRequest(http.MethodPost, "/auth", data)
+	resp, err := p.doRequest(ctx, http.MethodPost, apiPath("auth"), "", data)
 	if err != nil {
-		return err
+		return "", 0, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("authentication failed with status code %d", resp.StatusCode)
-	}
-
 	type authResponse struct {
 		Session struct {
-			Valid bool   `json:"valid"`
-			SID   string `json:"sid"`
+			Valid    bool   `json:"valid"`
+			TOTP     bool   `json:"totp"`
+			SID      string `json:"sid"`
+			Validity int    `json:"validity"`
 		} `json:"session"`
+		Error struct {
+			Key     string `json:"key"`
+			Message string `json:"message"`
+			Hint    string `json:"hint"`
+		} `json:"error"`
 	}
 
 	var response authResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return err
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil && resp.StatusCode == http.StatusOK {
+		return "", 0, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest && strings.Contains(response.Error.Message, "2FA") && p.TOTPSecret == "":
+		return "", 0, ErrTOTPRequired
+	case resp.StatusCode == http.StatusUnauthorized && response.Session.TOTP && p.TOTPSecret == "":
+		return "", 0, ErrTOTPRequired
+	case resp.StatusCode == http.StatusUnauthorized && response.Session.TOTP:
+		return "", 0, fmt.Errorf("%w: wrong app password or TOTP code, check the TOTP secret and the clock of the operator", ErrUnauthorized)
+	case resp.StatusCode == http.StatusUnauthorized:
+		return "", 0, fmt.Errorf("%w: wrong app password", ErrUnauthorized)
+	case resp.StatusCode != http.StatusOK:
+		return "", 0, fmt.Errorf("failed to authenticate: %w", &APIError{
+			StatusCode: resp.StatusCode,
+			Key:        response.Error.Key,
+			Message:    response.Error.Message,
+			Hint:       response.Error.Hint,
+		})
 	}
 
 	if !response.Session.Valid {
-		return fmt.Errorf("authentication failed %v", response)
+		return "", 0, fmt.Errorf("%w: the Pi-hole returned no valid session", ErrUnauthorized)
 	}
 
-	p.sid = response.Session.SID
+	return response.Session.SID, time.Duration(response.Session.Validity) * time.Second, nil
+}
 
-	return nil
+// nextTOTPPeriod returns the time to generate the TOTP code of the next login for. If the
+// code of the current period has already been used, it waits for the next period.
+func (p *PiHole) nextTOTPPeriod(ctx context.Context) (time.Time, error) {
+	now := p.now()
+	next := p.lastTOTP.Add(totp.Period)
+	if !now.Before(next) {
+		return now, nil
+	}
+
+	select {
+	case <-time.After(next.Sub(now)):
+		return next, nil
+	case <-ctx.Done():
+		return time.Time{}, ctx.Err()
+	}
+}
+
+// logout deletes a session that has been replaced, errors are ignored as the
+// session expires on its own anyway
+func (p *PiHole) logout(ctx context.Context, sid string) {
+	resp, err := p.doRequest(ctx, http.MethodDelete, apiPath("auth"), sid, nil)
+	if err == nil {
+		resp.Body.Close()
+	}
 }
 
-func (p *PiHole) doAuthenticatedRequest(method string, path string, body []byte) (*http.Response, error) {
-	if p.sid == "" {
-		if err := p.authenticate(); err != nil {
-			return nil, err
+// doAuthenticatedRequest sends a request with a valid session, failed attempts
+// are retried as configured by p.Retry
+func (p *PiHole) doAuthenticatedRequest(ctx context.Context, method string, path string, body []byte) (*http.Response, error) {
+	p.budget.deposit(p.Retry.BudgetPercent)
+
+	for retry := 1; ; retry++ {
+		resp, sent, err := p.attempt(ctx, method, path, body)
+		if !retryableAttempt(method, sent, resp, err) {
+			return resp, err
+		}
+
+		delay, ok := p.waitForRetry(ctx, retry)
+		if !ok {
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
 	}
+}
 
-	resp, err := p.doRequest(method, path, body)
+// attempt sends a request once, authenticating first if there is no valid
+// session. sent is true if the request may have reached the Pi-hole.
+func (p *PiHole) attempt(ctx context.Context, method string, path string, body []byte) (*http.Response, bool, error) {
+	sid, err := p.session.get(ctx, p.authenticate, p.logout)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+
+	resp, err := p.doRequest(ctx, method, path, sid, body)
+	if err != nil {
+		return nil, true, err
 	}
 
 	if resp.StatusCode == http.StatusUnauthorized {
-		if err := p.authenticate(); err != nil {
-			return nil, err
+		resp.Body.Close()
+		p.session.invalidate(sid)
+
+		sid, err = p.session.get(ctx, p.authenticate, p.logout)
+		if err != nil {
+			// the first request has been rejected, so it wasn't applied
+			return nil, false, err
 		}
 
 		// do request again with new session id
-		resp, err = p.doRequest(method, path, body)
+		resp, err = p.doRequest(ctx, method, path, sid, body)
+		if err != nil {
+			return nil, true, err
+		}
 	}
 
-	return resp, err
+	if resp.StatusCode != http.StatusUnauthorized {
+		p.session.touch(sid)
+	}
+
+	return resp, true, nil
 }
 
-func (p *PiHole) doRequest(method string, path string, body []byte) (*http.Response, error) {
-	req, err := http.NewRequest(method, p.URL+path, io.NopCloser(bytes.NewReader(body)))
+// apiPath returns the path of an API endpoint. Every segment is escaped on its
+// own, so record values containing spaces, commas, slashes or query characters
+// stay a single segment of the path.
+func apiPath(segments ...string) string {
+	var path strings.Builder
+	for _, segment := range segments {
+		path.WriteString("/")
+		path.WriteString(url.PathEscape(segment))
+	}
+
+	return path.String()
+}
+
+func (p *PiHole) doRequest(ctx context.Context, method string, path string, sid string, body []byte) (*http.Response, error) {
+	cancel := func() {}
+	var headerTimeout *time.Timer
+	if p.Timeout > 0 && streaming(ctx) {
+		ctx, cancel = context.WithCancel(ctx)
+		headerTimeout = time.AfterFunc(p.Timeout, cancel)
+	} else if p.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.URL+path, io.NopCloser(bytes.NewReader(body)))
 	if err != nil {
-		log.Fatal(err)
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if sid != "" {
+		req.Header.Add("sid", sid)
 	}
 
-	if p.sid != "" {
-		req.Header.Add("sid", p.sid)
+	resp, err := p.HTTPClient.Do(req)
+	if headerTimeout != nil {
+		headerTimeout.Stop()
 	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// the timeout must cover reading the body, it is released once the body is closed.
+	// Streamed responses are only limited by ctx once their headers have arrived.
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
 
-	client := &http.Client{}
-	return client.Do(req)
+	return resp, nil
 }
 
-func (p *PiHole) Close() error {
-	resp, err := p.doAuthenticatedRequest(http.MethodDelete, "/auth", nil)
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+
+	return c.ReadCloser.Close()
+}
+
+func (p *PiHole) Close(ctx context.Context) error {
+	sid := p.session.current()
+	if sid == "" {
+		// there is no session to close
+		return nil
+	}
+
+	resp, err := p.doRequest(ctx, http.MethodDelete, apiPath("auth"), sid, nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to close session with status code %d", resp.StatusCode)
+	p.session.invalidate(sid)
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("failed to close session: %w", newAPIError(resp))
 	}
 
 	return nil
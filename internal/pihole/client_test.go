@@ -1,15 +1,452 @@
 package pihole
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+	"github.com/domnikl/pihole-operator/internal/pihole/totp"
 )
 
 var _ = Describe("Pi-Hole Client", func() {
-	Context("When reconciling a resource", func() {
-		It("should successfully reconcile the resource", func() {
-			By("Reconciling the created resource")
-			Expect(true).To(BeFalse())
+	const password = "secret"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var piHole *PiHole
+
+	ttl := func(t int32) *int32 {
+		return &t
+	}
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		piHole = NewPiHole(server.URL+"/api", password)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	DescribeTable("listing DNS records",
+		func(hosts []string, cnameRecords []string, expected []DNSRecord) {
+			sim.SetHosts(hosts...)
+			sim.SetCNAMERecords(cnameRecords...)
+
+			records, err := piHole.GetDNSRecords(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(ConsistOf(expected))
+		},
+		Entry("no records", nil, nil, []DNSRecord{}),
+		Entry("A record", []string{"192.168.178.1 foo.com"}, nil, []DNSRecord{
+			{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+		}),
+		Entry("AAAA record", []string{"2001:db8::1 foo.com"}, nil, []DNSRecord{
+			{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA},
+		}),
+		Entry("CNAME record without TTL", nil, []string{"foo.com,bar.com"}, []DNSRecord{
+			{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName},
+		}),
+		Entry("CNAME record with TTL", nil, []string{"foo.com,bar.com,300"}, []DNSRecord{
+			{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName, TTL: ttl(300)},
+		}),
+		Entry("mixed records", []string{"192.168.178.1 foo.com", "192.168.178.2 bar.com"}, []string{"baz.com,foo.com"}, []DNSRecord{
+			{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			{Domain: "bar.com", Target: "192.168.178.2", Type: v1alpha1.A},
+			{Domain: "baz.com", Target: "foo.com", Type: v1alpha1.CName},
+		}),
+	)
+
+	DescribeTable("skipping malformed entries",
+		func(hosts []string, cnameRecords []string) {
+			sim.SetHosts(append([]string{"192.168.178.1 foo.com"}, hosts...)...)
+			sim.SetCNAMERecords(append(cnameRecords, "baz.com,foo.com")...)
+
+			records, err := piHole.GetDNSRecords(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(ConsistOf(
+				DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+				DNSRecord{Domain: "baz.com", Target: "foo.com", Type: v1alpha1.CName},
+			))
+		},
+		Entry("host without a domain", []string{"192.168.178.1"}, nil),
+		Entry("host with an invalid address", []string{"foo.com bar.com"}, nil),
+		Entry("CNAME record without a target", nil, []string{"foo.com"}),
+		Entry("CNAME record with an empty target", nil, []string{"foo.com,"}),
+		Entry("CNAME record with an invalid TTL", nil, []string{"foo.com,bar.com,soon"}),
+		Entry("CNAME record with too many fields", nil, []string{"foo.com,bar.com,300,1"}),
+	)
+
+	DescribeTable("creating DNS records",
+		func(record DNSRecord, hosts []string, cnameRecords []string) {
+			Expect(piHole.CreateDNSRecord(ctx, record)).To(Succeed())
+
+			Expect(sim.Hosts()).To(Equal(hosts))
+			Expect(sim.CNAMERecords()).To(Equal(cnameRecords))
+		},
+		Entry("A record",
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			[]string{"192.168.178.1 foo.com"}, []string{}),
+		Entry("AAAA record",
+			DNSRecord{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA},
+			[]string{"2001:db8::1 foo.com"}, []string{}),
+		Entry("CNAME record without TTL",
+			DNSRecord{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName},
+			[]string{}, []string{"foo.com,bar.com"}),
+		Entry("CNAME record with TTL",
+			DNSRecord{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName, TTL: ttl(300)},
+			[]string{}, []string{"foo.com,bar.com,300"}),
+	)
+
+	DescribeTable("deleting DNS records",
+		func(record DNSRecord) {
+			sim.SetHosts("192.168.178.1 foo.com", "2001:db8::1 foo.com")
+			sim.SetCNAMERecords("foo.com,bar.com", "bar.com,baz.com,300")
+
+			Expect(piHole.DeleteDNSRecord(ctx, record)).To(Succeed())
+
+			records, err := piHole.GetDNSRecords(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).NotTo(ContainElement(record))
+			Expect(records).To(HaveLen(3))
+		},
+		Entry("A record", DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A}),
+		Entry("AAAA record", DNSRecord{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA}),
+		Entry("CNAME record without TTL", DNSRecord{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName}),
+		Entry("CNAME record with TTL", DNSRecord{Domain: "bar.com", Target: "baz.com", Type: v1alpha1.CName, TTL: ttl(300)}),
+	)
+
+	DescribeTable("escaping record values",
+		func(record DNSRecord) {
+			Expect(piHole.CreateDNSRecord(ctx, record)).To(Succeed())
+
+			records, err := piHole.GetDNSRecords(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(ConsistOf(record))
+
+			Expect(piHole.DeleteDNSRecord(ctx, record)).To(Succeed())
+			Expect(sim.Hosts()).To(BeEmpty())
+			Expect(sim.CNAMERecords()).To(BeEmpty())
+		},
+		Entry("query characters", DNSRecord{Domain: "foo?bar#baz.com", Target: "192.168.178.1", Type: v1alpha1.A}),
+		Entry("percent sign", DNSRecord{Domain: "foo%20.com", Target: "192.168.178.1", Type: v1alpha1.A}),
+		Entry("slash", DNSRecord{Domain: "foo/bar.com", Target: "baz/../qux.com", Type: v1alpha1.CName}),
+		Entry("unicode", DNSRecord{Domain: "bücher.de", Target: "2001:db8::1", Type: v1alpha1.AAAA}),
+	)
+
+	DescribeTable("replacing DNS records",
+		func(old DNSRecord, replacement DNSRecord, hosts []string, cnameRecords []string) {
+			sim.SetHosts("192.168.178.1 foo.com", "192.168.178.2 bar.com")
+			sim.SetCNAMERecords("baz.com,foo.com")
+
+			Expect(piHole.ReplaceDNSRecords(ctx, []DNSRecord{old}, []DNSRecord{replacement})).To(Succeed())
+
+			Expect(sim.Hosts()).To(Equal(hosts))
+			Expect(sim.CNAMERecords()).To(Equal(cnameRecords))
+		},
+		Entry("A record",
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.3", Type: v1alpha1.A},
+			[]string{"192.168.178.2 bar.com", "192.168.178.3 foo.com"}, []string{"baz.com,foo.com"}),
+		Entry("CNAME record",
+			DNSRecord{Domain: "baz.com", Target: "foo.com", Type: v1alpha1.CName},
+			DNSRecord{Domain: "baz.com", Target: "bar.com", Type: v1alpha1.CName, TTL: ttl(300)},
+			[]string{"192.168.178.1 foo.com", "192.168.178.2 bar.com"}, []string{"baz.com,bar.com,300"}),
+		Entry("A record by a CNAME record",
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			DNSRecord{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName},
+			[]string{"192.168.178.2 bar.com"}, []string{"baz.com,foo.com", "foo.com,bar.com"}),
+	)
+
+	It("should replace nothing if a record to replace doesn't exist", func() {
+		sim.SetHosts("192.168.178.1 foo.com")
+
+		err := piHole.ReplaceDNSRecords(ctx,
+			[]DNSRecord{{Domain: "foo.com", Target: "192.168.178.9", Type: v1alpha1.A}},
+			[]DNSRecord{{Domain: "foo.com", Target: "192.168.178.3", Type: v1alpha1.A}})
+		Expect(err).To(MatchError(ErrNotFound))
+
+		Expect(sim.Hosts()).To(Equal([]string{"192.168.178.1 foo.com"}))
+	})
+
+	It("should fail to create a record that already exists", func() {
+		sim.SetHosts("192.168.178.1 foo.com")
+
+		err := piHole.CreateDNSRecord(ctx, DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A})
+		Expect(err).To(MatchError(ErrConflict))
+
+		var apiErr *APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.Message).To(Equal("Item already present"))
+	})
+
+	It("should fail to delete a record that doesn't exist", func() {
+		err := piHole.DeleteDNSRecord(ctx, DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A})
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should fail to create an invalid record", func() {
+		err := piHole.CreateDNSRecord(ctx, DNSRecord{Domain: "foo.com", Target: "not-an-ip", Type: v1alpha1.A})
+		Expect(err).To(MatchError(ErrInvalid))
+		Expect(IsRetryable(err)).To(BeFalse())
+	})
+
+	It("should return an error for an invalid URL", func() {
+		piHole = NewPiHole("http://pi.hole:port/api", password)
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).To(MatchError(ContainSubstring("failed to create request")))
+	})
+
+	It("should fail with a wrong password", func() {
+		piHole = NewPiHole(server.URL+"/api", "wrong")
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).To(MatchError(ErrUnauthorized))
+	})
+
+	Context("with two-factor authentication", func() {
+		// base32 encoding of "12345678901234567890"
+		const totpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+		BeforeEach(func() {
+			sim.TOTPSecret = totpSecret
+		})
+
+		It("should authenticate with the current TOTP code", func() {
+			piHole.TOTPSecret = totpSecret
+
+			_, err := piHole.GetDNSRecords(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sim.Logins()).To(Equal(1))
+		})
+
+		It("should not reuse a TOTP code when authenticating again", func() {
+			// start shortly before the end of a period, so the second login has to wait for the next one
+			now := time.Now()
+			offset := now.Truncate(totp.Period).Add(totp.Period - 100*time.Millisecond).Sub(now)
+			clock := func() time.Time { return time.Now().Add(offset) }
+			sim.SetClock(clock)
+			piHole.now = clock
+			piHole.TOTPSecret = totpSecret
+
+			_, err := piHole.GetDNSRecords(ctx)
+			Expect(err).NotTo(HaveOccurred())
+
+			sim.ExpireSessions()
+
+			_, err = piHole.GetDNSRecords(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sim.Logins()).To(Equal(2))
+		})
+
+		It("should fail when no TOTP secret is configured", func() {
+			_, err := piHole.GetDNSRecords(ctx)
+			Expect(err).To(MatchError(ErrTOTPRequired))
+			Expect(err).To(MatchError(ErrUnauthorized))
 		})
+
+		It("should fail with a wrong TOTP secret", func() {
+			piHole.TOTPSecret = "JBSWY3DPEHPK3PXP"
+
+			_, err := piHole.GetDNSRecords(ctx)
+			Expect(err).To(MatchError(ContainSubstring("TOTP code")))
+		})
+
+		It("should fail with an invalid TOTP secret", func() {
+			piHole.TOTPSecret = "not base32!"
+
+			_, err := piHole.GetDNSRecords(ctx)
+			Expect(err).To(MatchError(ContainSubstring("invalid TOTP secret")))
+		})
+	})
+
+	It("should reuse the session", func() {
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sim.Logins()).To(Equal(1))
+	})
+
+	It("should authenticate again when the session expired", func() {
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		sim.ExpireSessions()
+
+		_, err = piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sim.Logins()).To(Equal(2))
+	})
+
+	Context("when used concurrently", func() {
+		getConcurrently := func(n int) {
+			var wg sync.WaitGroup
+			errs := make(chan error, n)
+
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := piHole.GetDNSRecords(ctx)
+					errs <- err
+				}()
+			}
+
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		}
+
+		It("should log in only once", func() {
+			sim.Latency = 10 * time.Millisecond
+
+			getConcurrently(20)
+
+			Expect(sim.Logins()).To(Equal(1))
+			Expect(sim.Sessions()).To(Equal(1))
+		})
+
+		It("should log in only once when the session expired", func() {
+			getConcurrently(1)
+			sim.ExpireSessions()
+
+			getConcurrently(20)
+
+			Expect(sim.Logins()).To(Equal(2))
+			Expect(sim.Sessions()).To(Equal(1))
+		})
+	})
+
+	It("should refresh the session before it expires", func() {
+		sim.SessionValidity = time.Second
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		time.Sleep(920 * time.Millisecond)
+
+		_, err = piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sim.Logins()).To(Equal(2))
+		Expect(sim.Sessions()).To(Equal(1))
+	})
+
+	It("should close the session", func() {
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sim.Sessions()).To(Equal(1))
+
+		Expect(piHole.Close(ctx)).To(Succeed())
+		Expect(sim.Sessions()).To(Equal(0))
+	})
+
+	It("should time out when the Pi-hole doesn't answer", func() {
+		sim.Latency = time.Second
+		piHole.Timeout = 50 * time.Millisecond
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("should honor the cancellation of the context", func() {
+		sim.Latency = time.Second
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := piHole.GetDNSRecords(cancelled)
+		Expect(err).To(MatchError(context.Canceled))
 	})
 })
+
+// FuzzDNSRecordRoundTrip creates, lists and deletes arbitrary records against the
+// simulator, every record the Pi-hole can store must survive the round trip
+func FuzzDNSRecordRoundTrip(f *testing.F) {
+	f.Add("foo.com", "192.168.178.1", false, int32(-1))
+	f.Add("foo.com", "2001:db8::1", false, int32(-1))
+	f.Add("foo.com", "bar.com", true, int32(-1))
+	f.Add("foo.com", "bar.com", true, int32(300))
+	f.Add("foo?bar#baz.com", "192.168.178.1", false, int32(-1))
+	f.Add("foo/../bar.com", "%2F.com", true, int32(0))
+
+	server, _ := simulator.NewServer("secret")
+	f.Cleanup(server.Close)
+	piHole := NewPiHole(server.URL+"/api", "secret")
+	ctx := context.Background()
+
+	f.Fuzz(func(t *testing.T, domain string, target string, cname bool, ttl int32) {
+		record := DNSRecord{Domain: domain, Target: target, Type: addressType(target)}
+		if cname {
+			record.Type = v1alpha1.CName
+			if ttl >= 0 {
+				record.TTL = &ttl
+			}
+		}
+
+		if !storable(record) {
+			t.Skip("the Pi-hole can't store the record")
+		}
+
+		if err := piHole.CreateDNSRecord(ctx, record); err != nil {
+			t.Fatalf("create %+v: %v", record, err)
+		}
+
+		records, err := piHole.GetDNSRecords(ctx)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if len(records) != 1 || !records[0].Equals(&record) {
+			t.Fatalf("list: expected %+v, got %+v", record, records)
+		}
+
+		if err := piHole.DeleteDNSRecord(ctx, record); err != nil {
+			t.Fatalf("delete %+v: %v", record, err)
+		}
+
+		records, err = piHole.GetDNSRecords(ctx)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if len(records) != 0 {
+			t.Fatalf("list after delete: expected no records, got %+v", records)
+		}
+	})
+}
+
+// storable returns true if the record can be written to the lists of the Pi-hole
+// config, whitespace and commas separate the fields of their entries
+func storable(record DNSRecord) bool {
+	values := []string{record.Domain, record.Target}
+	for _, value := range values {
+		if value == "" || !utf8.ValidString(value) || strings.ContainsFunc(value, unicode.IsSpace) {
+			return false
+		}
+	}
+
+	if record.Type == v1alpha1.CName {
+		return !strings.Contains(record.Domain, ",") && !strings.Contains(record.Target, ",")
+	}
+
+	return net.ParseIP(record.Target) != nil
+}
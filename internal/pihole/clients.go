@@ -0,0 +1,154 @@
+package pihole
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// PiHoleClient is a client of a Pi-hole, i.e. a device or network sending DNS
+// queries to it. Clients are assigned to groups to apply their adlists and domains
+// to the queries of the client only.
+type PiHoleClient struct {
+	// ID is the id of the client on the Pi-hole, it is assigned when the client is created
+	ID int
+	// Client identifies the client by its IP address, subnet, MAC address or hostname
+	Client string
+	// Name is the hostname the Pi-hole resolved for the client, if any
+	Name string
+	// Comment describes the client
+	Comment string
+	// Groups are the ids of the groups the client is assigned to
+	Groups []int
+}
+
+// ClientClient manages the clients of a Pi-hole, only Pi-hole v6 supports it
+type ClientClient interface {
+	// GetClients returns all clients
+	GetClients(ctx context.Context) ([]PiHoleClient, error)
+	// CreateClient adds a client and returns it as stored by the Pi-hole
+	CreateClient(ctx context.Context, client PiHoleClient) (PiHoleClient, error)
+	// UpdateClient changes the comment and groups of an existing client
+	UpdateClient(ctx context.Context, client PiHoleClient) (PiHoleClient, error)
+	// DeleteClient removes a client
+	DeleteClient(ctx context.Context, client string) error
+}
+
+var _ ClientClient = &PiHole{}
+
+// clientJSON is a client as represented by the Pi-hole API
+type clientJSON struct {
+	ID      int     `json:"id,omitempty"`
+	Client  string  `json:"client"`
+	Name    *string `json:"name,omitempty"`
+	Comment *string `json:"comment"`
+	Groups  []int   `json:"groups"`
+}
+
+func (c clientJSON) client() PiHoleClient {
+	client := PiHoleClient{ID: c.ID, Client: c.Client, Groups: c.Groups}
+	if c.Name != nil {
+		client.Name = *c.Name
+	}
+	if c.Comment != nil {
+		client.Comment = *c.Comment
+	}
+
+	return client
+}
+
+func (p *PiHole) GetClients(ctx context.Context) ([]PiHoleClient, error) {
+	var response struct {
+		Clients []clientJSON `json:"clients"`
+	}
+	if err := p.getJSON(ctx, apiPath("clients"), &response); err != nil {
+		return nil, fmt.Errorf("failed to get clients: %w", err)
+	}
+
+	clients := make([]PiHoleClient, 0, len(response.Clients))
+	for _, client := range response.Clients {
+		clients = append(clients, client.client())
+	}
+
+	return clients, nil
+}
+
+func (p *PiHole) CreateClient(ctx context.Context, client PiHoleClient) (PiHoleClient, error) {
+	if err := ValidateClient(client.Client); err != nil {
+		return PiHoleClient{}, err
+	}
+
+	body := clientJSON{Client: client.Client, Comment: &client.Comment, Groups: client.Groups}
+
+	created, err := p.writeClient(ctx, http.MethodPost, apiPath("clients"), body, http.StatusCreated)
+	if err != nil {
+		return PiHoleClient{}, fmt.Errorf("failed to create client %s: %w", client.Client, err)
+	}
+
+	return created, nil
+}
+
+func (p *PiHole) UpdateClient(ctx context.Context, client PiHoleClient) (PiHoleClient, error) {
+	body := clientJSON{Client: client.Client, Comment: &client.Comment, Groups: client.Groups}
+
+	updated, err := p.writeClient(ctx, http.MethodPut, apiPath("clients", client.Client), body, http.StatusOK)
+	if err != nil {
+		return PiHoleClient{}, fmt.Errorf("failed to update client %s: %w", client.Client, err)
+	}
+
+	return updated, nil
+}
+
+func (p *PiHole) DeleteClient(ctx context.Context, client string) error {
+	resp, err := p.doAuthenticatedRequest(ctx, http.MethodDelete, apiPath("clients", client), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to delete client %s: %w", client, newAPIError(resp))
+	}
+
+	return nil
+}
+
+// writeClient creates or updates a client and returns it as stored by the Pi-hole
+func (p *PiHole) writeClient(ctx context.Context, method string, path string, client clientJSON, expected int) (PiHoleClient, error) {
+	var response struct {
+		Clients   []clientJSON `json:"clients"`
+		Processed processed    `json:"processed"`
+	}
+	if err := p.sendJSON(ctx, method, path, client, expected, &response); err != nil {
+		return PiHoleClient{}, err
+	}
+
+	if err := response.Processed.err(); err != nil {
+		return PiHoleClient{}, err
+	}
+	if len(response.Clients) == 0 {
+		return PiHoleClient{}, fmt.Errorf("the Pi-hole returned no client")
+	}
+
+	return response.Clients[0].client(), nil
+}
+
+// ValidateClient checks that a client is an IP address, a subnet in CIDR notation,
+// a MAC address or a hostname, the forms of clients the Pi-hole can match queries to
+func ValidateClient(client string) error {
+	if net.ParseIP(client) != nil {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(client); err == nil {
+		return nil
+	}
+	if mac, err := net.ParseMAC(client); err == nil && len(mac) == 6 {
+		return nil
+	}
+	if len(client) <= 253 && exactDomain.MatchString(client) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %q is neither an IP address, a subnet, a MAC address nor a hostname", ErrInvalid, client)
+}
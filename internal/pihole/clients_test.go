@@ -0,0 +1,135 @@
+package pihole
+
+import (
+	"context"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Pi-Hole Clients", func() {
+	const password = "secret"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var piHole *PiHole
+
+	name := func(n string) *string {
+		return &n
+	}
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		piHole = NewPiHole(server.URL+"/api", password)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list clients", func() {
+		sim.SetClients(simulator.Client{Client: "192.168.178.20", Name: name("tablet.fritz.box"), Comment: name("kids tablet"), Groups: []int{0, 3}})
+
+		clients, err := piHole.GetClients(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(clients).To(HaveLen(1))
+		Expect(clients[0].ID).NotTo(BeZero())
+		Expect(clients[0].Client).To(Equal("192.168.178.20"))
+		Expect(clients[0].Name).To(Equal("tablet.fritz.box"))
+		Expect(clients[0].Comment).To(Equal("kids tablet"))
+		Expect(clients[0].Groups).To(Equal([]int{0, 3}))
+	})
+
+	DescribeTable("creating clients",
+		func(client PiHoleClient) {
+			created, err := piHole.CreateClient(ctx, client)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.ID).NotTo(BeZero())
+
+			client.ID = created.ID
+			Expect(created).To(Equal(client))
+			Expect(sim.Clients()).To(HaveLen(1))
+		},
+		Entry("IP address", PiHoleClient{Client: "192.168.178.20", Groups: []int{0}}),
+		Entry("IPv6 address", PiHoleClient{Client: "fd00::20", Groups: []int{0}}),
+		Entry("subnet", PiHoleClient{Client: "192.168.178.0/24", Comment: "home", Groups: []int{0}}),
+		Entry("MAC address", PiHoleClient{Client: "12:34:56:78:9a:bc", Groups: []int{0}}),
+		Entry("hostname", PiHoleClient{Client: "tablet.fritz.box", Groups: []int{0}}),
+	)
+
+	It("should not send an invalid client to the Pi-hole", func() {
+		_, err := piHole.CreateClient(ctx, PiHoleClient{Client: "192.168.178.300/24"})
+		Expect(err).To(MatchError(ErrInvalid))
+		Expect(sim.Writes()).To(BeZero())
+	})
+
+	It("should report an existing client as a conflict", func() {
+		sim.SetClients(simulator.Client{Client: "192.168.178.20", Groups: []int{0}})
+
+		_, err := piHole.CreateClient(ctx, PiHoleClient{Client: "192.168.178.20"})
+		Expect(err).To(MatchError(ErrConflict))
+	})
+
+	It("should update the groups of a subnet", func() {
+		sim.SetClients(simulator.Client{Client: "10.0.0.0/8", Groups: []int{0}})
+
+		updated, err := piHole.UpdateClient(ctx, PiHoleClient{Client: "10.0.0.0/8", Comment: "guests", Groups: []int{4}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Groups).To(Equal([]int{4}))
+		Expect(updated.Comment).To(Equal("guests"))
+	})
+
+	It("should report updating a missing client as not found", func() {
+		_, err := piHole.UpdateClient(ctx, PiHoleClient{Client: "192.168.178.20"})
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should delete clients", func() {
+		sim.SetClients(simulator.Client{Client: "10.0.0.0/8", Groups: []int{0}}, simulator.Client{Client: "10.0.0.1", Groups: []int{0}})
+
+		Expect(piHole.DeleteClient(ctx, "10.0.0.0/8")).To(Succeed())
+		Expect(sim.Clients()).To(ConsistOf(HaveField("Client", "10.0.0.1")))
+	})
+
+	It("should report deleting a missing client as not found", func() {
+		err := piHole.DeleteClient(ctx, "12:34:56:78:9a:bc")
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should not support clients on Pi-hole v5", func() {
+		server, _ := simulator.NewLegacyServer("token")
+		defer server.Close()
+
+		client := NewClient(Config{URL: server.URL + "/admin/api.php", AppPassword: "token"})
+
+		_, err := client.(ClientClient).GetClients(ctx)
+		Expect(err).To(MatchError(ErrUnsupported))
+	})
+})
+
+var _ = DescribeTable("validating clients",
+	func(client string, valid bool) {
+		err := ValidateClient(client)
+		if valid {
+			Expect(err).NotTo(HaveOccurred())
+		} else {
+			Expect(err).To(MatchError(ErrInvalid))
+		}
+	},
+	Entry("IPv4 address", "192.168.178.20", true),
+	Entry("IPv6 address", "fd00::20", true),
+	Entry("IPv4 subnet", "192.168.178.0/24", true),
+	Entry("IPv6 subnet", "fd00::/64", true),
+	Entry("MAC address", "12:34:56:78:9A:BC", true),
+	Entry("hostname", "tablet", true),
+	Entry("fully qualified hostname", "tablet.fritz.box", true),
+	Entry("invalid subnet", "192.168.178.0/33", false),
+	Entry("truncated MAC address", "12:34:56:78:9a", false),
+	Entry("hostname with a space", "kids tablet", false),
+	Entry("empty client", "", false),
+)
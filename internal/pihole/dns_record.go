@@ -2,6 +2,8 @@ package pihole
 
 import (
 	"fmt"
+	"net"
+	"slices"
 
 	"github.com/domnikl/pihole-operator/api/v1alpha1"
 )
@@ -13,47 +15,146 @@ type DNSRecord struct {
 	TTL    *int32
 }
 
-func NewDNSRecordFromSpec(spec v1alpha1.DNSNameSpec) (*DNSRecord, error) {
-	// TODO: targetIP is required for A records
-	var target string
+// NewDNSRecordsFromSpec returns all records described by a DNSName. CNAMEs map to a
+// single record, addresses to one record per IP. IPv6 addresses in targetIPs of an
+// A record are published as AAAA records, so dual-stack hosts need only one DNSName.
+func NewDNSRecordsFromSpec(spec v1alpha1.DNSNameSpec) ([]DNSRecord, error) {
+	switch spec.Type {
+	case v1alpha1.A, v1alpha1.AAAA:
+		if spec.TargetIP == nil && len(spec.TargetIPs) == 0 {
+			return nil, fmt.Errorf("targetIP or targetIPs is required for %s records", spec.Type)
+		}
+
+		var records []DNSRecord
+		add := func(ip string, recordType v1alpha1.DNSRecordType) {
+			record := DNSRecord{Domain: spec.Domain, Target: ip, Type: recordType}
+			for i := range records {
+				if records[i].Equals(&record) {
+					return
+				}
+			}
+
+			records = append(records, record)
+		}
+
+		if spec.TargetIP != nil {
+			ip := string(*spec.TargetIP)
+			if addressType(ip) != spec.Type {
+				return nil, fmt.Errorf("targetIP %s is not valid for %s records", ip, spec.Type)
+			}
 
-	if spec.Type == v1alpha1.A {
-		if spec.TargetIP == nil {
-			return nil, fmt.Errorf("targetIP is required for A records")
+			add(ip, spec.Type)
 		}
 
-		target = string(*spec.TargetIP)
-	} else if spec.Type == v1alpha1.CName {
+		for _, targetIP := range spec.TargetIPs {
+			ip := string(targetIP)
+			if net.ParseIP(ip) == nil {
+				return nil, fmt.Errorf("targetIPs contains the invalid address %s", ip)
+			}
+			if spec.Type == v1alpha1.AAAA && addressType(ip) != v1alpha1.AAAA {
+				return nil, fmt.Errorf("targetIPs %s is not valid for AAAA records", ip)
+			}
+
+			add(ip, addressType(ip))
+		}
+
+		return records, nil
+	case v1alpha1.CName:
 		if spec.Target == nil {
 			return nil, fmt.Errorf("target is required for CNAME records")
 		}
 
-		target = string(*spec.Target)
-	} else {
-		return nil, fmt.Errorf("invalid DNS record type %s", spec.Type)
+		return []DNSRecord{{
+			Domain: spec.Domain,
+			Target: string(*spec.Target),
+			Type:   spec.Type,
+			TTL:    spec.TTL,
+		}}, nil
 	}
 
-	return &DNSRecord{
-		Domain: spec.Domain,
-		Target: target,
-		Type:   spec.Type,
-		TTL:    spec.TTL,
-	}, nil
+	return nil, fmt.Errorf("invalid DNS record type %s", spec.Type)
 }
 
 func (r *DNSRecord) Equals(other *DNSRecord) bool {
 	if r.Domain != other.Domain {
 		return false
 	}
-	if r.Target != other.Target {
+	if r.Type != other.Type {
 		return false
 	}
-	if r.Type != other.Type {
+	if r.Type == v1alpha1.A || r.Type == v1alpha1.AAAA {
+		// IPv6 addresses have several representations
+		if !net.ParseIP(r.Target).Equal(net.ParseIP(other.Target)) {
+			return false
+		}
+	} else if r.Target != other.Target {
+		return false
+	}
+	if (r.TTL == nil) != (other.TTL == nil) {
 		return false
 	}
-	if r.TTL != other.TTL {
+	if r.TTL != nil && *r.TTL != *other.TTL {
 		return false
 	}
 
 	return true
 }
+
+// Key identifies the record, records that are equal have the same key.
+func (r *DNSRecord) Key() string {
+	target := r.Target
+	if ip := net.ParseIP(target); ip != nil && (r.Type == v1alpha1.A || r.Type == v1alpha1.AAAA) {
+		target = ip.String()
+	}
+
+	key := fmt.Sprintf("%s %s %s", r.Type, r.Domain, target)
+	if r.TTL != nil {
+		key = fmt.Sprintf("%s %d", key, *r.TTL)
+	}
+
+	return key
+}
+
+// Conflicts returns true if both records are for the same domain and can't
+// coexist. A CNAME conflicts with every other record of its domain, addresses
+// only conflict with addresses of the same type.
+func (r *DNSRecord) Conflicts(other *DNSRecord) bool {
+	if r.Domain != other.Domain {
+		return false
+	}
+
+	return TypesConflict(r.Type, other.Type)
+}
+
+// TypesConflict returns true if records of both types can't coexist for the same domain.
+func TypesConflict(a v1alpha1.DNSRecordType, b v1alpha1.DNSRecordType) bool {
+	return a == b || a == v1alpha1.CName || b == v1alpha1.CName
+}
+
+// RecordTypes returns the types of all records described by a DNSName.
+func RecordTypes(spec v1alpha1.DNSNameSpec) []v1alpha1.DNSRecordType {
+	records, err := NewDNSRecordsFromSpec(spec)
+	if err != nil {
+		return []v1alpha1.DNSRecordType{spec.Type}
+	}
+
+	var types []v1alpha1.DNSRecordType
+	for _, record := range records {
+		if !slices.Contains(types, record.Type) {
+			types = append(types, record.Type)
+		}
+	}
+
+	return types
+}
+
+// addressType returns the type of the record for an address, which is AAAA for
+// IPv6 and A for everything else
+func addressType(ip string) v1alpha1.DNSRecordType {
+	parsed := net.ParseIP(ip)
+	if parsed != nil && parsed.To4() == nil {
+		return v1alpha1.AAAA
+	}
+
+	return v1alpha1.A
+}
@@ -0,0 +1,110 @@
+package pihole
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/api/v1alpha1"
+)
+
+var _ = Describe("DNS records", func() {
+	ip := func(ip string) *v1alpha1.IPAddressStr {
+		i := v1alpha1.IPAddressStr(ip)
+		return &i
+	}
+
+	hostname := func(h string) *v1alpha1.Hostname {
+		n := v1alpha1.Hostname(h)
+		return &n
+	}
+
+	DescribeTable("creating records from a spec",
+		func(spec v1alpha1.DNSNameSpec, expected []DNSRecord, expectedErr string) {
+			records, err := NewDNSRecordsFromSpec(spec)
+			if expectedErr != "" {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+				return
+			}
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(Equal(expected))
+		},
+		Entry("A record",
+			v1alpha1.DNSNameSpec{Type: v1alpha1.A, Domain: "foo.com", TargetIP: ip("192.168.178.1")},
+			[]DNSRecord{{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A}}, ""),
+		Entry("AAAA record",
+			v1alpha1.DNSNameSpec{Type: v1alpha1.AAAA, Domain: "foo.com", TargetIP: ip("2001:db8::1")},
+			[]DNSRecord{{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA}}, ""),
+		Entry("A record with IPv6",
+			v1alpha1.DNSNameSpec{Type: v1alpha1.A, Domain: "foo.com", TargetIP: ip("2001:db8::1")},
+			nil, "not valid for A records"),
+		Entry("AAAA record with IPv4",
+			v1alpha1.DNSNameSpec{Type: v1alpha1.AAAA, Domain: "foo.com", TargetIP: ip("192.168.178.1")},
+			nil, "not valid for AAAA records"),
+		Entry("AAAA record without targetIP",
+			v1alpha1.DNSNameSpec{Type: v1alpha1.AAAA, Domain: "foo.com"},
+			nil, "targetIP or targetIPs is required"),
+		Entry("dual-stack A record",
+			v1alpha1.DNSNameSpec{Type: v1alpha1.A, Domain: "foo.com", TargetIPs: []v1alpha1.IPAddressStr{"192.168.178.1", "2001:db8::1"}},
+			[]DNSRecord{
+				{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+				{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA},
+			}, ""),
+		Entry("round-robin A record with duplicates",
+			v1alpha1.DNSNameSpec{Type: v1alpha1.A, Domain: "foo.com", TargetIP: ip("192.168.178.1"), TargetIPs: []v1alpha1.IPAddressStr{"192.168.178.1", "192.168.178.2"}},
+			[]DNSRecord{
+				{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+				{Domain: "foo.com", Target: "192.168.178.2", Type: v1alpha1.A},
+			}, ""),
+		Entry("AAAA record with IPv4 in targetIPs",
+			v1alpha1.DNSNameSpec{Type: v1alpha1.AAAA, Domain: "foo.com", TargetIPs: []v1alpha1.IPAddressStr{"2001:db8::1", "192.168.178.1"}},
+			nil, "not valid for AAAA records"),
+		Entry("CNAME record",
+			v1alpha1.DNSNameSpec{Type: v1alpha1.CName, Domain: "foo.com", Target: hostname("bar.com")},
+			[]DNSRecord{{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName}}, ""),
+	)
+
+	DescribeTable("comparing records",
+		func(a DNSRecord, b DNSRecord, equal bool) {
+			Expect(a.Equals(&b)).To(Equal(equal))
+		},
+		Entry("same A record",
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A}, true),
+		Entry("A and AAAA record",
+			DNSRecord{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.A},
+			DNSRecord{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA}, false),
+		Entry("differently written IPv6",
+			DNSRecord{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA},
+			DNSRecord{Domain: "foo.com", Target: "2001:0DB8:0:0::1", Type: v1alpha1.AAAA}, true),
+	)
+
+	It("should use the same key for equal records", func() {
+		a := DNSRecord{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA}
+		b := DNSRecord{Domain: "foo.com", Target: "2001:0DB8:0:0::1", Type: v1alpha1.AAAA}
+		Expect(a.Key()).To(Equal(b.Key()))
+
+		ttl := int32(300)
+		c := DNSRecord{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName}
+		d := DNSRecord{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName, TTL: &ttl}
+		Expect(c.Key()).NotTo(Equal(d.Key()))
+	})
+
+	DescribeTable("conflicting records",
+		func(a DNSRecord, b DNSRecord, conflicts bool) {
+			Expect(a.Conflicts(&b)).To(Equal(conflicts))
+		},
+		Entry("A records of the same domain",
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.2", Type: v1alpha1.A}, true),
+		Entry("A and AAAA record of the same domain",
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			DNSRecord{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA}, false),
+		Entry("CNAME and AAAA record of the same domain",
+			DNSRecord{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName},
+			DNSRecord{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA}, true),
+		Entry("different domains",
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			DNSRecord{Domain: "bar.com", Target: "192.168.178.1", Type: v1alpha1.A}, false),
+	)
+})
@@ -0,0 +1,270 @@
+package pihole
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// DomainType defines whether a domain is allowed or denied
+type DomainType string
+
+const (
+	// DomainTypeAllow allows a domain, even if it is on a block list
+	DomainTypeAllow DomainType = "allow"
+	// DomainTypeDeny blocks a domain
+	DomainTypeDeny DomainType = "deny"
+)
+
+// DomainKind defines how a domain is matched
+type DomainKind string
+
+const (
+	// DomainKindExact matches the domain only
+	DomainKindExact DomainKind = "exact"
+	// DomainKindRegex matches all domains matching a regular expression
+	DomainKindRegex DomainKind = "regex"
+)
+
+// Domain is an allowed or denied domain of a Pi-hole
+type Domain struct {
+	// ID is the id of the domain on the Pi-hole, it is assigned when the domain is created
+	ID int
+	// Domain is the domain or the regular expression matching domains
+	Domain string
+	// Type defines whether the domain is allowed or denied
+	Type DomainType
+	// Kind defines how the domain is matched
+	Kind DomainKind
+	// Comment describes the domain
+	Comment string
+	// Groups are the ids of the groups the domain applies to
+	Groups []int
+	// Enabled is false if the domain is not used
+	Enabled bool
+}
+
+// DomainClient manages the allowed and denied domains of a Pi-hole, only Pi-hole v6 supports it
+type DomainClient interface {
+	// GetDomains returns all allowed and denied domains
+	GetDomains(ctx context.Context) ([]Domain, error)
+	// CreateDomain adds a domain and returns it as stored by the Pi-hole
+	CreateDomain(ctx context.Context, domain Domain) (Domain, error)
+	// UpdateDomain changes the comment, groups and state of an existing domain
+	UpdateDomain(ctx context.Context, domain Domain) (Domain, error)
+	// DeleteDomain removes a domain
+	DeleteDomain(ctx context.Context, domain string, domainType DomainType, kind DomainKind) error
+}
+
+var _ DomainClient = &PiHole{}
+
+// domainJSON is a domain as represented by the Pi-hole API
+type domainJSON struct {
+	ID      int        `json:"id,omitempty"`
+	Domain  string     `json:"domain"`
+	Type    DomainType `json:"type,omitempty"`
+	Kind    DomainKind `json:"kind,omitempty"`
+	Comment *string    `json:"comment"`
+	Groups  []int      `json:"groups"`
+	Enabled bool       `json:"enabled"`
+}
+
+func (d domainJSON) domain() Domain {
+	domain := Domain{
+		ID:      d.ID,
+		Domain:  d.Domain,
+		Type:    d.Type,
+		Kind:    d.Kind,
+		Groups:  d.Groups,
+		Enabled: d.Enabled,
+	}
+	if d.Comment != nil {
+		domain.Comment = *d.Comment
+	}
+
+	return domain
+}
+
+func (p *PiHole) GetDomains(ctx context.Context) ([]Domain, error) {
+	var response struct {
+		Domains []domainJSON `json:"domains"`
+	}
+	if err := p.getJSON(ctx, apiPath("domains"), &response); err != nil {
+		return nil, fmt.Errorf("failed to get domains: %w", err)
+	}
+
+	domains := make([]Domain, 0, len(response.Domains))
+	for _, domain := range response.Domains {
+		domains = append(domains, domain.domain())
+	}
+
+	return domains, nil
+}
+
+func (p *PiHole) CreateDomain(ctx context.Context, domain Domain) (Domain, error) {
+	if err := ValidateDomain(domain.Domain, domain.Kind); err != nil {
+		return Domain{}, err
+	}
+
+	body := domainJSON{
+		Domain:  domain.Domain,
+		Comment: &domain.Comment,
+		Groups:  domain.Groups,
+		Enabled: domain.Enabled,
+	}
+
+	path := apiPath("domains", string(domain.Type), string(domain.Kind))
+	created, err := p.writeDomain(ctx, http.MethodPost, path, body, http.StatusCreated)
+	if err != nil {
+		return Domain{}, fmt.Errorf("failed to create %s %s domain %q: %w", domain.Kind, domain.Type, domain.Domain, err)
+	}
+
+	return created, nil
+}
+
+func (p *PiHole) UpdateDomain(ctx context.Context, domain Domain) (Domain, error) {
+	if err := ValidateDomain(domain.Domain, domain.Kind); err != nil {
+		return Domain{}, err
+	}
+
+	body := domainJSON{
+		Domain:  domain.Domain,
+		Type:    domain.Type,
+		Kind:    domain.Kind,
+		Comment: &domain.Comment,
+		Groups:  domain.Groups,
+		Enabled: domain.Enabled,
+	}
+
+	path := apiPath("domains", string(domain.Type), string(domain.Kind), domain.Domain)
+	updated, err := p.writeDomain(ctx, http.MethodPut, path, body, http.StatusOK)
+	if err != nil {
+		return Domain{}, fmt.Errorf("failed to update %s %s domain %q: %w", domain.Kind, domain.Type, domain.Domain, err)
+	}
+
+	return updated, nil
+}
+
+func (p *PiHole) DeleteDomain(ctx context.Context, domain string, domainType DomainType, kind DomainKind) error {
+	resp, err := p.doAuthenticatedRequest(ctx, http.MethodDelete, apiPath("domains", string(domainType), string(kind), domain), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to delete %s %s domain %q: %w", kind, domainType, domain, newAPIError(resp))
+	}
+
+	return nil
+}
+
+// writeDomain creates or updates a domain and returns it as stored by the Pi-hole
+func (p *PiHole) writeDomain(ctx context.Context, method string, path string, domain domainJSON, expected int) (Domain, error) {
+	var response struct {
+		Domains   []domainJSON `json:"domains"`
+		Processed processed    `json:"processed"`
+	}
+	if err := p.sendJSON(ctx, method, path, domain, expected, &response); err != nil {
+		return Domain{}, err
+	}
+
+	if err := response.Processed.err(); err != nil {
+		return Domain{}, err
+	}
+	if len(response.Domains) == 0 {
+		return Domain{}, fmt.Errorf("the Pi-hole returned no domain")
+	}
+
+	return response.Domains[0].domain(), nil
+}
+
+// exactDomain matches the domains FTL accepts as exact domains, underscores are
+// allowed for service records like _dmarc
+var exactDomain = regexp.MustCompile(`^([a-zA-Z0-9_]([a-zA-Z0-9_-]{0,61}[a-zA-Z0-9_])?\.)*[a-zA-Z0-9_]([a-zA-Z0-9_-]{0,61}[a-zA-Z0-9_])?\.?$`)
+
+// ValidateDomain checks a domain of the given kind before it is sent to the Pi-hole.
+// FTL accepts broken regular expressions and only reports them in its log, so they
+// must never reach it.
+func ValidateDomain(domain string, kind DomainKind) error {
+	switch kind {
+	case DomainKindExact:
+		if len(domain) > 253 || !exactDomain.MatchString(domain) {
+			return fmt.Errorf("%w: %q is not a valid domain", ErrInvalid, domain)
+		}
+	case DomainKindRegex:
+		if err := ValidateRegex(domain); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown domain kind %q", ErrInvalid, kind)
+	}
+
+	return nil
+}
+
+// regexQueryTypes are the query types a regex can be limited to with ;querytype=
+var regexQueryTypes = map[string]bool{
+	"ANY": true, "A": true, "AAAA": true, "CNAME": true, "SRV": true, "SOA": true, "PTR": true, "TXT": true,
+	"NAPTR": true, "MX": true, "DS": true, "RRSIG": true, "DNSKEY": true, "NS": true, "SVCB": true, "HTTPS": true,
+	"OTHER": true,
+}
+
+// ValidateRegex checks a regular expression as accepted by FTL. The expression is
+// parsed as POSIX extended regular expression with the Perl character classes FTL
+// supports, followed by the Pi-hole specific options ;querytype=, ;reply= and ;invert.
+func ValidateRegex(expression string) error {
+	pattern, options, _ := strings.Cut(expression, ";")
+	if pattern == "" {
+		return fmt.Errorf("%w: regex %q is empty", ErrInvalid, expression)
+	}
+
+	if _, err := syntax.Parse(pattern, syntax.PerlX); err != nil {
+		return fmt.Errorf("%w: regex %q: %w", ErrInvalid, expression, err)
+	}
+
+	if options == "" {
+		return nil
+	}
+
+	for _, option := range strings.Split(options, ";") {
+		if err := validateRegexOption(option); err != nil {
+			return fmt.Errorf("%w: regex %q: %w", ErrInvalid, expression, err)
+		}
+	}
+
+	return nil
+}
+
+func validateRegexOption(option string) error {
+	name, value, _ := strings.Cut(option, "=")
+
+	switch name {
+	case "invert":
+		if value != "" {
+			return fmt.Errorf("option invert takes no value")
+		}
+	case "querytype":
+		for _, queryType := range strings.Split(value, ",") {
+			if !regexQueryTypes[strings.ToUpper(strings.TrimPrefix(queryType, "!"))] {
+				return fmt.Errorf("unknown query type %q", queryType)
+			}
+		}
+	case "reply":
+		switch strings.ToLower(value) {
+		case "nodata", "nxdomain", "refused", "ip":
+		default:
+			if net.ParseIP(value) == nil {
+				return fmt.Errorf("invalid reply %q", value)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown option %q", name)
+	}
+
+	return nil
+}
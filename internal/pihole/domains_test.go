@@ -0,0 +1,159 @@
+package pihole
+
+import (
+	"context"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Pi-Hole Domains", func() {
+	const password = "secret"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var piHole *PiHole
+
+	comment := func(c string) *string {
+		return &c
+	}
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		piHole = NewPiHole(server.URL+"/api", password)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list domains", func() {
+		sim.SetDomains(
+			simulator.Domain{Domain: "ads.example.com", Type: "deny", Kind: "exact", Comment: comment("ads"), Groups: []int{0}, Enabled: true},
+			simulator.Domain{Domain: `(\.|^)tracker\.com$`, Type: "deny", Kind: "regex", Groups: []int{0, 1}},
+		)
+
+		domains, err := piHole.GetDomains(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(domains).To(HaveLen(2))
+		Expect(domains[0].Type).To(Equal(DomainTypeDeny))
+		Expect(domains[0].Kind).To(Equal(DomainKindExact))
+		Expect(domains[0].Comment).To(Equal("ads"))
+		Expect(domains[0].Enabled).To(BeTrue())
+		Expect(domains[1].Kind).To(Equal(DomainKindRegex))
+		Expect(domains[1].Comment).To(BeEmpty())
+		Expect(domains[1].Groups).To(Equal([]int{0, 1}))
+	})
+
+	DescribeTable("creating domains",
+		func(domain Domain) {
+			created, err := piHole.CreateDomain(ctx, domain)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.ID).NotTo(BeZero())
+
+			stored := sim.Domains()
+			Expect(stored).To(HaveLen(1))
+			Expect(stored[0].Domain).To(Equal(domain.Domain))
+			Expect(stored[0].Type).To(Equal(string(domain.Type)))
+			Expect(stored[0].Kind).To(Equal(string(domain.Kind)))
+			Expect(stored[0].Enabled).To(Equal(domain.Enabled))
+		},
+		Entry("exact allow", Domain{Domain: "example.com", Type: DomainTypeAllow, Kind: DomainKindExact, Groups: []int{0}, Enabled: true}),
+		Entry("exact deny", Domain{Domain: "ads.example.com", Type: DomainTypeDeny, Kind: DomainKindExact, Groups: []int{0}, Enabled: true}),
+		Entry("regex allow", Domain{Domain: `^cdn[0-9]+\.example\.com$`, Type: DomainTypeAllow, Kind: DomainKindRegex, Groups: []int{0}}),
+		Entry("regex with a slash and a query", Domain{Domain: `^(ads|track)/?\.example\.com$;querytype=A`, Type: DomainTypeDeny, Kind: DomainKindRegex, Groups: []int{0}, Enabled: true}),
+	)
+
+	It("should not send an invalid regex to the Pi-hole", func() {
+		requests := sim.Requests()
+
+		_, err := piHole.CreateDomain(ctx, Domain{Domain: `(ads`, Type: DomainTypeDeny, Kind: DomainKindRegex})
+		Expect(err).To(MatchError(ErrInvalid))
+		Expect(sim.Requests()).To(Equal(requests))
+	})
+
+	It("should report an existing domain as a conflict", func() {
+		sim.SetDomains(simulator.Domain{Domain: "example.com", Type: "allow", Kind: "exact", Groups: []int{0}})
+
+		_, err := piHole.CreateDomain(ctx, Domain{Domain: "example.com", Type: DomainTypeAllow, Kind: DomainKindExact})
+		Expect(err).To(MatchError(ErrConflict))
+	})
+
+	It("should update domains", func() {
+		regex := `(\.|^)example\.com$`
+		sim.SetDomains(simulator.Domain{Domain: regex, Type: "deny", Kind: "regex", Groups: []int{0}, Enabled: true})
+
+		updated, err := piHole.UpdateDomain(ctx, Domain{Domain: regex, Type: DomainTypeDeny, Kind: DomainKindRegex, Comment: "updated", Groups: []int{1}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Comment).To(Equal("updated"))
+
+		stored := sim.Domains()
+		Expect(stored[0].Groups).To(Equal([]int{1}))
+		Expect(stored[0].Enabled).To(BeFalse())
+	})
+
+	It("should report updating a missing domain as not found", func() {
+		_, err := piHole.UpdateDomain(ctx, Domain{Domain: "example.com", Type: DomainTypeDeny, Kind: DomainKindExact})
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should delete domains by type and kind", func() {
+		sim.SetDomains(
+			simulator.Domain{Domain: "example.com", Type: "allow", Kind: "exact", Groups: []int{0}},
+			simulator.Domain{Domain: "example.com", Type: "deny", Kind: "exact", Groups: []int{0}},
+		)
+
+		Expect(piHole.DeleteDomain(ctx, "example.com", DomainTypeDeny, DomainKindExact)).To(Succeed())
+		Expect(sim.Domains()).To(ConsistOf(HaveField("Type", "allow")))
+	})
+
+	It("should report deleting a missing domain as not found", func() {
+		err := piHole.DeleteDomain(ctx, `^ads?\.`, DomainTypeDeny, DomainKindRegex)
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should not support domains on Pi-hole v5", func() {
+		server, _ := simulator.NewLegacyServer("token")
+		defer server.Close()
+
+		client := NewClient(Config{URL: server.URL + "/admin/api.php", AppPassword: "token"})
+
+		_, err := client.(DomainClient).GetDomains(ctx)
+		Expect(err).To(MatchError(ErrUnsupported))
+	})
+})
+
+var _ = DescribeTable("validating domains",
+	func(domain string, kind DomainKind, valid bool) {
+		err := ValidateDomain(domain, kind)
+		if valid {
+			Expect(err).NotTo(HaveOccurred())
+		} else {
+			Expect(err).To(MatchError(ErrInvalid))
+		}
+	},
+	Entry("exact domain", "ads.example.com", DomainKindExact, true),
+	Entry("exact domain with an underscore", "_dmarc.example.com", DomainKindExact, true),
+	Entry("exact domain with a trailing dot", "example.com.", DomainKindExact, true),
+	Entry("exact domain with a space", "ads example.com", DomainKindExact, false),
+	Entry("exact domain with a wildcard", "*.example.com", DomainKindExact, false),
+	Entry("empty exact domain", "", DomainKindExact, false),
+	Entry("regex", `(\.|^)example\.com$`, DomainKindRegex, true),
+	Entry("regex with Perl classes", `^ad\d+\.\w+\.com$`, DomainKindRegex, true),
+	Entry("regex with query types", `^ads\.;querytype=A,!AAAA`, DomainKindRegex, true),
+	Entry("regex with a reply", `^ads\.;reply=nxdomain`, DomainKindRegex, true),
+	Entry("regex with a reply address", `^ads\.;reply=192.168.178.1;invert`, DomainKindRegex, true),
+	Entry("regex with an unbalanced parenthesis", `(ads\.example\.com`, DomainKindRegex, false),
+	Entry("regex with a dangling repetition", `*ads`, DomainKindRegex, false),
+	Entry("regex with an invalid range", `[z-a]`, DomainKindRegex, false),
+	Entry("regex with an unknown query type", `^ads\.;querytype=BOGUS`, DomainKindRegex, false),
+	Entry("regex with an unknown option", `^ads\.;block`, DomainKindRegex, false),
+	Entry("regex with an invalid reply", `^ads\.;reply=maybe`, DomainKindRegex, false),
+	Entry("empty regex", `;invert`, DomainKindRegex, false),
+	Entry("unknown kind", "example.com", DomainKind("wildcard"), false),
+)
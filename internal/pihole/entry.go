@@ -0,0 +1,97 @@
+package pihole
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/domnikl/pihole-operator/api/v1alpha1"
+)
+
+// ParseError describes an entry of dns.hosts or dns.cnameRecords that can't be read
+// as a DNS record, e.g. because it has been edited by hand. Such entries are skipped
+// when the records are listed.
+type ParseError struct {
+	// List is the list of the Pi-hole config the entry has been read from
+	List string
+	// Entry is the malformed entry
+	Entry string
+	// Err describes what is wrong with the entry
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("malformed entry %q in dns.%s: %v", e.Entry, e.List, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// configEntry returns the list of the Pi-hole config a record is stored in and its entry
+func configEntry(record DNSRecord) (string, string, error) {
+	switch record.Type {
+	case v1alpha1.A, v1alpha1.AAAA:
+		return "hosts", fmt.Sprintf("%s %s", record.Target, record.Domain), nil
+	case v1alpha1.CName:
+		if record.TTL != nil {
+			return "cnameRecords", fmt.Sprintf("%s,%s,%d", record.Domain, record.Target, *record.TTL), nil
+		}
+
+		return "cnameRecords", fmt.Sprintf("%s,%s", record.Domain, record.Target), nil
+	}
+
+	return "", "", fmt.Errorf("invalid DNS record type %s", record.Type)
+}
+
+// parseHost reads an entry of dns.hosts in the format "IP domain", further
+// names of the same address are ignored
+func parseHost(entry string) (DNSRecord, error) {
+	fields := strings.Fields(entry)
+	if len(fields) < 2 {
+		return DNSRecord{}, &ParseError{List: "hosts", Entry: entry, Err: errors.New("expected an address and a domain")}
+	}
+
+	if net.ParseIP(fields[0]) == nil {
+		return DNSRecord{}, &ParseError{List: "hosts", Entry: entry, Err: fmt.Errorf("invalid address %q", fields[0])}
+	}
+
+	return DNSRecord{
+		Target: fields[0],
+		Domain: fields[1],
+		Type:   addressType(fields[0]),
+	}, nil
+}
+
+// parseCNAMERecord reads an entry of dns.cnameRecords in the format "domain,target[,ttl]"
+func parseCNAMERecord(entry string) (DNSRecord, error) {
+	parts := strings.Split(entry, ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return DNSRecord{}, &ParseError{List: "cnameRecords", Entry: entry, Err: errors.New("expected a domain, a target and an optional TTL")}
+	}
+
+	if parts[0] == "" || parts[1] == "" {
+		return DNSRecord{}, &ParseError{List: "cnameRecords", Entry: entry, Err: errors.New("domain and target must not be empty")}
+	}
+
+	record := DNSRecord{
+		Domain: parts[0],
+		Target: parts[1],
+		Type:   v1alpha1.CName,
+	}
+
+	// CNAME records have an optional TTL
+	if len(parts) == 3 {
+		ttl, err := strconv.ParseInt(parts[2], 10, 32)
+		if err != nil {
+			return DNSRecord{}, &ParseError{List: "cnameRecords", Entry: entry, Err: err}
+		}
+
+		t := int32(ttl)
+		record.TTL = &t
+	}
+
+	return record, nil
+}
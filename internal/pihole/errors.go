@@ -0,0 +1,129 @@
+package pihole
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized is returned when the Pi-hole rejects the configured credentials
+	ErrUnauthorized = errors.New("authentication failed")
+	// ErrNotFound is returned when an item to change doesn't exist on the Pi-hole
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when an item to create already exists on the Pi-hole
+	ErrConflict = errors.New("already exists")
+	// ErrInvalid is returned when the Pi-hole rejects a request as invalid, repeating
+	// it won't change that
+	ErrInvalid = errors.New("invalid request")
+	// ErrUnsupported is returned when the Pi-hole doesn't support a feature, e.g.
+	// everything but local DNS records on Pi-hole v5
+	ErrUnsupported = errors.New("not supported by the Pi-hole")
+	// ErrUnavailable is returned when the Pi-hole can't serve requests for the moment,
+	// e.g. while FTL restarts or all API seats are taken
+	ErrUnavailable = errors.New("pi-hole unavailable")
+)
+
+// ErrTOTPRequired is returned when the Pi-hole has two-factor authentication enabled
+// but no TOTP secret is configured
+var ErrTOTPRequired = fmt.Errorf("%w: the Pi-hole requires a TOTP code but no TOTP secret is configured", ErrUnauthorized)
+
+// APIError is an error response of the Pi-hole API. It unwraps to one of the
+// errors above, so callers can check it with errors.Is.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// Key identifies the kind of error, e.g. bad_request
+	Key string
+	// Message describes the error
+	Message string
+	// Hint is additional information on the error, e.g. the invalid value
+	Hint string
+}
+
+func (e *APIError) Error() string {
+	message := fmt.Sprintf("status code %d", e.StatusCode)
+	if e.Message != "" {
+		message = fmt.Sprintf("%s: %s", message, e.Message)
+	}
+	if e.Hint != "" {
+		message = fmt.Sprintf("%s (%s)", message, e.Hint)
+	}
+
+	return message
+}
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusBadRequest && strings.Contains(e.Message, "already present"):
+		// FTL rejects duplicate items as bad requests
+		return ErrConflict
+	case e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError:
+		return ErrUnavailable
+	case e.StatusCode >= http.StatusBadRequest:
+		return ErrInvalid
+	}
+
+	return nil
+}
+
+// newAPIError reads the error from the body of a failed response, the body is
+// optional as proxies in front of the Pi-hole answer with their own errors
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var response struct {
+		Error struct {
+			Key     string  `json:"key"`
+			Message string  `json:"message"`
+			Hint    *string `json:"hint"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err == nil {
+		apiErr.Key = response.Error.Key
+		apiErr.Message = response.Error.Message
+		if response.Error.Hint != nil {
+			apiErr.Hint = *response.Error.Hint
+		}
+	}
+
+	return apiErr
+}
+
+// IsRetryable returns true if a failed request may succeed when it is repeated,
+// e.g. because the Pi-hole was unreachable or restarting. Rejected credentials,
+// invalid requests and the state of the Pi-hole differing from the expected one
+// are not retryable, as repeating the same request fails the same way.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var parseErr *ParseError
+	switch {
+	case errors.Is(err, ErrUnavailable):
+		return true
+	case errors.Is(err, ErrUnauthorized), errors.Is(err, ErrInvalid),
+		errors.Is(err, ErrNotFound), errors.Is(err, ErrConflict),
+		errors.As(err, &parseErr), errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		// timed out or the connection was closed while FTL restarted
+		return true
+	}
+
+	var netErr net.Error
+
+	return errors.As(err, &netErr)
+}
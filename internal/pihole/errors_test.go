@@ -0,0 +1,60 @@
+package pihole
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errors", func() {
+	DescribeTable("classifying error responses",
+		func(apiErr *APIError, expected error) {
+			if expected == nil {
+				Expect(apiErr.Unwrap()).To(BeNil())
+				return
+			}
+
+			Expect(apiErr).To(MatchError(expected))
+		},
+		Entry("unauthorized", &APIError{StatusCode: http.StatusUnauthorized}, ErrUnauthorized),
+		Entry("not found", &APIError{StatusCode: http.StatusNotFound, Message: "Item not found"}, ErrNotFound),
+		Entry("duplicate item", &APIError{StatusCode: http.StatusBadRequest, Message: "Item already present"}, ErrConflict),
+		Entry("invalid value", &APIError{StatusCode: http.StatusBadRequest, Message: "Invalid value"}, ErrInvalid),
+		Entry("API seats exceeded", &APIError{StatusCode: http.StatusTooManyRequests}, ErrUnavailable),
+		Entry("FTL restarting", &APIError{StatusCode: http.StatusServiceUnavailable}, ErrUnavailable),
+		Entry("bad gateway", &APIError{StatusCode: http.StatusBadGateway}, ErrUnavailable),
+		Entry("success", &APIError{StatusCode: http.StatusOK}, nil),
+	)
+
+	It("should describe the error of the Pi-hole", func() {
+		err := &APIError{StatusCode: http.StatusBadRequest, Key: "bad_request", Message: "Invalid value", Hint: "foo.com"}
+		Expect(err.Error()).To(Equal("status code 400: Invalid value (foo.com)"))
+	})
+
+	DescribeTable("deciding whether to retry",
+		func(err error, retryable bool) {
+			Expect(IsRetryable(err)).To(Equal(retryable))
+		},
+		Entry("no error", nil, false),
+		Entry("Pi-hole unavailable", fmt.Errorf("failed to get DNS records: %w", &APIError{StatusCode: http.StatusServiceUnavailable}), true),
+		Entry("connection refused",
+			&url.Error{Op: "Get", URL: "http://pi.hole/api/auth", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}, true),
+		Entry("connection closed", &url.Error{Op: "Get", URL: "http://pi.hole/api/auth", Err: io.EOF}, true),
+		Entry("timeout", context.DeadlineExceeded, true),
+		Entry("cancelled", context.Canceled, false),
+		Entry("wrong password", ErrTOTPRequired, false),
+		Entry("invalid value", &APIError{StatusCode: http.StatusBadRequest}, false),
+		Entry("not found", &APIError{StatusCode: http.StatusNotFound}, false),
+		Entry("conflict", fmt.Errorf("failed to replace DNS record: %w", ErrConflict), false),
+		Entry("malformed entry", &ParseError{List: "hosts", Entry: "foo", Err: errors.New("expected an address and a domain")}, false),
+		Entry("anything else", errors.New("something went wrong"), false),
+	)
+})
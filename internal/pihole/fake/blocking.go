@@ -0,0 +1,79 @@
+package fake
+
+import (
+	"context"
+	"time"
+
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const (
+	GetBlocking Operation = "GetBlocking"
+	SetBlocking Operation = "SetBlocking"
+)
+
+var _ pihole.BlockingClient = &PiHole{}
+
+// Blocking returns the current blocking state, a timer that has passed is applied first.
+func (p *PiHole) Blocking() pihole.Blocking {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.blockingState()
+}
+
+// SetBlockingState changes the blocking state without counting a call, as it happens in the Pi-hole UI.
+func (p *PiHole) SetBlockingState(enabled bool, timer time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.setBlocking(enabled, timer)
+}
+
+func (p *PiHole) GetBlocking(ctx context.Context) (pihole.Blocking, error) {
+	if err := p.begin(ctx, GetBlocking); err != nil {
+		return pihole.Blocking{}, err
+	}
+
+	return p.Blocking(), nil
+}
+
+func (p *PiHole) SetBlocking(ctx context.Context, enabled bool, timer time.Duration) (pihole.Blocking, error) {
+	if err := p.begin(ctx, SetBlocking); err != nil {
+		return pihole.Blocking{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.setBlocking(enabled, timer)
+
+	return p.blockingState(), nil
+}
+
+// setBlocking changes the blocking state, p.mu must be held
+func (p *PiHole) setBlocking(enabled bool, timer time.Duration) {
+	p.blocking = enabled
+	p.blockingEnd = time.Time{}
+	if timer > 0 {
+		p.blockingEnd = time.Now().Add(timer)
+	}
+}
+
+// blockingState reverts the blocking state if its timer has passed and returns it, p.mu must be held
+func (p *PiHole) blockingState() pihole.Blocking {
+	if !p.blockingEnd.IsZero() && !time.Now().Before(p.blockingEnd) {
+		p.blocking = !p.blocking
+		p.blockingEnd = time.Time{}
+	}
+
+	blocking := pihole.Blocking{State: pihole.BlockingDisabled}
+	if p.blocking {
+		blocking.State = pihole.BlockingEnabled
+	}
+	if !p.blockingEnd.IsZero() {
+		blocking.Timer = time.Until(p.blockingEnd)
+	}
+
+	return blocking
+}
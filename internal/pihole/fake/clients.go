@@ -0,0 +1,111 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const (
+	GetClients   Operation = "GetClients"
+	CreateClient Operation = "CreateClient"
+	UpdateClient Operation = "UpdateClient"
+	DeleteClient Operation = "DeleteClient"
+)
+
+var _ pihole.ClientClient = &PiHole{}
+
+// Clients returns a copy of all clients currently stored.
+func (p *PiHole) Clients() []pihole.PiHoleClient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.clients)
+}
+
+// SetClients replaces all clients, ids are assigned to clients without one.
+func (p *PiHole) SetClients(clients ...pihole.PiHoleClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.clients = nil
+	for _, client := range clients {
+		if client.ID == 0 {
+			client.ID = p.nextID()
+		}
+		p.clients = append(p.clients, client)
+	}
+}
+
+func (p *PiHole) GetClients(ctx context.Context) ([]pihole.PiHoleClient, error) {
+	if err := p.begin(ctx, GetClients); err != nil {
+		return nil, err
+	}
+
+	return p.Clients(), nil
+}
+
+func (p *PiHole) CreateClient(ctx context.Context, client pihole.PiHoleClient) (pihole.PiHoleClient, error) {
+	if err := p.begin(ctx, CreateClient); err != nil {
+		return pihole.PiHoleClient{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.indexOfClient(client.Client) >= 0 {
+		return pihole.PiHoleClient{}, fmt.Errorf("failed to create client %s: %w", client.Client, pihole.ErrConflict)
+	}
+
+	client.ID = p.nextID()
+	client.Groups = slices.Clone(client.Groups)
+	p.clients = append(p.clients, client)
+
+	return client, nil
+}
+
+func (p *PiHole) UpdateClient(ctx context.Context, client pihole.PiHoleClient) (pihole.PiHoleClient, error) {
+	if err := p.begin(ctx, UpdateClient); err != nil {
+		return pihole.PiHoleClient{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.indexOfClient(client.Client)
+	if i < 0 {
+		return pihole.PiHoleClient{}, fmt.Errorf("failed to update client %s: %w", client.Client, pihole.ErrNotFound)
+	}
+
+	stored := &p.clients[i]
+	stored.Comment = client.Comment
+	stored.Groups = slices.Clone(client.Groups)
+
+	return *stored, nil
+}
+
+func (p *PiHole) DeleteClient(ctx context.Context, client string) error {
+	if err := p.begin(ctx, DeleteClient); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.indexOfClient(client)
+	if i < 0 {
+		return fmt.Errorf("failed to delete client %s: %w", client, pihole.ErrNotFound)
+	}
+
+	p.clients = slices.Delete(p.clients, i, i+1)
+
+	return nil
+}
+
+func (p *PiHole) indexOfClient(client string) int {
+	return slices.IndexFunc(p.clients, func(c pihole.PiHoleClient) bool {
+		return c.Client == client
+	})
+}
@@ -0,0 +1,112 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const (
+	GetDomains   Operation = "GetDomains"
+	CreateDomain Operation = "CreateDomain"
+	UpdateDomain Operation = "UpdateDomain"
+	DeleteDomain Operation = "DeleteDomain"
+)
+
+var _ pihole.DomainClient = &PiHole{}
+
+// Domains returns a copy of all allowed and denied domains currently stored.
+func (p *PiHole) Domains() []pihole.Domain {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.domains)
+}
+
+// SetDomains replaces all allowed and denied domains, ids are assigned to domains without one.
+func (p *PiHole) SetDomains(domains ...pihole.Domain) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.domains = nil
+	for _, domain := range domains {
+		if domain.ID == 0 {
+			domain.ID = p.nextID()
+		}
+		p.domains = append(p.domains, domain)
+	}
+}
+
+func (p *PiHole) GetDomains(ctx context.Context) ([]pihole.Domain, error) {
+	if err := p.begin(ctx, GetDomains); err != nil {
+		return nil, err
+	}
+
+	return p.Domains(), nil
+}
+
+func (p *PiHole) CreateDomain(ctx context.Context, domain pihole.Domain) (pihole.Domain, error) {
+	if err := p.begin(ctx, CreateDomain); err != nil {
+		return pihole.Domain{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.indexOfDomain(domain.Domain, domain.Type, domain.Kind) >= 0 {
+		return pihole.Domain{}, fmt.Errorf("failed to create domain %q: %w", domain.Domain, pihole.ErrConflict)
+	}
+
+	domain.ID = p.nextID()
+	domain.Groups = slices.Clone(domain.Groups)
+	p.domains = append(p.domains, domain)
+
+	return domain, nil
+}
+
+func (p *PiHole) UpdateDomain(ctx context.Context, domain pihole.Domain) (pihole.Domain, error) {
+	if err := p.begin(ctx, UpdateDomain); err != nil {
+		return pihole.Domain{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.indexOfDomain(domain.Domain, domain.Type, domain.Kind)
+	if i < 0 {
+		return pihole.Domain{}, fmt.Errorf("failed to update domain %q: %w", domain.Domain, pihole.ErrNotFound)
+	}
+
+	stored := &p.domains[i]
+	stored.Comment = domain.Comment
+	stored.Groups = slices.Clone(domain.Groups)
+	stored.Enabled = domain.Enabled
+
+	return *stored, nil
+}
+
+func (p *PiHole) DeleteDomain(ctx context.Context, domain string, domainType pihole.DomainType, kind pihole.DomainKind) error {
+	if err := p.begin(ctx, DeleteDomain); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.indexOfDomain(domain, domainType, kind)
+	if i < 0 {
+		return fmt.Errorf("failed to delete domain %q: %w", domain, pihole.ErrNotFound)
+	}
+
+	p.domains = slices.Delete(p.domains, i, i+1)
+
+	return nil
+}
+
+func (p *PiHole) indexOfDomain(domain string, domainType pihole.DomainType, kind pihole.DomainKind) int {
+	return slices.IndexFunc(p.domains, func(d pihole.Domain) bool {
+		return d.Domain == domain && d.Type == domainType && d.Kind == kind
+	})
+}
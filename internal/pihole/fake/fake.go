@@ -0,0 +1,225 @@
+// Package fake provides an in-memory implementation of pihole.Client and the
+// feature interfaces of Pi-hole v6 for tests.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+// Operation identifies a method of pihole.Client
+type Operation string
+
+const (
+	GetDNSRecords   Operation = "GetDNSRecords"
+	CreateDNSRecord Operation = "CreateDNSRecord"
+	DeleteDNSRecord Operation = "DeleteDNSRecord"
+	// ReplaceDNSRecords replaces records atomically
+	ReplaceDNSRecords Operation = "ReplaceDNSRecords"
+	Close             Operation = "Close"
+)
+
+// PiHole is a thread-safe in-memory Pi-hole. Errors and latency can be injected
+// per operation to simulate a misbehaving instance.
+type PiHole struct {
+	mu      sync.Mutex
+	records []pihole.DNSRecord
+	lists   []pihole.List
+	groups  []pihole.Group
+	domains []pihole.Domain
+	clients []pihole.PiHoleClient
+	gravity []string
+	// blocking is the blocking state, it is reverted at blockingEnd unless that is zero
+	blocking    bool
+	blockingEnd time.Time
+	lastID      int
+	errors      map[Operation]error
+	latency     map[Operation]time.Duration
+	calls       map[Operation]int
+	closed      bool
+}
+
+var _ pihole.Client = &PiHole{}
+
+// NewPiHole returns a fake Pi-hole containing the given records.
+func NewPiHole(records ...pihole.DNSRecord) *PiHole {
+	return &PiHole{
+		records:  append([]pihole.DNSRecord{}, records...),
+		groups:   []pihole.Group{{ID: pihole.DefaultGroupID, Name: "Default", Enabled: true}},
+		blocking: true,
+		errors:   map[Operation]error{},
+		latency:  map[Operation]time.Duration{},
+		calls:    map[Operation]int{},
+	}
+}
+
+// SetError makes every call of op fail with err until it is reset with a nil error.
+func (p *PiHole) SetError(op Operation, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		delete(p.errors, op)
+		return
+	}
+
+	p.errors[op] = err
+}
+
+// SetLatency delays every call of op by d, calls are aborted when their context is done.
+func (p *PiHole) SetLatency(op Operation, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.latency[op] = d
+}
+
+// Records returns a copy of all records currently stored.
+func (p *PiHole) Records() []pihole.DNSRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]pihole.DNSRecord{}, p.records...)
+}
+
+// Calls returns how often op has been called.
+func (p *PiHole) Calls(op Operation) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.calls[op]
+}
+
+// Closed returns true if Close has been called successfully.
+func (p *PiHole) Closed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.closed
+}
+
+func (p *PiHole) GetDNSRecords(ctx context.Context) ([]pihole.DNSRecord, error) {
+	if err := p.begin(ctx, GetDNSRecords); err != nil {
+		return nil, err
+	}
+
+	return p.Records(), nil
+}
+
+func (p *PiHole) CreateDNSRecord(ctx context.Context, record pihole.DNSRecord) error {
+	if err := p.begin(ctx, CreateDNSRecord); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.indexOf(record) >= 0 {
+		return fmt.Errorf("failed to create DNS record %s %s: %w", record.Domain, record.Target, pihole.ErrConflict)
+	}
+
+	p.records = append(p.records, record)
+
+	return nil
+}
+
+func (p *PiHole) DeleteDNSRecord(ctx context.Context, record pihole.DNSRecord) error {
+	if err := p.begin(ctx, DeleteDNSRecord); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.indexOf(record)
+	if i < 0 {
+		return fmt.Errorf("failed to delete DNS record %s %s: %w", record.Domain, record.Target, pihole.ErrNotFound)
+	}
+
+	p.records = append(p.records[:i], p.records[i+1:]...)
+
+	return nil
+}
+
+func (p *PiHole) ReplaceDNSRecords(ctx context.Context, oldRecords []pihole.DNSRecord, newRecords []pihole.DNSRecord) error {
+	if err := p.begin(ctx, ReplaceDNSRecords); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	records := append([]pihole.DNSRecord{}, p.records...)
+	for _, record := range oldRecords {
+		i := indexOf(records, record)
+		if i < 0 {
+			return fmt.Errorf("failed to replace DNS record %s %s: %w", record.Domain, record.Target, pihole.ErrNotFound)
+		}
+
+		records = append(records[:i], records[i+1:]...)
+	}
+
+	for _, record := range newRecords {
+		if indexOf(records, record) >= 0 {
+			return fmt.Errorf("failed to replace DNS record %s %s: %w", record.Domain, record.Target, pihole.ErrConflict)
+		}
+
+		records = append(records, record)
+	}
+
+	// all records are replaced at once, or none of them
+	p.records = records
+
+	return nil
+}
+
+func (p *PiHole) Close(ctx context.Context) error {
+	if err := p.begin(ctx, Close); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+
+	return nil
+}
+
+// begin counts the call, applies the configured latency and returns the injected error.
+// A call is aborted when ctx is done before the latency passed.
+func (p *PiHole) begin(ctx context.Context, op Operation) error {
+	p.mu.Lock()
+	p.calls[op]++
+	latency := p.latency[op]
+	err := p.errors[op]
+	p.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return err
+}
+
+func (p *PiHole) indexOf(record pihole.DNSRecord) int {
+	return indexOf(p.records, record)
+}
+
+func indexOf(records []pihole.DNSRecord, record pihole.DNSRecord) int {
+	for i := range records {
+		if records[i].Equals(&record) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,46 @@
+package fake
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const UpdateGravity Operation = "UpdateGravity"
+
+var _ pihole.GravityClient = &PiHole{}
+
+// SetGravityOutput sets the lines UpdateGravity reports as its progress.
+func (p *PiHole) SetGravityOutput(lines ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.gravity = slices.Clone(lines)
+}
+
+// UpdateGravity reports the configured output and marks all enabled adlists as updated.
+func (p *PiHole) UpdateGravity(ctx context.Context, progress func(line string)) error {
+	if err := p.begin(ctx, UpdateGravity); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	lines := slices.Clone(p.gravity)
+	now := time.Now()
+	for i := range p.lists {
+		if p.lists[i].Enabled {
+			p.lists[i].DateUpdated = now
+		}
+	}
+	p.mu.Unlock()
+
+	if progress != nil {
+		for _, line := range lines {
+			progress(line)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const (
+	GetGroups   Operation = "GetGroups"
+	CreateGroup Operation = "CreateGroup"
+	UpdateGroup Operation = "UpdateGroup"
+	DeleteGroup Operation = "DeleteGroup"
+)
+
+var _ pihole.GroupClient = &PiHole{}
+
+// Groups returns a copy of all groups currently stored, including the Default group.
+func (p *PiHole) Groups() []pihole.Group {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.groups)
+}
+
+// SetGroups replaces all groups but the Default group, ids are assigned to groups without one.
+func (p *PiHole) SetGroups(groups ...pihole.Group) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.groups = p.groups[:1]
+	for _, group := range groups {
+		if group.ID == 0 {
+			group.ID = p.nextID()
+		}
+		p.groups = append(p.groups, group)
+	}
+}
+
+func (p *PiHole) GetGroups(ctx context.Context) ([]pihole.Group, error) {
+	if err := p.begin(ctx, GetGroups); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.groups), nil
+}
+
+func (p *PiHole) CreateGroup(ctx context.Context, group pihole.Group) (pihole.Group, error) {
+	if err := p.begin(ctx, CreateGroup); err != nil {
+		return pihole.Group{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.indexOfGroup(group.Name) >= 0 {
+		return pihole.Group{}, fmt.Errorf("failed to create group %q: %w", group.Name, pihole.ErrConflict)
+	}
+
+	group.ID = p.nextID()
+	p.groups = append(p.groups, group)
+
+	return group, nil
+}
+
+func (p *PiHole) UpdateGroup(ctx context.Context, group pihole.Group) (pihole.Group, error) {
+	if err := p.begin(ctx, UpdateGroup); err != nil {
+		return pihole.Group{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.indexOfGroup(group.Name)
+	if i < 0 {
+		return pihole.Group{}, fmt.Errorf("failed to update group %q: %w", group.Name, pihole.ErrNotFound)
+	}
+
+	stored := &p.groups[i]
+	stored.Comment = group.Comment
+	stored.Enabled = group.Enabled
+
+	return *stored, nil
+}
+
+// DeleteGroup removes a group, like the Pi-hole it removes the group from all
+// adlists, domains and clients and refuses to delete the Default group
+func (p *PiHole) DeleteGroup(ctx context.Context, name string) error {
+	if err := p.begin(ctx, DeleteGroup); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.indexOfGroup(name)
+	if i < 0 {
+		return fmt.Errorf("failed to delete group %q: %w", name, pihole.ErrNotFound)
+	}
+
+	id := p.groups[i].ID
+	if id == pihole.DefaultGroupID {
+		return fmt.Errorf("failed to delete group %q: %w", name, pihole.ErrInvalid)
+	}
+
+	p.groups = slices.Delete(p.groups, i, i+1)
+	for j := range p.lists {
+		p.lists[j].Groups = withoutGroup(p.lists[j].Groups, id)
+	}
+	for j := range p.domains {
+		p.domains[j].Groups = withoutGroup(p.domains[j].Groups, id)
+	}
+	for j := range p.clients {
+		p.clients[j].Groups = withoutGroup(p.clients[j].Groups, id)
+	}
+
+	return nil
+}
+
+func (p *PiHole) indexOfGroup(name string) int {
+	return slices.IndexFunc(p.groups, func(g pihole.Group) bool {
+		return g.Name == name
+	})
+}
+
+// withoutGroup removes a group id from the groups of an adlist, domain or client
+func withoutGroup(groups []int, id int) []int {
+	return slices.DeleteFunc(slices.Clone(groups), func(g int) bool {
+		return g == id
+	})
+}
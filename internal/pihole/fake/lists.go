@@ -0,0 +1,119 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+const (
+	GetLists   Operation = "GetLists"
+	CreateList Operation = "CreateList"
+	UpdateList Operation = "UpdateList"
+	DeleteList Operation = "DeleteList"
+)
+
+var _ pihole.ListClient = &PiHole{}
+
+// Lists returns a copy of all adlists currently stored.
+func (p *PiHole) Lists() []pihole.List {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.lists)
+}
+
+// SetLists replaces all adlists, ids are assigned to lists without one.
+func (p *PiHole) SetLists(lists ...pihole.List) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lists = nil
+	for _, list := range lists {
+		if list.ID == 0 {
+			list.ID = p.nextID()
+		}
+		p.lists = append(p.lists, list)
+	}
+}
+
+func (p *PiHole) GetLists(ctx context.Context) ([]pihole.List, error) {
+	if err := p.begin(ctx, GetLists); err != nil {
+		return nil, err
+	}
+
+	return p.Lists(), nil
+}
+
+func (p *PiHole) CreateList(ctx context.Context, list pihole.List) (pihole.List, error) {
+	if err := p.begin(ctx, CreateList); err != nil {
+		return pihole.List{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.indexOfList(list.Address, list.Type) >= 0 {
+		return pihole.List{}, fmt.Errorf("failed to create adlist %s: %w", list.Address, pihole.ErrConflict)
+	}
+
+	list.ID = p.nextID()
+	list.Groups = slices.Clone(list.Groups)
+	p.lists = append(p.lists, list)
+
+	return list, nil
+}
+
+func (p *PiHole) UpdateList(ctx context.Context, list pihole.List) (pihole.List, error) {
+	if err := p.begin(ctx, UpdateList); err != nil {
+		return pihole.List{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.indexOfList(list.Address, list.Type)
+	if i < 0 {
+		return pihole.List{}, fmt.Errorf("failed to update adlist %s: %w", list.Address, pihole.ErrNotFound)
+	}
+
+	stored := &p.lists[i]
+	stored.Comment = list.Comment
+	stored.Groups = slices.Clone(list.Groups)
+	stored.Enabled = list.Enabled
+
+	return *stored, nil
+}
+
+func (p *PiHole) DeleteList(ctx context.Context, address string, listType pihole.ListType) error {
+	if err := p.begin(ctx, DeleteList); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := p.indexOfList(address, listType)
+	if i < 0 {
+		return fmt.Errorf("failed to delete adlist %s: %w", address, pihole.ErrNotFound)
+	}
+
+	p.lists = slices.Delete(p.lists, i, i+1)
+
+	return nil
+}
+
+func (p *PiHole) indexOfList(address string, listType pihole.ListType) int {
+	return slices.IndexFunc(p.lists, func(l pihole.List) bool {
+		return l.Address == address && l.Type == listType
+	})
+}
+
+// nextID returns a new id for a list, group, domain or client, p.mu must be held.
+func (p *PiHole) nextID() int {
+	p.lastID++
+
+	return p.lastID
+}
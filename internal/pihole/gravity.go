@@ -0,0 +1,101 @@
+package pihole
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ErrGravityFailed is returned when gravity reports an error in its output, e.g.
+// because an adlist couldn't be downloaded
+var ErrGravityFailed = errors.New("gravity update failed")
+
+// GravityClient updates gravity, the database of blocked domains built from the
+// adlists, only Pi-hole v6 supports it
+type GravityClient interface {
+	// UpdateGravity downloads all adlists and rebuilds gravity. It blocks until the
+	// update is done and passes each line of its output to progress.
+	UpdateGravity(ctx context.Context, progress func(line string)) error
+}
+
+var _ GravityClient = &PiHole{}
+
+// ansiEscape matches the escape sequences pihole -g uses to color and clear lines
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// gravityFailure marks the lines of the output of pihole -g reporting an error
+const gravityFailure = "[✗]"
+
+func (p *PiHole) UpdateGravity(ctx context.Context, progress func(line string)) error {
+	// gravity takes minutes on large lists, the timeout only covers waiting for it to start
+	resp, err := p.doAuthenticatedRequest(withoutTimeout(ctx), http.MethodPost, apiPath("action", "gravity"), nil)
+	if err != nil {
+		return fmt.Errorf("failed to update gravity: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to update gravity: %w", newAPIError(resp))
+	}
+
+	var failures []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Split(scanLines)
+	for scanner.Scan() {
+		line := strings.TrimSpace(ansiEscape.ReplaceAllString(scanner.Text(), ""))
+		if line == "" {
+			continue
+		}
+
+		if strings.Contains(line, gravityFailure) {
+			failures = append(failures, strings.TrimSpace(strings.TrimPrefix(line, gravityFailure)))
+		}
+		if progress != nil {
+			progress(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read the output of gravity: %w", err)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%w: %s", ErrGravityFailed, strings.Join(failures, ", "))
+	}
+
+	return nil
+}
+
+// scanLines splits the output of pihole -g into lines, it ends lines with a
+// carriage return to overwrite them with the progress of a step
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// streamingKey marks requests whose response is streamed, see withoutTimeout
+type streamingKey struct{}
+
+// withoutTimeout returns a context exempting reading the response from PiHole.Timeout,
+// the context itself still limits it
+func withoutTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+// streaming is true if the response of a request is exempt from PiHole.Timeout
+func streaming(ctx context.Context) bool {
+	streaming, _ := ctx.Value(streamingKey{}).(bool)
+
+	return streaming
+}
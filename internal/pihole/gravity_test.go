@@ -0,0 +1,117 @@
+package pihole
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Pi-Hole Gravity", func() {
+	const password = "secret"
+	const address = "https://example.com/hosts.txt"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var piHole *PiHole
+	var output []string
+
+	progress := func(line string) {
+		output = append(output, line)
+	}
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		piHole = NewPiHole(server.URL+"/api", password)
+		output = nil
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should stream the output of gravity without escape sequences", func() {
+		sim.SetLists(simulator.List{Address: address, Type: "block", Groups: []int{0}, Enabled: true})
+
+		Expect(piHole.UpdateGravity(ctx, progress)).To(Succeed())
+		Expect(output).To(Equal([]string{
+			"[i] Neutrino emissions detected...",
+			"[i] Target: " + address,
+			"[i] Status: Pending...",
+			"[✓] Status: Retrieval successful",
+			"[i] Building tree...",
+			"[✓] Building tree",
+			"[✓] Done.",
+		}))
+		Expect(sim.GravityRuns()).To(Equal(1))
+	})
+
+	It("should update the adlists", func() {
+		sim.SetLists(simulator.List{Address: address, Type: "block", Groups: []int{0}, Enabled: true})
+
+		Expect(piHole.UpdateGravity(ctx, nil)).To(Succeed())
+
+		lists, err := piHole.GetLists(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lists[0].DateUpdated).NotTo(BeZero())
+	})
+
+	It("should report the errors of gravity", func() {
+		sim.SetGravityOutput(
+			"  [i] Target: "+address+"\n",
+			"  [\x1b[1;31m✗\x1b[0m] Status: Not found\n",
+			"  [\x1b[1;32m✓\x1b[0m] Done.\n",
+		)
+
+		err := piHole.UpdateGravity(ctx, progress)
+		Expect(err).To(MatchError(ErrGravityFailed))
+		Expect(err).To(MatchError(ContainSubstring("Status: Not found")))
+		Expect(output).To(HaveLen(3))
+	})
+
+	It("should not limit the update to the timeout of a request", func() {
+		sim.GravityDelay = 20 * time.Millisecond
+		piHole.Timeout = 50 * time.Millisecond
+
+		Expect(piHole.UpdateGravity(ctx, progress)).To(Succeed())
+		Expect(output).To(HaveLen(4))
+	})
+
+	It("should abort the update when the context is done", func() {
+		sim.GravityDelay = time.Second
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		Expect(piHole.UpdateGravity(ctx, progress)).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("should not retry an update", func() {
+		// log in first, so the failure hits the update
+		_, err := piHole.GetLists(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		requests := sim.Requests()
+
+		sim.FailNext(http.MethodPost, 1, http.StatusInternalServerError)
+
+		Expect(piHole.UpdateGravity(ctx, progress)).NotTo(Succeed())
+		Expect(sim.Requests()).To(Equal(requests + 1))
+	})
+
+	It("should not support gravity updates on Pi-hole v5", func() {
+		server, _ := simulator.NewLegacyServer("token")
+		defer server.Close()
+
+		client := NewClient(Config{URL: server.URL + "/admin/api.php", AppPassword: "token"})
+
+		err := client.(GravityClient).UpdateGravity(ctx, progress)
+		Expect(err).To(MatchError(ErrUnsupported))
+	})
+})
@@ -0,0 +1,152 @@
+package pihole
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// DefaultGroupID is the id of the Default group every Pi-hole has, it can't be deleted
+const DefaultGroupID = 0
+
+// Group is a group of a Pi-hole, adlists, domains and clients are assigned to groups
+type Group struct {
+	// ID is the id of the group on the Pi-hole, it is assigned when the group is created
+	ID int
+	// Name is the unique name of the group
+	Name string
+	// Comment describes the group
+	Comment string
+	// Enabled is false if the adlists and domains of the group are not used
+	Enabled bool
+}
+
+// GroupClient manages the groups of a Pi-hole, only Pi-hole v6 supports it
+type GroupClient interface {
+	// GetGroups returns all groups
+	GetGroups(ctx context.Context) ([]Group, error)
+	// CreateGroup adds a group and returns it as stored by the Pi-hole
+	CreateGroup(ctx context.Context, group Group) (Group, error)
+	// UpdateGroup changes the comment and state of an existing group
+	UpdateGroup(ctx context.Context, group Group) (Group, error)
+	// DeleteGroup removes a group, the Pi-hole removes it from all adlists, domains
+	// and clients assigned to it
+	DeleteGroup(ctx context.Context, name string) error
+}
+
+var _ GroupClient = &PiHole{}
+
+// groupJSON is a group as represented by the Pi-hole API
+type groupJSON struct {
+	ID      int     `json:"id,omitempty"`
+	Name    string  `json:"name"`
+	Comment *string `json:"comment"`
+	Enabled bool    `json:"enabled"`
+}
+
+func (g groupJSON) group() Group {
+	group := Group{ID: g.ID, Name: g.Name, Enabled: g.Enabled}
+	if g.Comment != nil {
+		group.Comment = *g.Comment
+	}
+
+	return group
+}
+
+func (p *PiHole) GetGroups(ctx context.Context) ([]Group, error) {
+	var response struct {
+		Groups []groupJSON `json:"groups"`
+	}
+	if err := p.getJSON(ctx, apiPath("groups"), &response); err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+
+	groups := make([]Group, 0, len(response.Groups))
+	for _, group := range response.Groups {
+		groups = append(groups, group.group())
+	}
+
+	return groups, nil
+}
+
+func (p *PiHole) CreateGroup(ctx context.Context, group Group) (Group, error) {
+	body := groupJSON{Name: group.Name, Comment: &group.Comment, Enabled: group.Enabled}
+
+	created, err := p.writeGroup(ctx, http.MethodPost, apiPath("groups"), body, http.StatusCreated)
+	if err != nil {
+		return Group{}, fmt.Errorf("failed to create group %q: %w", group.Name, err)
+	}
+
+	return created, nil
+}
+
+func (p *PiHole) UpdateGroup(ctx context.Context, group Group) (Group, error) {
+	body := groupJSON{Name: group.Name, Comment: &group.Comment, Enabled: group.Enabled}
+
+	updated, err := p.writeGroup(ctx, http.MethodPut, apiPath("groups", group.Name), body, http.StatusOK)
+	if err != nil {
+		return Group{}, fmt.Errorf("failed to update group %q: %w", group.Name, err)
+	}
+
+	return updated, nil
+}
+
+func (p *PiHole) DeleteGroup(ctx context.Context, name string) error {
+	resp, err := p.doAuthenticatedRequest(ctx, http.MethodDelete, apiPath("groups", name), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to delete group %q: %w", name, newAPIError(resp))
+	}
+
+	return nil
+}
+
+// writeGroup creates or updates a group and returns it as stored by the Pi-hole
+func (p *PiHole) writeGroup(ctx context.Context, method string, path string, group groupJSON, expected int) (Group, error) {
+	var response struct {
+		Groups    []groupJSON `json:"groups"`
+		Processed processed   `json:"processed"`
+	}
+	if err := p.sendJSON(ctx, method, path, group, expected, &response); err != nil {
+		return Group{}, err
+	}
+
+	if err := response.Processed.err(); err != nil {
+		return Group{}, err
+	}
+	if len(response.Groups) == 0 {
+		return Group{}, fmt.Errorf("the Pi-hole returned no group")
+	}
+
+	return response.Groups[0].group(), nil
+}
+
+// GroupIDs returns the ids of the groups with the given names, an empty list of
+// names selects the Default group
+func GroupIDs(groups []Group, names []string) ([]int, error) {
+	if len(names) == 0 {
+		return []int{DefaultGroupID}, nil
+	}
+
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, group := range groups {
+			if group.Name == name {
+				ids = append(ids, group.ID)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("group %q: %w", name, ErrNotFound)
+		}
+	}
+
+	return ids, nil
+}
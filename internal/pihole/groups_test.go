@@ -0,0 +1,118 @@
+package pihole
+
+import (
+	"context"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Pi-Hole Groups", func() {
+	const password = "secret"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var piHole *PiHole
+
+	comment := func(c string) *string {
+		return &c
+	}
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		piHole = NewPiHole(server.URL+"/api", password)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list groups", func() {
+		sim.SetGroups(simulator.Group{Name: "kids", Comment: comment("no games"), Enabled: true})
+
+		groups, err := piHole.GetGroups(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(groups).To(HaveLen(2))
+		Expect(groups[0]).To(Equal(Group{ID: DefaultGroupID, Name: "Default", Enabled: true}))
+		Expect(groups[1].Name).To(Equal("kids"))
+		Expect(groups[1].Comment).To(Equal("no games"))
+	})
+
+	It("should create groups", func() {
+		created, err := piHole.CreateGroup(ctx, Group{Name: "kids", Comment: "stricter blocking", Enabled: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.ID).NotTo(BeZero())
+		Expect(created.Name).To(Equal("kids"))
+
+		Expect(sim.Groups()).To(ContainElement(simulator.Group{ID: created.ID, Name: "kids", Comment: comment("stricter blocking"), Enabled: true}))
+	})
+
+	It("should report an existing group as a conflict", func() {
+		sim.SetGroups(simulator.Group{Name: "kids", Enabled: true})
+
+		_, err := piHole.CreateGroup(ctx, Group{Name: "kids", Enabled: true})
+		Expect(err).To(MatchError(ErrConflict))
+	})
+
+	It("should update groups by name", func() {
+		sim.SetGroups(simulator.Group{Name: "kids guests", Enabled: true})
+
+		updated, err := piHole.UpdateGroup(ctx, Group{Name: "kids guests", Comment: "paused"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Enabled).To(BeFalse())
+		Expect(updated.Comment).To(Equal("paused"))
+	})
+
+	It("should report updating a missing group as not found", func() {
+		_, err := piHole.UpdateGroup(ctx, Group{Name: "kids"})
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should remove deleted groups from adlists", func() {
+		sim.SetGroups(simulator.Group{ID: 7, Name: "kids", Enabled: true})
+		sim.SetLists(simulator.List{Address: "https://example.com/hosts.txt", Type: "block", Groups: []int{0, 7}})
+
+		Expect(piHole.DeleteGroup(ctx, "kids")).To(Succeed())
+		Expect(sim.Groups()).To(HaveLen(1))
+		Expect(sim.Lists()[0].Groups).To(Equal([]int{0}))
+	})
+
+	It("should report deleting a missing group as not found", func() {
+		err := piHole.DeleteGroup(ctx, "kids")
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should not support groups on Pi-hole v5", func() {
+		server, _ := simulator.NewLegacyServer("token")
+		defer server.Close()
+
+		client := NewClient(Config{URL: server.URL + "/admin/api.php", AppPassword: "token"})
+
+		_, err := client.(GroupClient).CreateGroup(ctx, Group{Name: "kids"})
+		Expect(err).To(MatchError(ErrUnsupported))
+	})
+})
+
+var _ = DescribeTable("resolving group names",
+	func(names []string, expected []int, err error) {
+		groups := []Group{{ID: 0, Name: "Default"}, {ID: 3, Name: "kids"}, {ID: 5, Name: "iot"}}
+
+		ids, e := GroupIDs(groups, names)
+		if err != nil {
+			Expect(e).To(MatchError(err))
+			return
+		}
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(ids).To(Equal(expected))
+	},
+	Entry("no names select the Default group", nil, []int{0}, nil),
+	Entry("single group", []string{"kids"}, []int{3}, nil),
+	Entry("several groups", []string{"iot", "Default"}, []int{5, 0}, nil),
+	Entry("unknown group", []string{"kids", "guests"}, nil, ErrNotFound),
+)
@@ -0,0 +1,266 @@
+package pihole
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/domnikl/pihole-operator/api/v1alpha1"
+)
+
+var _ Client = &LegacyPiHole{}
+
+// LegacyPiHole manages DNS records through the admin/api.php endpoint of Pi-hole v5.
+// It authenticates every request with the API token, so there is no session to manage.
+type LegacyPiHole struct {
+	// URL is the URL of the api.php endpoint, e.g. http://pi.hole/admin/api.php
+	URL string
+	// Token is the API token shown in the settings of the Pi-hole
+	Token string
+	// HTTPClient is the client used to talk to the PiHole API
+	HTTPClient *http.Client
+	// Timeout is the timeout of a single request against the PiHole API
+	Timeout time.Duration
+}
+
+func NewLegacyPiHole(url string, token string) *LegacyPiHole {
+	return &LegacyPiHole{
+		URL:        url,
+		Token:      token,
+		HTTPClient: &http.Client{},
+		Timeout:    DefaultTimeout,
+	}
+}
+
+func (p *LegacyPiHole) GetDNSRecords(ctx context.Context) ([]DNSRecord, error) {
+	var records []DNSRecord
+
+	// A Records
+	hosts, err := p.list(ctx, "customdns")
+	if err != nil {
+		return []DNSRecord{}, err
+	}
+
+	for _, host := range hosts {
+		records = append(records, DNSRecord{
+			Domain: host[0],
+			Target: host[1],
+			Type:   addressType(host[1]),
+		})
+	}
+
+	// CNAME Records
+	cnameRecords, err := p.list(ctx, "customcname")
+	if err != nil {
+		return []DNSRecord{}, err
+	}
+
+	for _, cname := range cnameRecords {
+		records = append(records, DNSRecord{
+			Domain: cname[0],
+			Target: cname[1],
+			Type:   v1alpha1.CName,
+		})
+	}
+
+	return records, nil
+}
+
+func (p *LegacyPiHole) CreateDNSRecord(ctx context.Context, record DNSRecord) error {
+	list, params, err := legacyParams(record)
+	if err != nil {
+		return err
+	}
+
+	if record.TTL != nil {
+		return fmt.Errorf("failed to create DNS record: %w: TTLs are not supported by Pi-hole v5", ErrInvalid)
+	}
+
+	if err := p.modify(ctx, list, "add", params); err != nil {
+		return fmt.Errorf("failed to create DNS record: %w", err)
+	}
+
+	return nil
+}
+
+func (p *LegacyPiHole) DeleteDNSRecord(ctx context.Context, record DNSRecord) error {
+	list, params, err := legacyParams(record)
+	if err != nil {
+		return err
+	}
+
+	if err := p.modify(ctx, list, "delete", params); err != nil {
+		return fmt.Errorf("failed to delete DNS record: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceDNSRecords creates the new records before it deletes the old ones, so the
+// domains keep resolving. Pi-hole v5 only accepts a single address per domain and
+// address family and a single CNAME per domain though, an old record blocking its
+// replacement is deleted right before the replacement is created.
+func (p *LegacyPiHole) ReplaceDNSRecords(ctx context.Context, oldRecords []DNSRecord, newRecords []DNSRecord) error {
+	remaining := slices.Clone(oldRecords)
+
+	for _, record := range newRecords {
+		i := slices.IndexFunc(remaining, func(old DNSRecord) bool {
+			return old.Domain == record.Domain && TypesConflict(old.Type, record.Type)
+		})
+		if i >= 0 {
+			if err := p.DeleteDNSRecord(ctx, remaining[i]); err != nil {
+				return err
+			}
+			remaining = slices.Delete(remaining, i, i+1)
+		}
+
+		if err := p.CreateDNSRecord(ctx, record); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range remaining {
+		if err := p.DeleteDNSRecord(ctx, record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close does nothing, Pi-hole v5 has no sessions
+func (p *LegacyPiHole) Close(_ context.Context) error {
+	return nil
+}
+
+// legacyParams returns the list a record is stored in and the parameters identifying it
+func legacyParams(record DNSRecord) (string, url.Values, error) {
+	switch record.Type {
+	case v1alpha1.A, v1alpha1.AAAA:
+		return "customdns", url.Values{"domain": {record.Domain}, "ip": {record.Target}}, nil
+	case v1alpha1.CName:
+		return "customcname", url.Values{"domain": {record.Domain}, "target": {record.Target}}, nil
+	}
+
+	return "", nil, fmt.Errorf("invalid DNS record type %s", record.Type)
+}
+
+// list returns the entries of a custom DNS list as pairs of domain and target
+func (p *LegacyPiHole) list(ctx context.Context, list string) ([][2]string, error) {
+	body, err := p.doRequest(ctx, list, "get", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Data [][]string `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to get DNS records: unexpected response %q", body)
+	}
+
+	entries := make([][2]string, 0, len(response.Data))
+	for _, entry := range response.Data {
+		if len(entry) != 2 {
+			// an entry edited by hand must not hide all other records
+			log.FromContext(ctx).Error(fmt.Errorf("unexpected entry %v in %s", entry, list), "Skipping malformed entry")
+			continue
+		}
+
+		entries = append(entries, [2]string{entry[0], entry[1]})
+	}
+
+	return entries, nil
+}
+
+// modify adds or deletes an entry of a custom DNS list
+func (p *LegacyPiHole) modify(ctx context.Context, list string, action string, params url.Values) error {
+	body, err := p.doRequest(ctx, list, action, params)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("unexpected response %q", body)
+	}
+
+	if !response.Success {
+		return legacyError(response.Message)
+	}
+
+	return nil
+}
+
+// legacyError classifies the message api.php returns for a failed change
+func legacyError(message string) error {
+	switch {
+	case strings.Contains(message, "already"):
+		return fmt.Errorf("%w: %s", ErrConflict, message)
+	case strings.Contains(message, "does not exist"):
+		return fmt.Errorf("%w: %s", ErrNotFound, message)
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalid, message)
+}
+
+func (p *LegacyPiHole) doRequest(ctx context.Context, list string, action string, params url.Values) ([]byte, error) {
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("action", action)
+
+	// api.php selects the list by a parameter without a value
+	target := p.URL + "?" + list + "&" + query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target+"&"+url.Values{"auth": {p.Token}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		// the error contains the URL and ends up in statuses and events, the token must not
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = target
+		}
+
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(resp.Body); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+
+	// api.php answers requests it didn't authorize with an empty array
+	if bytes.Equal(bytes.TrimSpace(body.Bytes()), []byte("[]")) {
+		return nil, fmt.Errorf("%w: the API token was not accepted", ErrUnauthorized)
+	}
+
+	return body.Bytes(), nil
+}
@@ -0,0 +1,173 @@
+package pihole
+
+import (
+	"context"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Legacy Pi-Hole Client", func() {
+	const token = "token"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Legacy
+	var piHole *LegacyPiHole
+
+	BeforeEach(func() {
+		server, sim = simulator.NewLegacyServer(token)
+		piHole = NewLegacyPiHole(server.URL+"/admin/api.php", token)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list DNS records", func() {
+		sim.SetHosts("192.168.178.1 foo.com", "2001:db8::1 baz.com")
+		sim.SetCNAMERecords("bar.com,foo.com")
+
+		records, err := piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(ConsistOf(
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			DNSRecord{Domain: "baz.com", Target: "2001:db8::1", Type: v1alpha1.AAAA},
+			DNSRecord{Domain: "bar.com", Target: "foo.com", Type: v1alpha1.CName},
+		))
+	})
+
+	DescribeTable("creating and deleting DNS records",
+		func(record DNSRecord, hosts []string, cnameRecords []string) {
+			Expect(piHole.CreateDNSRecord(ctx, record)).To(Succeed())
+			Expect(sim.Hosts()).To(Equal(hosts))
+			Expect(sim.CNAMERecords()).To(Equal(cnameRecords))
+
+			Expect(piHole.DeleteDNSRecord(ctx, record)).To(Succeed())
+			Expect(sim.Hosts()).To(BeEmpty())
+			Expect(sim.CNAMERecords()).To(BeEmpty())
+		},
+		Entry("A record",
+			DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A},
+			[]string{"192.168.178.1 foo.com"}, []string{}),
+		Entry("CNAME record",
+			DNSRecord{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName},
+			[]string{}, []string{"foo.com,bar.com"}),
+	)
+
+	It("should create a replacement before deleting the old record", func() {
+		sim.SetHosts("192.168.178.1 foo.com")
+
+		err := piHole.ReplaceDNSRecords(ctx,
+			[]DNSRecord{{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A}},
+			[]DNSRecord{{Domain: "foo.com", Target: "2001:db8::1", Type: v1alpha1.AAAA}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sim.Hosts()).To(Equal([]string{"2001:db8::1 foo.com"}))
+	})
+
+	It("should delete an old record blocking its replacement first", func() {
+		sim.SetHosts("192.168.178.1 foo.com")
+
+		err := piHole.ReplaceDNSRecords(ctx,
+			[]DNSRecord{{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A}},
+			[]DNSRecord{{Domain: "foo.com", Target: "192.168.178.2", Type: v1alpha1.A}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sim.Hosts()).To(Equal([]string{"192.168.178.2 foo.com"}))
+	})
+
+	It("should reject TTLs", func() {
+		ttl := int32(300)
+
+		err := piHole.CreateDNSRecord(ctx, DNSRecord{Domain: "foo.com", Target: "bar.com", Type: v1alpha1.CName, TTL: &ttl})
+		Expect(err).To(MatchError(ContainSubstring("not supported")))
+	})
+
+	It("should report errors of the Pi-hole", func() {
+		sim.SetHosts("192.168.178.1 foo.com")
+
+		err := piHole.CreateDNSRecord(ctx, DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A})
+		Expect(err).To(MatchError(ContainSubstring("already has a custom DNS entry")))
+		Expect(err).To(MatchError(ErrConflict))
+
+		err = piHole.DeleteDNSRecord(ctx, DNSRecord{Domain: "bar.com", Target: "192.168.178.1", Type: v1alpha1.A})
+		Expect(err).To(MatchError(ContainSubstring("does not exist")))
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should fail with a wrong token", func() {
+		piHole.Token = "wrong"
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).To(MatchError(ErrUnauthorized))
+	})
+
+	It("should not reveal the token when the Pi-hole is unreachable", func() {
+		server.Close()
+		piHole.Token = "SUPERSECRETTOKEN"
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("customdns"))
+		Expect(err.Error()).NotTo(ContainSubstring("SUPERSECRETTOKEN"))
+		Expect(IsRetryable(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("API version detection", func() {
+	ctx := context.Background()
+
+	DescribeTable("base URLs",
+		func(url string, expected string) {
+			Expect(BaseURL(url)).To(Equal(expected))
+		},
+		Entry("address", "http://pi.hole", "http://pi.hole"),
+		Entry("trailing slash", "http://pi.hole/", "http://pi.hole"),
+		Entry("v6 API", "http://pi.hole/api", "http://pi.hole"),
+		Entry("v5 API", "http://pi.hole/admin/api.php", "http://pi.hole"),
+		Entry("sub path", "https://example.com/pihole/api/", "https://example.com/pihole"),
+	)
+
+	It("should detect Pi-hole v6", func() {
+		server, sim := simulator.NewServer("secret")
+		defer server.Close()
+
+		client := NewClient(Config{URL: server.URL, AppPassword: "secret"})
+
+		_, err := client.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sim.Logins()).To(Equal(1))
+	})
+
+	It("should detect Pi-hole v5", func() {
+		server, sim := simulator.NewLegacyServer("token")
+		defer server.Close()
+		sim.SetHosts("192.168.178.1 foo.com")
+
+		client := NewClient(Config{URL: server.URL + "/admin/api.php", AppPassword: "token"})
+
+		records, err := client.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(1))
+	})
+
+	It("should fail for something that isn't a Pi-hole", func() {
+		server := httptest.NewServer(nil)
+		defer server.Close()
+
+		_, err := DetectVersion(ctx, server.Client(), server.URL)
+		Expect(err).To(MatchError(ContainSubstring("failed to detect")))
+	})
+
+	It("should use the configured version without detection", func() {
+		client := NewClient(Config{URL: "http://pi.hole", Version: V5})
+		Expect(client).To(BeAssignableToTypeOf(&LegacyPiHole{}))
+		Expect(client.(*LegacyPiHole).URL).To(Equal("http://pi.hole/admin/api.php"))
+	})
+})
@@ -0,0 +1,235 @@
+package pihole
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ListType is the type of an adlist
+type ListType string
+
+const (
+	// ListTypeBlock is a list of domains that are blocked
+	ListTypeBlock ListType = "block"
+	// ListTypeAllow is a list of domains that are allowed
+	ListTypeAllow ListType = "allow"
+)
+
+// List is an adlist subscribed to by a Pi-hole
+type List struct {
+	// ID is the id of the list on the Pi-hole, it is assigned when the list is created
+	ID int
+	// Address is the URL the list is downloaded from
+	Address string
+	// Type is the type of the list
+	Type ListType
+	// Comment describes the list
+	Comment string
+	// Groups are the ids of the groups the list applies to
+	Groups []int
+	// Enabled is false if the list is not used
+	Enabled bool
+	// Number is the number of domains on the list as of the last gravity update
+	Number int
+	// DateUpdated is the time of the last gravity update of the list
+	DateUpdated time.Time
+}
+
+// ListClient manages the adlists of a Pi-hole, only Pi-hole v6 supports it
+type ListClient interface {
+	// GetLists returns all adlists
+	GetLists(ctx context.Context) ([]List, error)
+	// CreateList subscribes to a new adlist and returns it as stored by the Pi-hole
+	CreateList(ctx context.Context, list List) (List, error)
+	// UpdateList changes the comment, groups and state of an existing adlist
+	UpdateList(ctx context.Context, list List) (List, error)
+	// DeleteList removes an adlist
+	DeleteList(ctx context.Context, address string, listType ListType) error
+}
+
+var _ ListClient = &PiHole{}
+
+// listJSON is a list as represented by the Pi-hole API
+type listJSON struct {
+	ID          int      `json:"id,omitempty"`
+	Address     string   `json:"address,omitempty"`
+	Type        ListType `json:"type"`
+	Comment     *string  `json:"comment"`
+	Groups      []int    `json:"groups"`
+	Enabled     bool     `json:"enabled"`
+	Number      int      `json:"number,omitempty"`
+	DateUpdated int64    `json:"date_updated,omitempty"`
+}
+
+func (l listJSON) list() List {
+	list := List{
+		ID:      l.ID,
+		Address: l.Address,
+		Type:    l.Type,
+		Groups:  l.Groups,
+		Enabled: l.Enabled,
+		Number:  l.Number,
+	}
+	if l.Comment != nil {
+		list.Comment = *l.Comment
+	}
+	if l.DateUpdated > 0 {
+		list.DateUpdated = time.Unix(l.DateUpdated, 0)
+	}
+
+	return list
+}
+
+func (p *PiHole) GetLists(ctx context.Context) ([]List, error) {
+	var response struct {
+		Lists []listJSON `json:"lists"`
+	}
+	if err := p.getJSON(ctx, apiPath("lists"), &response); err != nil {
+		return nil, fmt.Errorf("failed to get adlists: %w", err)
+	}
+
+	lists := make([]List, 0, len(response.Lists))
+	for _, list := range response.Lists {
+		lists = append(lists, list.list())
+	}
+
+	return lists, nil
+}
+
+func (p *PiHole) CreateList(ctx context.Context, list List) (List, error) {
+	body := listJSON{
+		Address: list.Address,
+		Type:    list.Type,
+		Comment: &list.Comment,
+		Groups:  list.Groups,
+		Enabled: list.Enabled,
+	}
+
+	created, err := p.writeList(ctx, http.MethodPost, apiPath("lists"), body, http.StatusCreated)
+	if err != nil {
+		return List{}, fmt.Errorf("failed to create adlist %s: %w", list.Address, err)
+	}
+
+	return created, nil
+}
+
+func (p *PiHole) UpdateList(ctx context.Context, list List) (List, error) {
+	body := listJSON{
+		Type:    list.Type,
+		Comment: &list.Comment,
+		Groups:  list.Groups,
+		Enabled: list.Enabled,
+	}
+
+	updated, err := p.writeList(ctx, http.MethodPut, listPath(list.Address, list.Type), body, http.StatusOK)
+	if err != nil {
+		return List{}, fmt.Errorf("failed to update adlist %s: %w", list.Address, err)
+	}
+
+	return updated, nil
+}
+
+func (p *PiHole) DeleteList(ctx context.Context, address string, listType ListType) error {
+	resp, err := p.doAuthenticatedRequest(ctx, http.MethodDelete, listPath(address, listType), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to delete adlist %s: %w", address, newAPIError(resp))
+	}
+
+	return nil
+}
+
+// listPath returns the path of a single list, lists are identified by their address and type
+func listPath(address string, listType ListType) string {
+	return apiPath("lists", address) + "?" + url.Values{"type": {string(listType)}}.Encode()
+}
+
+// writeList creates or updates a list and returns it as stored by the Pi-hole
+func (p *PiHole) writeList(ctx context.Context, method string, path string, list listJSON, expected int) (List, error) {
+	var response struct {
+		Lists     []listJSON `json:"lists"`
+		Processed processed  `json:"processed"`
+	}
+	if err := p.sendJSON(ctx, method, path, list, expected, &response); err != nil {
+		return List{}, err
+	}
+
+	if err := response.Processed.err(); err != nil {
+		return List{}, err
+	}
+	if len(response.Lists) == 0 {
+		return List{}, fmt.Errorf("the Pi-hole returned no list")
+	}
+
+	return response.Lists[0].list(), nil
+}
+
+// processed reports the items a request to a list endpoint of the Pi-hole succeeded
+// or failed for, FTL answers with a success status even if all items failed
+type processed struct {
+	Errors []struct {
+		Item  string `json:"item"`
+		Error string `json:"error"`
+	} `json:"errors"`
+}
+
+func (p processed) err() error {
+	if len(p.Errors) == 0 {
+		return nil
+	}
+
+	item := p.Errors[0]
+	if strings.Contains(item.Error, "UNIQUE constraint failed") {
+		return fmt.Errorf("%w: %s", ErrConflict, item.Item)
+	}
+
+	return fmt.Errorf("%w: %s: %s", ErrInvalid, item.Item, item.Error)
+}
+
+// getJSON reads a resource of the Pi-hole API into v
+func (p *PiHole) getJSON(ctx context.Context, path string, v any) error {
+	resp, err := p.doAuthenticatedRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// sendJSON sends body to the Pi-hole API and reads the response into v, which may be nil
+func (p *PiHole) sendJSON(ctx context.Context, method string, path string, body any, expected int, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.doAuthenticatedRequest(ctx, method, path, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		return newAPIError(resp)
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,164 @@
+package pihole
+
+import (
+	"context"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Pi-Hole Lists", func() {
+	const password = "secret"
+	const address = "https://example.com/hosts.txt"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var piHole *PiHole
+
+	comment := func(c string) *string {
+		return &c
+	}
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		piHole = NewPiHole(server.URL+"/api", password)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list adlists", func() {
+		sim.SetLists(simulator.List{
+			Address:     address,
+			Type:        "block",
+			Comment:     comment("ads"),
+			Groups:      []int{0, 2},
+			Enabled:     true,
+			Number:      4711,
+			DateUpdated: 1700000000,
+		})
+
+		lists, err := piHole.GetLists(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lists).To(HaveLen(1))
+		Expect(lists[0].ID).NotTo(BeZero())
+		Expect(lists[0].Address).To(Equal(address))
+		Expect(lists[0].Type).To(Equal(ListTypeBlock))
+		Expect(lists[0].Comment).To(Equal("ads"))
+		Expect(lists[0].Groups).To(Equal([]int{0, 2}))
+		Expect(lists[0].Number).To(Equal(4711))
+		Expect(lists[0].DateUpdated.Unix()).To(Equal(int64(1700000000)))
+	})
+
+	It("should list adlists without a comment or gravity update", func() {
+		sim.SetLists(simulator.List{Address: address, Type: "allow", Groups: []int{0}})
+
+		lists, err := piHole.GetLists(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lists[0].Comment).To(BeEmpty())
+		Expect(lists[0].DateUpdated.IsZero()).To(BeTrue())
+	})
+
+	DescribeTable("creating adlists",
+		func(list List) {
+			created, err := piHole.CreateList(ctx, list)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.ID).NotTo(BeZero())
+
+			stored := sim.Lists()
+			Expect(stored).To(HaveLen(1))
+			Expect(stored[0].Address).To(Equal(list.Address))
+			Expect(stored[0].Type).To(Equal(string(list.Type)))
+			Expect(stored[0].Groups).To(Equal(list.Groups))
+			Expect(stored[0].Enabled).To(Equal(list.Enabled))
+		},
+		Entry("block list", List{Address: address, Type: ListTypeBlock, Groups: []int{0}, Enabled: true}),
+		Entry("allow list", List{Address: address, Type: ListTypeAllow, Groups: []int{0}, Enabled: true}),
+		Entry("disabled list", List{Address: address, Type: ListTypeBlock, Groups: []int{0}, Enabled: false}),
+		Entry("list with a query", List{Address: address + "?format=hosts&v=2", Type: ListTypeBlock, Groups: []int{0}, Enabled: true}),
+	)
+
+	It("should report an existing adlist as a conflict", func() {
+		sim.SetLists(simulator.List{Address: address, Type: "block", Groups: []int{0}})
+
+		_, err := piHole.CreateList(ctx, List{Address: address, Type: ListTypeBlock, Groups: []int{0}})
+		Expect(err).To(MatchError(ErrConflict))
+	})
+
+	It("should allow the same address as block and allow list", func() {
+		sim.SetLists(simulator.List{Address: address, Type: "block", Groups: []int{0}})
+
+		_, err := piHole.CreateList(ctx, List{Address: address, Type: ListTypeAllow, Groups: []int{0}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sim.Lists()).To(HaveLen(2))
+	})
+
+	It("should reject an invalid address", func() {
+		_, err := piHole.CreateList(ctx, List{Address: "not a url", Type: ListTypeBlock})
+		Expect(err).To(MatchError(ErrInvalid))
+	})
+
+	DescribeTable("updating adlists",
+		func(address string) {
+			sim.SetLists(simulator.List{Address: address, Type: "block", Groups: []int{0}, Enabled: true})
+
+			updated, err := piHole.UpdateList(ctx, List{Address: address, Type: ListTypeBlock, Comment: "updated", Groups: []int{0, 1}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Comment).To(Equal("updated"))
+
+			stored := sim.Lists()
+			Expect(*stored[0].Comment).To(Equal("updated"))
+			Expect(stored[0].Groups).To(Equal([]int{0, 1}))
+			Expect(stored[0].Enabled).To(BeFalse())
+		},
+		Entry("plain address", address),
+		Entry("address with a query", address+"?format=hosts&v=2"),
+		Entry("address with escaped characters", "https://example.com/a%20list.txt"),
+	)
+
+	It("should report updating a missing adlist as not found", func() {
+		_, err := piHole.UpdateList(ctx, List{Address: address, Type: ListTypeBlock})
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should delete adlists by address and type", func() {
+		sim.SetLists(
+			simulator.List{Address: address, Type: "block", Groups: []int{0}},
+			simulator.List{Address: address, Type: "allow", Groups: []int{0}},
+		)
+
+		Expect(piHole.DeleteList(ctx, address, ListTypeAllow)).To(Succeed())
+		Expect(sim.Lists()).To(ConsistOf(HaveField("Type", "block")))
+	})
+
+	It("should report deleting a missing adlist as not found", func() {
+		err := piHole.DeleteList(ctx, address, ListTypeBlock)
+		Expect(err).To(MatchError(ErrNotFound))
+	})
+
+	It("should not support adlists on Pi-hole v5", func() {
+		server, _ := simulator.NewLegacyServer("token")
+		defer server.Close()
+
+		client := NewClient(Config{URL: server.URL + "/admin/api.php", AppPassword: "token"})
+
+		_, err := client.(ListClient).GetLists(ctx)
+		Expect(err).To(MatchError(ErrUnsupported))
+	})
+
+	It("should detect Pi-hole v6 for adlists", func() {
+		sim.SetLists(simulator.List{Address: address, Type: "block", Groups: []int{0}})
+
+		client := NewClient(Config{URL: server.URL, AppPassword: password})
+
+		lists, err := client.(ListClient).GetLists(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lists).To(HaveLen(1))
+	})
+})
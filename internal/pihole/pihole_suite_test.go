@@ -0,0 +1,14 @@
+package pihole
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPiHole(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Pi-Hole Client Suite")
+}
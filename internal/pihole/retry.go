@@ -0,0 +1,159 @@
+package pihole
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryPolicy configures how requests failing because the Pi-hole is unavailable,
+// e.g. while FTL restarts after a config change, are retried. Requests against
+// Pi-hole v5 are not retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a request, 0 or 1 disables retries
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, it doubles with every further retry
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum delay between two attempts
+	MaxBackoff time.Duration
+	// BudgetPercent limits the retries to this share of all requests, so a Pi-hole
+	// that is down isn't flooded with them. 0 doesn't limit retries.
+	BudgetPercent int
+}
+
+// DefaultRetryPolicy is the retry policy used by the operator by default
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	BudgetPercent:  20,
+}
+
+// retryBudgetBurst is the number of retries available before the budget applies,
+// so a few failures are retried even if there haven't been any requests yet
+const retryBudgetBurst = 10
+
+// backoff returns the jittered delay before the given retry, starting with 1. The
+// delay is at least half of the exponential backoff, so retries of concurrent
+// requests are spread without retrying right away.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if delay <= 0 {
+		return 0
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryBudget is a token bucket limiting retries to a share of all requests. Every
+// request deposits the share of a token, every retry withdraws a whole one.
+type retryBudget struct {
+	mu          sync.Mutex
+	tokens      float64
+	initialized bool
+}
+
+func (b *retryBudget) deposit(percent int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.init()
+	b.tokens = min(b.tokens+float64(percent)/100, retryBudgetBurst)
+}
+
+func (b *retryBudget) withdraw(percent int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if percent <= 0 {
+		return true
+	}
+
+	b.init()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+
+	return true
+}
+
+// init fills the bucket on first use, b.mu must be held
+func (b *retryBudget) init() {
+	if !b.initialized {
+		b.tokens = retryBudgetBurst
+		b.initialized = true
+	}
+}
+
+// retryableAttempt returns true if an attempt failed in a way repeating it may fix.
+// Requests that were not sent or that the Pi-hole refused to handle are repeated
+// regardless of their method, requests it may have applied only if they are
+// idempotent.
+func retryableAttempt(method string, sent bool, resp *http.Response, err error) bool {
+	if err != nil {
+		if !sent || isDialError(err) {
+			return IsRetryable(err)
+		}
+
+		return idempotent(method) && IsRetryable(err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+
+	return false
+}
+
+// idempotent returns true for methods that can be repeated without changing the
+// outcome, see RFC 9110. PATCH /config is not retried as it replaces whole lists
+// read before, a retry could undo a change made in between.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// isDialError returns true if the connection to the Pi-hole couldn't be established
+func isDialError(err error) bool {
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// waitForRetry returns the delay before the given retry and false if the request
+// must not be retried, because the policy or the deadline of ctx doesn't allow it
+func (p *PiHole) waitForRetry(ctx context.Context, retry int) (time.Duration, bool) {
+	if retry >= p.Retry.MaxAttempts {
+		return 0, false
+	}
+
+	delay := p.Retry.backoff(retry)
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return 0, false
+	}
+
+	if !p.budget.withdraw(p.Retry.BudgetPercent) {
+		return 0, false
+	}
+
+	return delay, true
+}
@@ -0,0 +1,144 @@
+package pihole
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole/simulator"
+)
+
+var _ = Describe("Retrying requests", func() {
+	const password = "secret"
+
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var sim *simulator.Simulator
+	var piHole *PiHole
+
+	record := DNSRecord{Domain: "foo.com", Target: "192.168.178.1", Type: v1alpha1.A}
+
+	BeforeEach(func() {
+		server, sim = simulator.NewServer(password)
+		piHole = NewPiHole(server.URL+"/api", password)
+		piHole.Retry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+		// log in before failures are injected, so they hit the requests under test
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should retry a request while FTL restarts", func() {
+		sim.FailNext(http.MethodGet, 2, http.StatusServiceUnavailable)
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should give up after the maximum number of attempts", func() {
+		sim.FailNext(http.MethodGet, 3, http.StatusServiceUnavailable)
+		requests := sim.Requests()
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).To(MatchError(ErrUnavailable))
+		Expect(sim.Requests() - requests).To(Equal(3))
+	})
+
+	It("should not retry without a retry policy", func() {
+		piHole.Retry = RetryPolicy{}
+		sim.FailNext(http.MethodGet, 1, http.StatusServiceUnavailable)
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).To(MatchError(ErrUnavailable))
+	})
+
+	It("should not retry requests that can't succeed", func() {
+		sim.SetHosts("192.168.178.1 foo.com")
+		requests := sim.Requests()
+
+		Expect(piHole.CreateDNSRecord(ctx, record)).To(MatchError(ErrConflict))
+		Expect(sim.Requests() - requests).To(Equal(1))
+	})
+
+	It("should retry idempotent requests the Pi-hole may have applied", func() {
+		sim.FailNext(http.MethodPut, 1, http.StatusGatewayTimeout)
+
+		Expect(piHole.CreateDNSRecord(ctx, record)).To(Succeed())
+		Expect(sim.Hosts()).To(ConsistOf("192.168.178.1 foo.com"))
+	})
+
+	It("should not retry a config change the Pi-hole may have applied", func() {
+		sim.FailNext(http.MethodPatch, 1, http.StatusGatewayTimeout)
+
+		err := piHole.ReplaceDNSRecords(ctx, nil, []DNSRecord{record})
+		Expect(err).To(MatchError(ErrUnavailable))
+	})
+
+	It("should retry a config change the Pi-hole refused to handle", func() {
+		sim.FailNext(http.MethodPatch, 1, http.StatusServiceUnavailable)
+
+		Expect(piHole.ReplaceDNSRecords(ctx, nil, []DNSRecord{record})).To(Succeed())
+		Expect(sim.Hosts()).To(ConsistOf("192.168.178.1 foo.com"))
+	})
+
+	It("should retry the authentication", func() {
+		sim.ExpireSessions()
+		sim.FailNext(http.MethodPost, 2, http.StatusServiceUnavailable)
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should not wait for a retry beyond the deadline of the context", func() {
+		piHole.Retry.InitialBackoff = time.Minute
+		piHole.Retry.MaxBackoff = time.Minute
+		sim.FailNext(http.MethodGet, 1, http.StatusServiceUnavailable)
+
+		deadline, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		start := time.Now()
+		_, err := piHole.GetDNSRecords(deadline)
+		Expect(err).To(MatchError(ErrUnavailable))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+
+	It("should stop retrying once the budget is spent", func() {
+		piHole.Retry.MaxAttempts = 100
+		piHole.Retry.BudgetPercent = 10
+		sim.FailNext(http.MethodGet, 100, http.StatusServiceUnavailable)
+		requests := sim.Requests()
+
+		_, err := piHole.GetDNSRecords(ctx)
+		Expect(err).To(MatchError(ErrUnavailable))
+
+		// the first attempt and the retries left in the budget
+		Expect(sim.Requests() - requests).To(Equal(1 + retryBudgetBurst))
+	})
+
+	DescribeTable("backing off",
+		func(retry int, minimum time.Duration, maximum time.Duration) {
+			policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+			for i := 0; i < 100; i++ {
+				Expect(policy.backoff(retry)).To(And(
+					BeNumerically(">=", minimum),
+					BeNumerically("<=", maximum),
+				))
+			}
+		},
+		Entry("first retry", 1, 50*time.Millisecond, 100*time.Millisecond),
+		Entry("second retry", 2, 100*time.Millisecond, 200*time.Millisecond),
+		Entry("capped", 10, 500*time.Millisecond, time.Second),
+	)
+})
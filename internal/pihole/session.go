@@ -0,0 +1,129 @@
+package pihole
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// loginFunc creates a new session and returns its id and validity
+type loginFunc func(ctx context.Context) (string, time.Duration, error)
+
+// session tracks the session of a PiHole. It is safe for concurrent use and
+// serializes logins, so concurrent requests without a valid session share a
+// single login instead of each occupying one of the Pi-hole's session slots.
+type session struct {
+	mu        sync.Mutex
+	sid       string
+	validity  time.Duration
+	expiresAt time.Time
+	inflight  *loginCall
+	now       func() time.Time
+}
+
+// loginCall is a login in progress, done is closed once it finished
+type loginCall struct {
+	done chan struct{}
+	sid  string
+	err  error
+}
+
+func newSession() *session {
+	return &session{now: time.Now}
+}
+
+// get returns a valid session id. A new session is created if there is none,
+// if it expired or if it is about to expire (refresh is called with the id of
+// the replaced session then).
+func (s *session) get(ctx context.Context, login loginFunc, refresh func(ctx context.Context, oldSID string)) (string, error) {
+	s.mu.Lock()
+
+	if s.sid != "" && !s.expiringLocked() {
+		sid := s.sid
+		s.mu.Unlock()
+
+		return sid, nil
+	}
+
+	call := s.inflight
+	if call == nil {
+		call = &loginCall{done: make(chan struct{})}
+		s.inflight = call
+		oldSID := s.sid
+
+		s.mu.Unlock()
+
+		s.login(ctx, call, login)
+
+		if call.err == nil && oldSID != "" && refresh != nil {
+			refresh(ctx, oldSID)
+		}
+
+		return call.sid, call.err
+	}
+
+	s.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.sid, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// login runs a login and publishes its result to all callers waiting for it
+func (s *session) login(ctx context.Context, call *loginCall, login loginFunc) {
+	sid, validity, err := login(ctx)
+
+	s.mu.Lock()
+	call.sid, call.err = sid, err
+	if err == nil {
+		s.sid = sid
+		s.validity = validity
+		s.expiresAt = s.now().Add(validity)
+	}
+	s.inflight = nil
+	s.mu.Unlock()
+
+	close(call.done)
+}
+
+// touch marks the session as used, Pi-hole extends its validity on every request
+func (s *session) touch(sid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sid == sid && s.validity > 0 {
+		s.expiresAt = s.now().Add(s.validity)
+	}
+}
+
+// invalidate forgets the session if it is still the current one, a session
+// replaced concurrently stays untouched
+func (s *session) invalidate(sid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sid == sid {
+		s.sid = ""
+	}
+}
+
+// current returns the id of the current session, which may be empty
+func (s *session) current() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sid
+}
+
+// expiringLocked is true if the session expires within the last tenth of its validity,
+// s.mu must be held
+func (s *session) expiringLocked() bool {
+	if s.validity <= 0 {
+		return false
+	}
+
+	return s.now().Add(s.validity / 10).After(s.expiresAt)
+}
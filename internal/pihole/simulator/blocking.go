@@ -0,0 +1,100 @@
+package simulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Blocking returns whether blocking is enabled and the time left until FTL reverts it.
+func (s *Simulator) Blocking() (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.blockingState()
+}
+
+// SetBlocking enables or disables blocking, as it happens in the Pi-hole UI. With a
+// timer greater than 0 the state is reverted once it has passed.
+func (s *Simulator) SetBlocking(enabled bool, timer time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocking = enabled
+	s.blockingEnd = time.Time{}
+	if timer > 0 {
+		s.blockingEnd = s.now().Add(timer)
+	}
+}
+
+func (s *Simulator) getBlocking(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writeBlocking(w)
+}
+
+func (s *Simulator) setBlocking(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Blocking *bool    `json:"blocking"`
+		Timer    *float64 `json:"timer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+	if request.Blocking == nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "No \"blocking\" boolean in JSON data found", "")
+		return
+	}
+	if request.Timer != nil && *request.Timer < 0 {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid \"timer\" value", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocking = *request.Blocking
+	s.blockingEnd = time.Time{}
+	if request.Timer != nil && *request.Timer > 0 {
+		s.blockingEnd = s.now().Add(time.Duration(*request.Timer * float64(time.Second)))
+	}
+
+	s.writeBlocking(w)
+}
+
+// blockingState reverts the blocking state if its timer has passed and returns it, s.mu must be held
+func (s *Simulator) blockingState() (bool, time.Duration) {
+	if s.blockingEnd.IsZero() {
+		return s.blocking, 0
+	}
+
+	left := s.blockingEnd.Sub(s.now())
+	if left <= 0 {
+		s.blocking = !s.blocking
+		s.blockingEnd = time.Time{}
+
+		return s.blocking, 0
+	}
+
+	return s.blocking, left
+}
+
+// writeBlocking writes the blocking state like FTL, s.mu must be held
+func (s *Simulator) writeBlocking(w http.ResponseWriter) {
+	enabled, left := s.blockingState()
+
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+
+	var timer *float64
+	if left > 0 {
+		seconds := left.Seconds()
+		timer = &seconds
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"blocking": state, "timer": timer})
+}
@@ -0,0 +1,126 @@
+package simulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+// Client is a client as stored by the simulator
+type Client struct {
+	ID      int     `json:"id"`
+	Client  string  `json:"client"`
+	Name    *string `json:"name"`
+	Comment *string `json:"comment"`
+	Groups  []int   `json:"groups"`
+}
+
+// Clients returns all clients.
+func (s *Simulator) Clients() []Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.clients)
+}
+
+// SetClients replaces all clients, ids are assigned to clients without one.
+func (s *Simulator) SetClients(clients ...Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clients = nil
+	for _, client := range clients {
+		if client.ID == 0 {
+			client.ID = s.nextID()
+		}
+		s.clients = append(s.clients, client)
+	}
+}
+
+func (s *Simulator) getClients(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"clients": s.clients})
+}
+
+// createClient adds a client, like FTL it reports duplicates in the processed errors
+// instead of failing the request
+func (s *Simulator) createClient(w http.ResponseWriter, r *http.Request) {
+	var request Client
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+
+	if request.Client == "" {
+		writeError(w, http.StatusBadRequest, "bad_request", "No client in request body", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOfClient(request.Client) >= 0 {
+		writeProcessed(w, http.StatusCreated, "clients", []Client{}, request.Client, "UNIQUE constraint failed: client.ip")
+		return
+	}
+
+	request.ID = s.nextID()
+	request.Name = nil
+	if len(request.Groups) == 0 {
+		request.Groups = []int{0}
+	}
+	s.clients = append(s.clients, request)
+
+	writeProcessed(w, http.StatusCreated, "clients", []Client{request}, request.Client, "")
+}
+
+func (s *Simulator) updateClient(w http.ResponseWriter, r *http.Request) {
+	var request Client
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+
+	client := r.PathValue("client")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfClient(client)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Client not found", client)
+		return
+	}
+
+	stored := &s.clients[i]
+	stored.Comment = request.Comment
+	stored.Groups = request.Groups
+
+	writeProcessed(w, http.StatusOK, "clients", []Client{*stored}, client, "")
+}
+
+func (s *Simulator) deleteClient(w http.ResponseWriter, r *http.Request) {
+	client := r.PathValue("client")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfClient(client)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Client not found", client)
+		return
+	}
+
+	s.clients = slices.Delete(s.clients, i, i+1)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// indexOfClient returns the index of the given client, s.mu must be held.
+func (s *Simulator) indexOfClient(client string) int {
+	return slices.IndexFunc(s.clients, func(c Client) bool {
+		return c.Client == client
+	})
+}
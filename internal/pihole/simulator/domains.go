@@ -0,0 +1,138 @@
+package simulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+// Domain is an allowed or denied domain as stored by the simulator
+type Domain struct {
+	ID      int     `json:"id"`
+	Domain  string  `json:"domain"`
+	Type    string  `json:"type"`
+	Kind    string  `json:"kind"`
+	Comment *string `json:"comment"`
+	Groups  []int   `json:"groups"`
+	Enabled bool    `json:"enabled"`
+}
+
+// Domains returns all allowed and denied domains.
+func (s *Simulator) Domains() []Domain {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.domains)
+}
+
+// SetDomains replaces all allowed and denied domains, ids are assigned to domains without one.
+func (s *Simulator) SetDomains(domains ...Domain) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.domains = nil
+	for _, domain := range domains {
+		if domain.ID == 0 {
+			domain.ID = s.nextID()
+		}
+		s.domains = append(s.domains, domain)
+	}
+}
+
+func (s *Simulator) getDomains(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"domains": s.domains})
+}
+
+// createDomain adds a domain, like FTL it doesn't check regular expressions and
+// reports duplicates in the processed errors instead of failing the request
+func (s *Simulator) createDomain(w http.ResponseWriter, r *http.Request) {
+	var request Domain
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+
+	request.Type = r.PathValue("type")
+	request.Kind = r.PathValue("kind")
+	if !validDomainType(request.Type, request.Kind) {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid type or kind", request.Type+"/"+request.Kind)
+		return
+	}
+	if request.Domain == "" {
+		writeError(w, http.StatusBadRequest, "bad_request", "No domain in request body", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOfDomain(request.Domain, request.Type, request.Kind) >= 0 {
+		writeProcessed(w, http.StatusCreated, "domains", []Domain{}, request.Domain, "UNIQUE constraint failed: domainlist.domain, domainlist.type")
+		return
+	}
+
+	request.ID = s.nextID()
+	if len(request.Groups) == 0 {
+		request.Groups = []int{0}
+	}
+	s.domains = append(s.domains, request)
+
+	writeProcessed(w, http.StatusCreated, "domains", []Domain{request}, request.Domain, "")
+}
+
+func (s *Simulator) updateDomain(w http.ResponseWriter, r *http.Request) {
+	var request Domain
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+
+	domain := r.PathValue("domain")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfDomain(domain, r.PathValue("type"), r.PathValue("kind"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Domain not found", domain)
+		return
+	}
+
+	stored := &s.domains[i]
+	stored.Comment = request.Comment
+	stored.Groups = request.Groups
+	stored.Enabled = request.Enabled
+
+	writeProcessed(w, http.StatusOK, "domains", []Domain{*stored}, domain, "")
+}
+
+func (s *Simulator) deleteDomain(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfDomain(domain, r.PathValue("type"), r.PathValue("kind"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Domain not found", domain)
+		return
+	}
+
+	s.domains = slices.Delete(s.domains, i, i+1)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// indexOfDomain returns the index of the domain with the given type and kind, s.mu must be held.
+func (s *Simulator) indexOfDomain(domain string, domainType string, kind string) int {
+	return slices.IndexFunc(s.domains, func(d Domain) bool {
+		return d.Domain == domain && d.Type == domainType && d.Kind == kind
+	})
+}
+
+func validDomainType(domainType string, kind string) bool {
+	return (domainType == "allow" || domainType == "deny") && (kind == "exact" || kind == "regex")
+}
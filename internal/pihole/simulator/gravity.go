@@ -0,0 +1,84 @@
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// SetGravityOutput replaces the output of gravity updates, by default they
+// report retrieving every enabled adlist.
+func (s *Simulator) SetGravityOutput(lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gravity = slices.Clone(lines)
+}
+
+// GravityRuns returns the number of gravity updates.
+func (s *Simulator) GravityRuns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.gravityRuns
+}
+
+// updateGravity streams the output of pihole -g like FTL, colored and with
+// progress overwriting the current line, and marks the enabled lists as updated
+func (s *Simulator) updateGravity(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.gravityRuns++
+	lines := slices.Clone(s.gravity)
+	if lines == nil {
+		lines = s.defaultGravityOutput()
+	}
+	now := s.now().Unix()
+	for i := range s.lists {
+		if s.lists[i].Enabled {
+			s.lists[i].DateUpdated = now
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	for _, line := range lines {
+		if s.GravityDelay > 0 {
+			select {
+			case <-time.After(s.GravityDelay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		fmt.Fprint(w, line)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// defaultGravityOutput returns the output of a successful update, s.mu must be held
+func (s *Simulator) defaultGravityOutput() []string {
+	lines := []string{"  [i] Neutrino emissions detected...\n"}
+	for _, list := range s.lists {
+		if !list.Enabled {
+			continue
+		}
+
+		lines = append(lines,
+			fmt.Sprintf("  [i] Target: %s\n", list.Address),
+			"  [i] Status: Pending...",
+			"\r\x1b[K  [\x1b[1;32m✓\x1b[0m] Status: Retrieval successful\n",
+		)
+	}
+
+	return append(lines,
+		"  [i] Building tree...",
+		"\r\x1b[K  [\x1b[1;32m✓\x1b[0m] Building tree\n",
+		"  [\x1b[1;32m✓\x1b[0m] Done.\n",
+	)
+}
@@ -0,0 +1,148 @@
+package simulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+)
+
+// Group is a group as stored by the simulator
+type Group struct {
+	ID      int     `json:"id"`
+	Name    string  `json:"name"`
+	Comment *string `json:"comment"`
+	Enabled bool    `json:"enabled"`
+}
+
+// Groups returns all groups, including the Default group.
+func (s *Simulator) Groups() []Group {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.groups)
+}
+
+// SetGroups replaces all groups but the Default group, ids are assigned to groups without one.
+func (s *Simulator) SetGroups(groups ...Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups = s.groups[:1]
+	for _, group := range groups {
+		if group.ID == 0 {
+			group.ID = s.nextID()
+		}
+		s.groups = append(s.groups, group)
+	}
+}
+
+func (s *Simulator) getGroups(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"groups": s.groups})
+}
+
+// createGroup adds a group, like FTL it reports duplicates in the processed errors
+// instead of failing the request
+func (s *Simulator) createGroup(w http.ResponseWriter, r *http.Request) {
+	var request Group
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+
+	if request.Name == "" {
+		writeError(w, http.StatusBadRequest, "bad_request", "No name in request body", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOfGroup(request.Name) >= 0 {
+		writeProcessed(w, http.StatusCreated, "groups", []Group{}, request.Name, "UNIQUE constraint failed: group.name")
+		return
+	}
+
+	request.ID = s.nextID()
+	s.groups = append(s.groups, request)
+
+	writeProcessed(w, http.StatusCreated, "groups", []Group{request}, request.Name, "")
+}
+
+func (s *Simulator) updateGroup(w http.ResponseWriter, r *http.Request) {
+	var request Group
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+
+	name := r.PathValue("name")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfGroup(name)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Group not found", name)
+		return
+	}
+
+	group := &s.groups[i]
+	if request.Name != "" {
+		group.Name = request.Name
+	}
+	group.Comment = request.Comment
+	group.Enabled = request.Enabled
+
+	writeProcessed(w, http.StatusOK, "groups", []Group{*group}, name, "")
+}
+
+// deleteGroup removes a group, like FTL it removes the group from all lists, domains
+// and clients and refuses to delete the Default group
+func (s *Simulator) deleteGroup(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfGroup(name)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "Group not found", name)
+		return
+	}
+
+	id := s.groups[i].ID
+	if id == 0 {
+		writeError(w, http.StatusBadRequest, "bad_request", "The Default group cannot be deleted", name)
+		return
+	}
+
+	s.groups = slices.Delete(s.groups, i, i+1)
+	for j := range s.lists {
+		s.lists[j].Groups = withoutGroup(s.lists[j].Groups, id)
+	}
+	for j := range s.domains {
+		s.domains[j].Groups = withoutGroup(s.domains[j].Groups, id)
+	}
+	for j := range s.clients {
+		s.clients[j].Groups = withoutGroup(s.clients[j].Groups, id)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// indexOfGroup returns the index of the group with the given name, s.mu must be held.
+func (s *Simulator) indexOfGroup(name string) int {
+	return slices.IndexFunc(s.groups, func(g Group) bool {
+		return g.Name == name
+	})
+}
+
+// withoutGroup removes a group id from the groups of a list, domain or client
+func withoutGroup(groups []int, id int) []int {
+	return slices.DeleteFunc(slices.Clone(groups), func(g int) bool {
+		return g == id
+	})
+}
@@ -0,0 +1,180 @@
+package simulator
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Legacy is an in-memory Pi-hole v5 admin/api.php endpoint. It implements the
+// customdns and customcname lists and is safe for concurrent use.
+type Legacy struct {
+	mu           sync.Mutex
+	token        string
+	hosts        []string
+	cnameRecords []string
+	mux          *http.ServeMux
+}
+
+// NewLegacy returns a Legacy simulator accepting the given API token.
+func NewLegacy(token string) *Legacy {
+	s := &Legacy{
+		token:        token,
+		hosts:        []string{},
+		cnameRecords: []string{},
+		mux:          http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/admin/api.php", s.api)
+
+	return s
+}
+
+// NewLegacyServer starts a Legacy simulator accepting the given API token on a
+// local httptest.Server. The URL of the API is server.URL + "/admin/api.php".
+func NewLegacyServer(token string) (*httptest.Server, *Legacy) {
+	s := NewLegacy(token)
+
+	return httptest.NewServer(s), s
+}
+
+func (s *Legacy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Hosts returns the local A/AAAA records in the format "IP domain".
+func (s *Legacy) Hosts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.hosts)
+}
+
+// SetHosts replaces all local A/AAAA records.
+func (s *Legacy) SetHosts(hosts ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hosts = append([]string{}, hosts...)
+}
+
+// CNAMERecords returns the local CNAME records in the format "domain,target".
+func (s *Legacy) CNAMERecords() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.cnameRecords)
+}
+
+// SetCNAMERecords replaces all local CNAME records.
+func (s *Legacy) SetCNAMERecords(records ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cnameRecords = append([]string{}, records...)
+}
+
+func (s *Legacy) api(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Has("version") {
+		writeJSON(w, http.StatusOK, map[string]any{"version": 3})
+		return
+	}
+
+	// like api.php, answer everything that isn't authorized with an empty array
+	if query.Get("auth") != s.token {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case query.Has("customdns"):
+		s.list(w, query.Get("action"), &s.hosts, query.Get("domain"), query.Get("ip"), func(domain, ip string) string {
+			return ip + " " + domain
+		}, func(ip string) bool {
+			return net.ParseIP(ip) != nil
+		})
+	case query.Has("customcname"):
+		s.list(w, query.Get("action"), &s.cnameRecords, query.Get("domain"), query.Get("target"), func(domain, target string) string {
+			return domain + "," + target
+		}, func(target string) bool {
+			return target != ""
+		})
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}
+}
+
+// list handles the actions of a custom DNS list, s.mu must be held. Like v5, it
+// allows only a single entry per domain and address family.
+func (s *Legacy) list(w http.ResponseWriter, action string, items *[]string, domain string, target string,
+	format func(string, string) string, valid func(string) bool) {
+	switch action {
+	case "get":
+		data := [][]string{}
+		for _, item := range *items {
+			data = append(data, parseLegacyItem(item))
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{"data": data})
+	case "add":
+		if domain == "" || !valid(target) {
+			writeLegacyResult(w, false, "Invalid domain or target")
+			return
+		}
+
+		for _, item := range *items {
+			existing := parseLegacyItem(item)
+			if existing[0] == domain && isIPv6(existing[1]) == isIPv6(target) {
+				writeLegacyResult(w, false, "This domain already has a custom DNS entry")
+				return
+			}
+		}
+
+		*items = append(*items, format(domain, target))
+
+		writeLegacyResult(w, true, "")
+	case "delete":
+		i := slices.Index(*items, format(domain, target))
+		if i < 0 {
+			writeLegacyResult(w, false, "This domain/target association does not exist")
+			return
+		}
+
+		*items = slices.Delete(*items, i, i+1)
+
+		writeLegacyResult(w, true, "")
+	default:
+		writeLegacyResult(w, false, "Invalid action")
+	}
+}
+
+func isIPv6(target string) bool {
+	ip := net.ParseIP(target)
+
+	return ip != nil && ip.To4() == nil
+}
+
+// parseLegacyItem returns domain and target of an item in either list format
+func parseLegacyItem(item string) []string {
+	if domain, target, ok := strings.Cut(item, ","); ok {
+		return []string{domain, target}
+	}
+
+	ip, domain, _ := strings.Cut(item, " ")
+
+	return []string{domain, ip}
+}
+
+func writeLegacyResult(w http.ResponseWriter, success bool, message string) {
+	writeJSON(w, http.StatusOK, map[string]any{"success": success, "message": message})
+}
@@ -0,0 +1,170 @@
+package simulator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// List is an adlist as stored by the simulator
+type List struct {
+	ID          int     `json:"id"`
+	Address     string  `json:"address"`
+	Type        string  `json:"type"`
+	Comment     *string `json:"comment"`
+	Groups      []int   `json:"groups"`
+	Enabled     bool    `json:"enabled"`
+	Number      int     `json:"number"`
+	DateUpdated int64   `json:"date_updated"`
+}
+
+// Lists returns all adlists.
+func (s *Simulator) Lists() []List {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.lists)
+}
+
+// SetLists replaces all adlists, ids are assigned to lists without one.
+func (s *Simulator) SetLists(lists ...List) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lists = nil
+	for _, list := range lists {
+		if list.ID == 0 {
+			list.ID = s.nextID()
+		}
+		s.lists = append(s.lists, list)
+	}
+}
+
+// nextID returns a new id for a list, group, domain or client, s.mu must be held.
+func (s *Simulator) nextID() int {
+	s.lastID++
+
+	return s.lastID
+}
+
+func (s *Simulator) getLists(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"lists": s.lists})
+}
+
+// createList adds a list, like FTL it reports duplicates in the processed errors
+// instead of failing the request
+func (s *Simulator) createList(w http.ResponseWriter, r *http.Request) {
+	var request List
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+
+	if !validListType(request.Type) {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid list type", request.Type)
+		return
+	}
+	if u, err := url.Parse(request.Address); err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid address", request.Address)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.indexOfList(request.Address, request.Type) >= 0 {
+		writeProcessed(w, http.StatusCreated, "lists", []List{}, request.Address, "UNIQUE constraint failed: adlist.address, adlist.type")
+		return
+	}
+
+	request.ID = s.nextID()
+	request.Number = 0
+	request.DateUpdated = 0
+	if len(request.Groups) == 0 {
+		request.Groups = []int{0}
+	}
+	s.lists = append(s.lists, request)
+
+	writeProcessed(w, http.StatusCreated, "lists", []List{request}, request.Address, "")
+}
+
+func (s *Simulator) updateList(w http.ResponseWriter, r *http.Request) {
+	var request List
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+
+	address := r.PathValue("address")
+	listType := r.URL.Query().Get("type")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfList(address, listType)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "List not found", address)
+		return
+	}
+
+	list := &s.lists[i]
+	list.Comment = request.Comment
+	list.Groups = request.Groups
+	list.Enabled = request.Enabled
+
+	writeProcessed(w, http.StatusOK, "lists", []List{*list}, address, "")
+}
+
+func (s *Simulator) deleteList(w http.ResponseWriter, r *http.Request) {
+	address := r.PathValue("address")
+	listType := r.URL.Query().Get("type")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOfList(address, listType)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "not_found", "List not found", address)
+		return
+	}
+
+	s.lists = slices.Delete(s.lists, i, i+1)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// indexOfList returns the index of the list with the given address and type, s.mu must be held.
+// FTL defaults to block lists if no type is given.
+func (s *Simulator) indexOfList(address string, listType string) int {
+	if listType == "" {
+		listType = "block"
+	}
+
+	return slices.IndexFunc(s.lists, func(l List) bool {
+		return l.Address == address && l.Type == listType
+	})
+}
+
+func validListType(listType string) bool {
+	return listType == "block" || listType == "allow"
+}
+
+// writeProcessed answers a write to a list endpoint, FTL reports the items that
+// failed in the body instead of the status code
+func writeProcessed[T any](w http.ResponseWriter, status int, key string, items []T, item string, failure string) {
+	processed := map[string]any{
+		"success": []map[string]string{},
+		"errors":  []map[string]string{},
+	}
+	if failure == "" {
+		processed["success"] = []map[string]string{{"item": item}}
+	} else {
+		processed["errors"] = []map[string]string{{"item": item, "error": failure}}
+	}
+
+	writeJSON(w, status, map[string]any{key: items, "processed": processed})
+}
@@ -0,0 +1,613 @@
+// Package simulator provides a local stand-in for the Pi-hole v6 REST API. It
+// implements the parts of the API used by the pihole client, including sessions
+// and the status codes returned by FTL, and can be started in-process with
+// httptest or as a standalone server (see cmd/simulator). Legacy stands in for
+// the admin/api.php endpoint of Pi-hole v5.
+package simulator
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/domnikl/pihole-operator/internal/pihole/totp"
+)
+
+const (
+	// DefaultSessionValidity is the validity of a session used by FTL by default
+	DefaultSessionValidity = 30 * time.Minute
+	// DefaultMaxSessions is the number of concurrent sessions allowed by FTL by default
+	DefaultMaxSessions = 16
+)
+
+// Simulator is an in-memory Pi-hole v6 API. It is safe for concurrent use.
+type Simulator struct {
+	// SessionValidity is the time a session stays valid without being used
+	SessionValidity time.Duration
+	// MaxSessions is the maximum number of concurrent sessions
+	MaxSessions int
+	// Latency delays every response, it is used to simulate a slow or hanging Pi-hole
+	Latency time.Duration
+	// TOTPSecret enables two-factor authentication, logins need to provide the
+	// current code generated from this base32 encoded secret, like FTL a code
+	// is only accepted once
+	TOTPSecret string
+	// GravityDelay delays every line of the output of a gravity update, it is used
+	// to simulate an update taking longer than the timeout of a request
+	GravityDelay time.Duration
+
+	mu           sync.Mutex
+	password     string
+	sessions     map[string]time.Time
+	logins       int
+	requests     int
+	failures     []failure
+	reads        int
+	writes       int
+	hosts        []string
+	cnameRecords []string
+	lists        []List
+	groups       []Group
+	domains      []Domain
+	clients      []Client
+	gravity      []string
+	gravityRuns  int
+	blocking     bool
+	blockingEnd  time.Time
+	lastID       int
+	lastTOTP     string
+	now          func() time.Time
+	mux          *http.ServeMux
+}
+
+// New returns a Simulator accepting the given app password.
+func New(password string) *Simulator {
+	s := &Simulator{
+		SessionValidity: DefaultSessionValidity,
+		MaxSessions:     DefaultMaxSessions,
+		password:        password,
+		sessions:        map[string]time.Time{},
+		hosts:           []string{},
+		cnameRecords:    []string{},
+		lists:           []List{},
+		domains:         []Domain{},
+		clients:         []Client{},
+		groups:          []Group{{ID: 0, Name: "Default", Enabled: true}},
+		blocking:        true,
+		now:             time.Now,
+		mux:             http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("POST /api/auth", s.login)
+	s.mux.HandleFunc("GET /api/auth", s.getSession)
+	s.mux.HandleFunc("DELETE /api/auth", s.authenticated(s.logout))
+
+	s.mux.HandleFunc("GET /api/config", s.authenticated(s.getConfig))
+	s.mux.HandleFunc("PATCH /api/config", s.authenticated(s.patchConfig))
+	s.mux.HandleFunc("GET /api/config/dns", s.authenticated(s.getConfig))
+	s.mux.HandleFunc("GET /api/config/dns/hosts", s.authenticated(s.getConfig))
+	s.mux.HandleFunc("PUT /api/config/dns/hosts/{value}", s.authenticated(s.addItem(&s.hosts, validHost)))
+	s.mux.HandleFunc("DELETE /api/config/dns/hosts/{value}", s.authenticated(s.deleteItem(&s.hosts)))
+	s.mux.HandleFunc("GET /api/config/dns/cnameRecords", s.authenticated(s.getConfig))
+	s.mux.HandleFunc("PUT /api/config/dns/cnameRecords/{value}", s.authenticated(s.addItem(&s.cnameRecords, validCNAMERecord)))
+	s.mux.HandleFunc("DELETE /api/config/dns/cnameRecords/{value}", s.authenticated(s.deleteItem(&s.cnameRecords)))
+
+	s.mux.HandleFunc("GET /api/lists", s.authenticated(s.getLists))
+	s.mux.HandleFunc("POST /api/lists", s.authenticated(s.createList))
+	s.mux.HandleFunc("PUT /api/lists/{address}", s.authenticated(s.updateList))
+	s.mux.HandleFunc("DELETE /api/lists/{address}", s.authenticated(s.deleteList))
+	s.mux.HandleFunc("GET /api/groups", s.authenticated(s.getGroups))
+	s.mux.HandleFunc("POST /api/groups", s.authenticated(s.createGroup))
+	s.mux.HandleFunc("PUT /api/groups/{name}", s.authenticated(s.updateGroup))
+	s.mux.HandleFunc("DELETE /api/groups/{name}", s.authenticated(s.deleteGroup))
+	s.mux.HandleFunc("GET /api/domains", s.authenticated(s.getDomains))
+	s.mux.HandleFunc("POST /api/domains/{type}/{kind}", s.authenticated(s.createDomain))
+	s.mux.HandleFunc("PUT /api/domains/{type}/{kind}/{domain}", s.authenticated(s.updateDomain))
+	s.mux.HandleFunc("DELETE /api/domains/{type}/{kind}/{domain}", s.authenticated(s.deleteDomain))
+	s.mux.HandleFunc("GET /api/clients", s.authenticated(s.getClients))
+	s.mux.HandleFunc("POST /api/clients", s.authenticated(s.createClient))
+	s.mux.HandleFunc("PUT /api/clients/{client}", s.authenticated(s.updateClient))
+	s.mux.HandleFunc("DELETE /api/clients/{client}", s.authenticated(s.deleteClient))
+
+	s.mux.HandleFunc("POST /api/action/gravity", s.authenticated(s.updateGravity))
+	s.mux.HandleFunc("GET /api/dns/blocking", s.authenticated(s.getBlocking))
+	s.mux.HandleFunc("POST /api/dns/blocking", s.authenticated(s.setBlocking))
+
+	return s
+}
+
+// NewServer starts a Simulator accepting the given app password on a local
+// httptest.Server. The URL of the API is server.URL + "/api".
+func NewServer(password string) (*httptest.Server, *Simulator) {
+	s := New(password)
+
+	return httptest.NewServer(s), s
+}
+
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Latency > 0 {
+		select {
+		case <-time.After(s.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.mu.Lock()
+	s.requests++
+	status, fail := s.nextFailure(r.Method)
+	s.mu.Unlock()
+
+	if fail {
+		writeError(w, status, "unavailable", http.StatusText(status), "")
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// failure is a request that is answered with an error status
+type failure struct {
+	method string
+	status int
+}
+
+// FailNext answers the next n requests using method with the given status code,
+// as FTL and proxies in front of it do while FTL restarts. An empty method
+// matches requests of all methods.
+func (s *Simulator) FailNext(method string, n int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, failure{method: method, status: status})
+	}
+}
+
+// nextFailure removes and returns the first pending failure matching method, s.mu must be held.
+func (s *Simulator) nextFailure(method string) (int, bool) {
+	i := slices.IndexFunc(s.failures, func(f failure) bool {
+		return f.method == "" || f.method == method
+	})
+	if i < 0 {
+		return 0, false
+	}
+
+	status := s.failures[i].status
+	s.failures = slices.Delete(s.failures, i, i+1)
+
+	return status, true
+}
+
+// Hosts returns the local A/AAAA records in the format "IP domain".
+func (s *Simulator) Hosts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.hosts)
+}
+
+// SetHosts replaces all local A/AAAA records.
+func (s *Simulator) SetHosts(hosts ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hosts = append([]string{}, hosts...)
+}
+
+// CNAMERecords returns the local CNAME records in the format "domain,target[,ttl]".
+func (s *Simulator) CNAMERecords() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.cnameRecords)
+}
+
+// SetCNAMERecords replaces all local CNAME records.
+func (s *Simulator) SetCNAMERecords(records ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cnameRecords = append([]string{}, records...)
+}
+
+// Logins returns the number of successful logins.
+func (s *Simulator) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.logins
+}
+
+// Requests returns the number of requests received, including failed ones.
+func (s *Simulator) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// Reads returns the number of requests that read the DNS configuration.
+func (s *Simulator) Reads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reads
+}
+
+// Writes returns the number of requests that changed the DNS configuration, FTL
+// restarts its resolver for each of them.
+func (s *Simulator) Writes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.writes
+}
+
+// Sessions returns the number of currently valid sessions.
+func (s *Simulator) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireSessions()
+
+	return len(s.sessions)
+}
+
+// SetClock replaces the clock of the simulator, which is used for TOTP codes and
+// the expiry of sessions and timers.
+func (s *Simulator) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.now = now
+}
+
+// ExpireSessions invalidates all sessions, as it happens when FTL restarts.
+func (s *Simulator) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions = map[string]time.Time{}
+}
+
+func (s *Simulator) login(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Password string `json:"password"`
+		TOTP     *int   `json:"totp"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid JSON payload", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.TOTPSecret != "" {
+		if request.TOTP == nil {
+			writeError(w, http.StatusBadRequest, "bad_request", "No 2FA token found in JSON payload", "")
+			return
+		}
+
+		code := fmt.Sprintf("%0*d", totp.Digits, *request.TOTP)
+		if !totp.Validate(s.TOTPSecret, code, s.now()) {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{
+				"session": session{Valid: false, TOTP: true, Validity: -1, Message: "2FA token invalid"},
+			})
+			return
+		}
+
+		if code == s.lastTOTP {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{
+				"session": session{Valid: false, TOTP: true, Validity: -1, Message: "2FA token has already been used"},
+			})
+			return
+		}
+		s.lastTOTP = code
+	}
+
+	if request.Password != s.password {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{
+			"session": session{Valid: false, TOTP: s.TOTPSecret != "", Validity: -1, Message: "password incorrect"},
+		})
+		return
+	}
+
+	s.expireSessions()
+	if len(s.sessions) >= s.MaxSessions {
+		writeError(w, http.StatusTooManyRequests, "api_seats_exceeded", "API seats exceeded", "increase webserver.api.max_sessions")
+		return
+	}
+
+	sid := randomString()
+	s.sessions[sid] = s.now().Add(s.SessionValidity)
+	s.logins++
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"session": session{
+			Valid:    true,
+			TOTP:     s.TOTPSecret != "",
+			SID:      &sid,
+			CSRF:     randomString(),
+			Validity: int(s.SessionValidity.Seconds()),
+			Message:  "password correct",
+		},
+	})
+}
+
+func (s *Simulator) getSession(w http.ResponseWriter, r *http.Request) {
+	sid := sessionID(r)
+
+	s.mu.Lock()
+	s.expireSessions()
+	expiry, ok := s.sessions[sid]
+	s.mu.Unlock()
+
+	// like FTL, report the missing session instead of a plain error
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{
+			"session": session{Valid: false, TOTP: s.TOTPSecret != "", Validity: -1, Message: "no valid session"},
+		})
+		return
+	}
+
+	validity := expiry.Sub(s.now())
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"session": session{
+			Valid:    true,
+			SID:      &sid,
+			Validity: int(validity.Seconds()),
+			Message:  "correct password",
+		},
+	})
+}
+
+func (s *Simulator) logout(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	delete(s.sessions, sessionID(r))
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Simulator) getConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reads++
+
+	dns := map[string]any{}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/hosts"):
+		dns["hosts"] = s.hosts
+	case strings.HasSuffix(r.URL.Path, "/cnameRecords"):
+		dns["cnameRecords"] = s.cnameRecords
+	default:
+		dns["hosts"] = s.hosts
+		dns["cnameRecords"] = s.cnameRecords
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"config": map[string]any{"dns": dns},
+	})
+}
+
+// patchConfig replaces dns.hosts and dns.cnameRecords as a whole, other settings
+// are not supported
+func (s *Simulator) patchConfig(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Config struct {
+			DNS struct {
+				Hosts        *[]string `json:"hosts"`
+				CNAMERecords *[]string `json:"cnameRecords"`
+			} `json:"dns"`
+		} `json:"config"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "Invalid request body", err.Error())
+		return
+	}
+
+	lists := []struct {
+		items *[]string
+		value *[]string
+		valid func(string) bool
+	}{
+		{&s.hosts, request.Config.DNS.Hosts, validHost},
+		{&s.cnameRecords, request.Config.DNS.CNAMERecords, validCNAMERecord},
+	}
+
+	// validate everything first, the config is changed completely or not at all
+	for _, list := range lists {
+		if list.value == nil {
+			continue
+		}
+
+		seen := map[string]bool{}
+		for _, value := range *list.value {
+			if !list.valid(value) || seen[value] {
+				writeError(w, http.StatusBadRequest, "bad_request", "Invalid value", value)
+				return
+			}
+			seen[value] = true
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, list := range lists {
+		if list.value != nil {
+			*list.items = slices.Clone(*list.value)
+		}
+	}
+	s.writes++
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"config": map[string]any{"dns": map[string]any{"hosts": s.hosts, "cnameRecords": s.cnameRecords}},
+	})
+}
+
+func (s *Simulator) addItem(items *[]string, valid func(string) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		value := r.PathValue("value")
+		if !valid(value) {
+			writeError(w, http.StatusBadRequest, "bad_request", "Invalid value", value)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if slices.Contains(*items, value) {
+			writeError(w, http.StatusBadRequest, "bad_request", "Item already present", "Uniqueness of items is enforced")
+			return
+		}
+
+		*items = append(*items, value)
+		s.writes++
+
+		writeJSON(w, http.StatusCreated, map[string]any{})
+	}
+}
+
+func (s *Simulator) deleteItem(items *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		value := r.PathValue("value")
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		i := slices.Index(*items, value)
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "not_found", "Item not found", value)
+			return
+		}
+
+		*items = slices.Delete(*items, i, i+1)
+		s.writes++
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// authenticated rejects requests without a valid session id and extends the
+// validity of the session otherwise.
+func (s *Simulator) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sid := sessionID(r)
+
+		s.mu.Lock()
+		s.expireSessions()
+		_, ok := s.sessions[sid]
+		if ok {
+			s.sessions[sid] = s.now().Add(s.SessionValidity)
+		}
+		s.mu.Unlock()
+
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "unauthorized", "Unauthorized", "")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// expireSessions removes all expired sessions, s.mu must be held.
+func (s *Simulator) expireSessions() {
+	now := s.now()
+	for sid, expiry := range s.sessions {
+		if now.After(expiry) {
+			delete(s.sessions, sid)
+		}
+	}
+}
+
+type session struct {
+	Valid    bool    `json:"valid"`
+	TOTP     bool    `json:"totp"`
+	SID      *string `json:"sid"`
+	CSRF     string  `json:"csrf,omitempty"`
+	Validity int     `json:"validity"`
+	Message  string  `json:"message"`
+}
+
+func sessionID(r *http.Request) string {
+	if sid := r.Header.Get("X-FTL-SID"); sid != "" {
+		return sid
+	}
+	if sid := r.Header.Get("sid"); sid != "" {
+		return sid
+	}
+
+	return r.URL.Query().Get("sid")
+}
+
+// validHost checks for the "IP domain [domain ...]" format used in dns.hosts.
+func validHost(value string) bool {
+	parts := strings.Fields(value)
+	if len(parts) < 2 {
+		return false
+	}
+
+	return net.ParseIP(parts[0]) != nil
+}
+
+// validCNAMERecord checks for the "domain,target[,ttl]" format used in dns.cnameRecords.
+func validCNAMERecord(value string) bool {
+	parts := strings.Split(value, ",")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return false
+	}
+
+	if len(parts) == 3 {
+		if _, err := strconv.ParseUint(parts[2], 10, 32); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func randomString() string {
+	b := make([]byte, 18)
+	_, _ = rand.Read(b)
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func writeError(w http.ResponseWriter, status int, key string, message string, hint string) {
+	var h *string
+	if hint != "" {
+		h = &hint
+	}
+
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{
+			"key":     key,
+			"message": message,
+			"hint":    h,
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body map[string]any) {
+	body["took"] = 0.0001
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,83 @@
+// Package totp implements time-based one-time passwords as specified in RFC 6238,
+// using the parameters Pi-hole expects: HMAC-SHA1, 30 second steps and 6 digits.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // mandated by RFC 6238 and used by Pi-hole
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is the time a code stays valid
+	Period = 30 * time.Second
+	// Digits is the number of digits of a code
+	Digits = 6
+)
+
+// Generate returns the code for the base32 encoded secret at the given time.
+func Generate(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return generate(key, counter(t)), nil
+}
+
+// Validate checks a code against the base32 encoded secret at the given time. To
+// compensate for clock drift, codes of the previous and the next period are accepted too.
+func Validate(secret string, code string, t time.Time) bool {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return false
+	}
+
+	c := counter(t)
+	for _, step := range []uint64{c - 1, c, c + 1} {
+		if hmac.Equal([]byte(generate(key, step)), []byte(code)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	secret = strings.TrimRight(secret, "=")
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	if len(key) == 0 {
+		return nil, fmt.Errorf("invalid TOTP secret: secret is empty")
+	}
+
+	return key, nil
+}
+
+func counter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(Period.Seconds()))
+}
+
+// generate implements HOTP (RFC 4226) for the given counter
+func generate(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
@@ -0,0 +1,57 @@
+package totp
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TOTP", func() {
+	// base32 encoding of the secret "12345678901234567890" used in RFC 6238
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	// the RFC lists 8 digit codes, Pi-hole uses the last 6 of them
+	DescribeTable("generating codes",
+		func(unix int64, expected string) {
+			code, err := Generate(secret, time.Unix(unix, 0))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(code).To(Equal(expected))
+		},
+		Entry("59", int64(59), "287082"),
+		Entry("1111111109", int64(1111111109), "081804"),
+		Entry("1111111111", int64(1111111111), "050471"),
+		Entry("1234567890", int64(1234567890), "005924"),
+		Entry("2000000000", int64(2000000000), "279037"),
+		Entry("20000000000", int64(20000000000), "353130"),
+	)
+
+	It("should accept lowercase secrets with spaces and padding", func() {
+		code, err := Generate("gezd gnbv gy3t qojq gezd gnbv gy3t qojq===", time.Unix(59, 0))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(code).To(Equal("287082"))
+	})
+
+	It("should reject invalid secrets", func() {
+		_, err := Generate("not base32!", time.Now())
+		Expect(err).To(MatchError(ContainSubstring("invalid TOTP secret")))
+
+		_, err = Generate("", time.Now())
+		Expect(err).To(MatchError(ContainSubstring("invalid TOTP secret")))
+	})
+
+	DescribeTable("validating codes",
+		func(offset time.Duration, valid bool) {
+			now := time.Unix(1111111111, 0)
+			code, err := Generate(secret, now.Add(offset))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(Validate(secret, code, now)).To(Equal(valid))
+		},
+		Entry("current period", time.Duration(0), true),
+		Entry("previous period", -Period, true),
+		Entry("next period", Period, true),
+		Entry("two periods ago", -2*Period, false),
+		Entry("in two periods", 2*Period, false),
+	)
+})
@@ -0,0 +1,441 @@
+package pihole
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the major version of the Pi-hole API
+type Version string
+
+const (
+	// V5 is the api.php endpoint of Pi-hole v5
+	V5 Version = "v5"
+	// V6 is the REST API of Pi-hole v6
+	V6 Version = "v6"
+)
+
+// BaseURL strips the path of the API from a URL, so a Pi-hole can be configured
+// by its address as well as by the URL of either API.
+func BaseURL(url string) string {
+	url = strings.TrimRight(url, "/")
+
+	for _, suffix := range []string{"/admin/api.php", "/api"} {
+		if strings.HasSuffix(url, suffix) {
+			return strings.TrimSuffix(url, suffix)
+		}
+	}
+
+	return url
+}
+
+// DetectVersion asks the Pi-hole at url which API it speaks. Pi-hole v6 answers
+// /api/auth with a session, v5 answers admin/api.php?version with its version.
+func DetectVersion(ctx context.Context, httpClient *http.Client, url string) (Version, error) {
+	base := BaseURL(url)
+
+	var v6 struct {
+		Session *struct{} `json:"session"`
+	}
+	ok, err := probe(ctx, httpClient, base+"/api/auth", &v6)
+	if err != nil {
+		return "", err
+	}
+	if ok && v6.Session != nil {
+		return V6, nil
+	}
+
+	var v5 struct {
+		Version *int `json:"version"`
+	}
+	ok, err = probe(ctx, httpClient, base+"/admin/api.php?version", &v5)
+	if err != nil {
+		return "", err
+	}
+	if ok && v5.Version != nil {
+		return V5, nil
+	}
+
+	return "", fmt.Errorf("failed to detect the API version of the Pi-hole at %s", base)
+}
+
+// probe requests url and decodes the response into v, it returns false if the
+// response is not the expected JSON
+func probe(ctx context.Context, httpClient *http.Client, url string, v any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	// v6 answers unauthenticated requests to /api/auth with 401 and a session
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		return false, nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v) == nil, nil
+}
+
+// detectingClient detects the API version when it connects to the Pi-hole for
+// the first time and then delegates to the matching backend
+type detectingClient struct {
+	config Config
+
+	mu     sync.Mutex
+	client Client
+}
+
+var (
+	_ Client         = &detectingClient{}
+	_ ListClient     = &detectingClient{}
+	_ GroupClient    = &detectingClient{}
+	_ DomainClient   = &detectingClient{}
+	_ ClientClient   = &detectingClient{}
+	_ GravityClient  = &detectingClient{}
+	_ BlockingClient = &detectingClient{}
+)
+
+func (d *detectingClient) connect(ctx context.Context) (Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.client != nil {
+		return d.client, nil
+	}
+
+	detectCtx := ctx
+	if d.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		detectCtx, cancel = context.WithTimeout(ctx, d.config.Timeout)
+		defer cancel()
+	}
+
+	version, err := DetectVersion(detectCtx, d.config.HTTPClient, d.config.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	d.client = newClient(d.config, version)
+
+	return d.client, nil
+}
+
+func (d *detectingClient) GetDNSRecords(ctx context.Context) ([]DNSRecord, error) {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.GetDNSRecords(ctx)
+}
+
+func (d *detectingClient) ReplaceDNSRecords(ctx context.Context, oldRecords []DNSRecord, newRecords []DNSRecord) error {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	return client.ReplaceDNSRecords(ctx, oldRecords, newRecords)
+}
+
+func (d *detectingClient) CreateDNSRecord(ctx context.Context, record DNSRecord) error {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	return client.CreateDNSRecord(ctx, record)
+}
+
+func (d *detectingClient) DeleteDNSRecord(ctx context.Context, record DNSRecord) error {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	return client.DeleteDNSRecord(ctx, record)
+}
+
+func (d *detectingClient) Close(ctx context.Context) error {
+	d.mu.Lock()
+	client := d.client
+	d.mu.Unlock()
+
+	if client == nil {
+		// never connected
+		return nil
+	}
+
+	return client.Close(ctx)
+}
+
+// lists returns the adlist API of the detected client
+func (d *detectingClient) lists(ctx context.Context) (ListClient, error) {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lists, ok := client.(ListClient)
+	if !ok {
+		return nil, fmt.Errorf("adlists are %w, they require Pi-hole v6", ErrUnsupported)
+	}
+
+	return lists, nil
+}
+
+func (d *detectingClient) GetLists(ctx context.Context) ([]List, error) {
+	lists, err := d.lists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return lists.GetLists(ctx)
+}
+
+func (d *detectingClient) CreateList(ctx context.Context, list List) (List, error) {
+	lists, err := d.lists(ctx)
+	if err != nil {
+		return List{}, err
+	}
+
+	return lists.CreateList(ctx, list)
+}
+
+func (d *detectingClient) UpdateList(ctx context.Context, list List) (List, error) {
+	lists, err := d.lists(ctx)
+	if err != nil {
+		return List{}, err
+	}
+
+	return lists.UpdateList(ctx, list)
+}
+
+func (d *detectingClient) DeleteList(ctx context.Context, address string, listType ListType) error {
+	lists, err := d.lists(ctx)
+	if err != nil {
+		return err
+	}
+
+	return lists.DeleteList(ctx, address, listType)
+}
+
+// groups returns the group API of the detected client
+func (d *detectingClient) groups(ctx context.Context) (GroupClient, error) {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, ok := client.(GroupClient)
+	if !ok {
+		return nil, fmt.Errorf("groups are %w, they require Pi-hole v6", ErrUnsupported)
+	}
+
+	return groups, nil
+}
+
+func (d *detectingClient) GetGroups(ctx context.Context) ([]Group, error) {
+	groups, err := d.groups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return groups.GetGroups(ctx)
+}
+
+func (d *detectingClient) CreateGroup(ctx context.Context, group Group) (Group, error) {
+	groups, err := d.groups(ctx)
+	if err != nil {
+		return Group{}, err
+	}
+
+	return groups.CreateGroup(ctx, group)
+}
+
+func (d *detectingClient) UpdateGroup(ctx context.Context, group Group) (Group, error) {
+	groups, err := d.groups(ctx)
+	if err != nil {
+		return Group{}, err
+	}
+
+	return groups.UpdateGroup(ctx, group)
+}
+
+func (d *detectingClient) DeleteGroup(ctx context.Context, name string) error {
+	groups, err := d.groups(ctx)
+	if err != nil {
+		return err
+	}
+
+	return groups.DeleteGroup(ctx, name)
+}
+
+// domains returns the domain API of the detected client
+func (d *detectingClient) domains(ctx context.Context) (DomainClient, error) {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	domains, ok := client.(DomainClient)
+	if !ok {
+		return nil, fmt.Errorf("domain rules are %w, they require Pi-hole v6", ErrUnsupported)
+	}
+
+	return domains, nil
+}
+
+func (d *detectingClient) GetDomains(ctx context.Context) ([]Domain, error) {
+	domains, err := d.domains(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return domains.GetDomains(ctx)
+}
+
+func (d *detectingClient) CreateDomain(ctx context.Context, domain Domain) (Domain, error) {
+	domains, err := d.domains(ctx)
+	if err != nil {
+		return Domain{}, err
+	}
+
+	return domains.CreateDomain(ctx, domain)
+}
+
+func (d *detectingClient) UpdateDomain(ctx context.Context, domain Domain) (Domain, error) {
+	domains, err := d.domains(ctx)
+	if err != nil {
+		return Domain{}, err
+	}
+
+	return domains.UpdateDomain(ctx, domain)
+}
+
+func (d *detectingClient) DeleteDomain(ctx context.Context, domain string, domainType DomainType, kind DomainKind) error {
+	domains, err := d.domains(ctx)
+	if err != nil {
+		return err
+	}
+
+	return domains.DeleteDomain(ctx, domain, domainType, kind)
+}
+
+// clients returns the client API of the detected client
+func (d *detectingClient) clients(ctx context.Context) (ClientClient, error) {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clients, ok := client.(ClientClient)
+	if !ok {
+		return nil, fmt.Errorf("clients are %w, they require Pi-hole v6", ErrUnsupported)
+	}
+
+	return clients, nil
+}
+
+func (d *detectingClient) GetClients(ctx context.Context) ([]PiHoleClient, error) {
+	clients, err := d.clients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return clients.GetClients(ctx)
+}
+
+func (d *detectingClient) CreateClient(ctx context.Context, client PiHoleClient) (PiHoleClient, error) {
+	clients, err := d.clients(ctx)
+	if err != nil {
+		return PiHoleClient{}, err
+	}
+
+	return clients.CreateClient(ctx, client)
+}
+
+func (d *detectingClient) UpdateClient(ctx context.Context, client PiHoleClient) (PiHoleClient, error) {
+	clients, err := d.clients(ctx)
+	if err != nil {
+		return PiHoleClient{}, err
+	}
+
+	return clients.UpdateClient(ctx, client)
+}
+
+func (d *detectingClient) DeleteClient(ctx context.Context, client string) error {
+	clients, err := d.clients(ctx)
+	if err != nil {
+		return err
+	}
+
+	return clients.DeleteClient(ctx, client)
+}
+
+// gravity returns the gravity API of the detected client
+func (d *detectingClient) gravity(ctx context.Context) (GravityClient, error) {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gravity, ok := client.(GravityClient)
+	if !ok {
+		return nil, fmt.Errorf("gravity updates are %w, they require Pi-hole v6", ErrUnsupported)
+	}
+
+	return gravity, nil
+}
+
+func (d *detectingClient) UpdateGravity(ctx context.Context, progress func(line string)) error {
+	gravity, err := d.gravity(ctx)
+	if err != nil {
+		return err
+	}
+
+	return gravity.UpdateGravity(ctx, progress)
+}
+
+// blocking returns the blocking API of the detected client
+func (d *detectingClient) blocking(ctx context.Context) (BlockingClient, error) {
+	client, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blocking, ok := client.(BlockingClient)
+	if !ok {
+		return nil, fmt.Errorf("blocking schedules are %w, they require Pi-hole v6", ErrUnsupported)
+	}
+
+	return blocking, nil
+}
+
+func (d *detectingClient) GetBlocking(ctx context.Context) (Blocking, error) {
+	blocking, err := d.blocking(ctx)
+	if err != nil {
+		return Blocking{}, err
+	}
+
+	return blocking.GetBlocking(ctx)
+}
+
+func (d *detectingClient) SetBlocking(ctx context.Context, enabled bool, timer time.Duration) (Blocking, error) {
+	blocking, err := d.blocking(ctx)
+	if err != nil {
+		return Blocking{}, err
+	}
+
+	return blocking.SetBlocking(ctx, enabled, timer)
+}
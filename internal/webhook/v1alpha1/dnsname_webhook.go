@@ -0,0 +1,203 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+// log is for logging in this package.
+var dnsnamelog = logf.Log.WithName("dnsname-resource")
+
+// SetupDNSNameWebhookWithManager registers the webhook for DNSName in the manager.
+func SetupDNSNameWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&networkingv1alpha1.DNSName{}).
+		WithValidator(&DNSNameCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-networking-liebler-dev-v1alpha1-dnsname,mutating=false,failurePolicy=fail,sideEffects=None,groups=networking.liebler.dev,resources=dnsnames,verbs=create;update,versions=v1alpha1,name=vdnsname-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// DNSNameCustomValidator rejects DNSNames with an invalid spec and DNSNames that
+// obviously conflict with an existing one, which is the case if both have
+// conflicting records for the same domain and reference the same instance. Claims
+// through selectors are only resolved by the controller, they result in a warning.
+type DNSNameCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &DNSNameCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type DNSName.
+func (v *DNSNameCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	dnsName, ok := obj.(*networkingv1alpha1.DNSName)
+	if !ok {
+		return nil, fmt.Errorf("expected a DNSName object but got %T", obj)
+	}
+	dnsnamelog.Info("Validation for DNSName upon creation", "name", dnsName.GetName())
+
+	return v.validate(ctx, dnsName, true)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type DNSName.
+func (v *DNSNameCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	dnsName, ok := newObj.(*networkingv1alpha1.DNSName)
+	if !ok {
+		return nil, fmt.Errorf("expected a DNSName object for the newObj but got %T", newObj)
+	}
+	oldDNSName, ok := oldObj.(*networkingv1alpha1.DNSName)
+	if !ok {
+		return nil, fmt.Errorf("expected a DNSName object for the oldObj but got %T", oldObj)
+	}
+	dnsnamelog.Info("Validation for DNSName upon update", "name", dnsName.GetName())
+
+	// an existing claim is only checked again if the domain or the instances change,
+	// the controller resolves conflicts between existing DNSNames
+	return v.validate(ctx, dnsName, claimChanged(oldDNSName, dnsName))
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type DNSName.
+func (v *DNSNameCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *DNSNameCustomValidator) validate(ctx context.Context, dnsName *networkingv1alpha1.DNSName, checkConflicts bool) (admission.Warnings, error) {
+	specPath := field.NewPath("spec")
+
+	if _, err := pihole.NewDNSRecordsFromSpec(dnsName.Spec); err != nil {
+		return nil, invalid(dnsName, field.Invalid(specPath, dnsName.Spec, err.Error()))
+	}
+
+	if !checkConflicts {
+		return nil, nil
+	}
+
+	dnsNames := &networkingv1alpha1.DNSNameList{}
+	if err := v.Client.List(ctx, dnsNames); err != nil {
+		return nil, err
+	}
+
+	types := pihole.RecordTypes(dnsName.Spec)
+	refs := explicitInstanceRefs(dnsName)
+
+	var warnings admission.Warnings
+	for i := range dnsNames.Items {
+		other := &dnsNames.Items[i]
+
+		if other.Spec.Domain != dnsName.Spec.Domain || other.UID == dnsName.UID || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if !typesConflict(types, pihole.RecordTypes(other.Spec)) {
+			continue
+		}
+
+		if instancesOverlap(refs, explicitInstanceRefs(other)) {
+			return nil, invalid(dnsName, field.Forbidden(specPath.Child("domain"),
+				fmt.Sprintf("domain %s is already claimed by DNSName %s/%s on the same Pi-hole", dnsName.Spec.Domain, other.Namespace, other.Name)))
+		}
+
+		if dnsName.Spec.InstanceSelector != nil || other.Spec.InstanceSelector != nil {
+			warnings = append(warnings, fmt.Sprintf("domain %s is also claimed by DNSName %s/%s, the older DNSName wins if both select the same Pi-hole",
+				dnsName.Spec.Domain, other.Namespace, other.Name))
+		}
+	}
+
+	return warnings, nil
+}
+
+// claimChanged is true if an update claims the domain anew, either for another domain
+// or on other instances
+func claimChanged(oldDNSName, dnsName *networkingv1alpha1.DNSName) bool {
+	return oldDNSName.Spec.Domain != dnsName.Spec.Domain ||
+		!equality.Semantic.DeepEqual(oldDNSName.Spec.InstanceRef, dnsName.Spec.InstanceRef) ||
+		!equality.Semantic.DeepEqual(oldDNSName.Spec.InstanceRefs, dnsName.Spec.InstanceRefs) ||
+		!equality.Semantic.DeepEqual(oldDNSName.Spec.InstanceSelector, dnsName.Spec.InstanceSelector)
+}
+
+func invalid(dnsName *networkingv1alpha1.DNSName, err *field.Error) error {
+	return apierrors.NewInvalid(networkingv1alpha1.GroupVersion.WithKind("DNSName").GroupKind(), dnsName.Name, field.ErrorList{err})
+}
+
+// instanceKey identifies an instance across namespaces
+type instanceKey struct {
+	kind      networkingv1alpha1.InstanceKind
+	namespace string
+	name      string
+}
+
+// explicitInstanceRefs returns the instances referenced by name, selectors are not resolved
+func explicitInstanceRefs(dnsName *networkingv1alpha1.DNSName) []instanceKey {
+	refs := dnsName.Spec.InstanceRefs
+	if dnsName.Spec.InstanceRef != nil {
+		refs = append([]networkingv1alpha1.InstanceReference{*dnsName.Spec.InstanceRef}, refs...)
+	}
+
+	keys := make([]instanceKey, 0, len(refs))
+	for _, ref := range refs {
+		keys = append(keys, explicitInstanceRef(dnsName.Namespace, ref))
+	}
+
+	return keys
+}
+
+// explicitInstanceRef returns the instance referenced from an object in the given namespace
+func explicitInstanceRef(namespace string, ref networkingv1alpha1.InstanceReference) instanceKey {
+	if ref.Kind == networkingv1alpha1.ClusterPiHoleInstanceKind {
+		return instanceKey{kind: ref.Kind, name: ref.Name}
+	}
+
+	return instanceKey{kind: networkingv1alpha1.PiHoleInstanceKind, namespace: namespace, name: ref.Name}
+}
+
+func instancesOverlap(a []instanceKey, b []instanceKey) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func typesConflict(a []networkingv1alpha1.DNSRecordType, b []networkingv1alpha1.DNSRecordType) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if pihole.TypesConflict(x, y) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
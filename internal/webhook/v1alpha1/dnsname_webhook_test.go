@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+)
+
+var _ = Describe("DNSName Webhook", func() {
+	ctx := context.Background()
+
+	var validator DNSNameCustomValidator
+
+	ip := func(ip string) *networkingv1alpha1.IPAddressStr {
+		i := networkingv1alpha1.IPAddressStr(ip)
+		return &i
+	}
+
+	newDNSName := func(namespace, name string, recordType networkingv1alpha1.DNSRecordType, target string) *networkingv1alpha1.DNSName {
+		return &networkingv1alpha1.DNSName{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: types.UID(namespace + "/" + name)},
+			Spec: networkingv1alpha1.DNSNameSpec{
+				InstanceRef: &networkingv1alpha1.InstanceReference{Kind: networkingv1alpha1.ClusterPiHoleInstanceKind, Name: "pihole"},
+				Type:        recordType,
+				Domain:      "foo.com",
+				TargetIP:    ip(target),
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(networkingv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		existing := newDNSName("other", "existing", networkingv1alpha1.A, "192.168.178.1")
+
+		validator = DNSNameCustomValidator{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build(),
+		}
+	})
+
+	It("should admit a DNSName without conflicts", func() {
+		dnsName := newDNSName("default", "new", networkingv1alpha1.A, "192.168.178.2")
+		dnsName.Spec.Domain = "bar.com"
+
+		warnings, err := validator.ValidateCreate(ctx, dnsName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+	})
+
+	It("should deny a DNSName claiming a domain on the same Pi-hole", func() {
+		_, err := validator.ValidateCreate(ctx, newDNSName("default", "new", networkingv1alpha1.A, "192.168.178.2"))
+		Expect(err).To(MatchError(ContainSubstring("already claimed by DNSName other/existing")))
+	})
+
+	It("should admit records of another type for the same domain", func() {
+		_, err := validator.ValidateCreate(ctx, newDNSName("default", "new", networkingv1alpha1.AAAA, "2001:db8::1"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should admit a DNSName for the same domain on another Pi-hole", func() {
+		dnsName := newDNSName("default", "new", networkingv1alpha1.A, "192.168.178.2")
+		dnsName.Spec.InstanceRef.Name = "other"
+
+		_, err := validator.ValidateCreate(ctx, dnsName)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should warn about claims through selectors", func() {
+		dnsName := newDNSName("default", "new", networkingv1alpha1.A, "192.168.178.2")
+		dnsName.Spec.InstanceRef = nil
+		dnsName.Spec.InstanceSelector = &metav1.LabelSelector{}
+
+		warnings, err := validator.ValidateCreate(ctx, dnsName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(ConsistOf(ContainSubstring("older DNSName wins")))
+	})
+
+	It("should deny an invalid spec", func() {
+		_, err := validator.ValidateCreate(ctx, newDNSName("default", "new", networkingv1alpha1.AAAA, "192.168.178.2"))
+		Expect(err).To(MatchError(ContainSubstring("not valid for AAAA records")))
+	})
+
+	It("should only check conflicts on update when the domain or the instances change", func() {
+		dnsName := newDNSName("default", "new", networkingv1alpha1.A, "192.168.178.2")
+		dnsName.Spec.Domain = "bar.com"
+
+		updated := dnsName.DeepCopy()
+		updated.Spec.TargetIP = ip("192.168.178.3")
+
+		_, err := validator.ValidateUpdate(ctx, dnsName, updated)
+		Expect(err).NotTo(HaveOccurred())
+
+		updated.Spec.Domain = "foo.com"
+
+		_, err = validator.ValidateUpdate(ctx, dnsName, updated)
+		Expect(err).To(MatchError(ContainSubstring("already claimed")))
+	})
+
+	It("should check conflicts on update when the instance refs change", func() {
+		dnsName := newDNSName("default", "new", networkingv1alpha1.A, "192.168.178.2")
+		dnsName.Spec.InstanceRef.Name = "other"
+
+		updated := dnsName.DeepCopy()
+		updated.Spec.InstanceRef = nil
+		updated.Spec.InstanceRefs = []networkingv1alpha1.InstanceReference{
+			{Kind: networkingv1alpha1.ClusterPiHoleInstanceKind, Name: "other"},
+			{Kind: networkingv1alpha1.ClusterPiHoleInstanceKind, Name: "pihole"},
+		}
+
+		_, err := validator.ValidateUpdate(ctx, dnsName, updated)
+		Expect(err).To(MatchError(ContainSubstring("already claimed by DNSName other/existing")))
+
+		updated = dnsName.DeepCopy()
+		updated.Spec.InstanceRef.Name = "pihole"
+
+		_, err = validator.ValidateUpdate(ctx, dnsName, updated)
+		Expect(err).To(MatchError(ContainSubstring("already claimed by DNSName other/existing")))
+	})
+})
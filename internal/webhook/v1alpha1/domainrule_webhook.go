@@ -0,0 +1,133 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+	"github.com/domnikl/pihole-operator/internal/pihole"
+)
+
+// log is for logging in this package.
+var domainrulelog = logf.Log.WithName("domainrule-resource")
+
+// SetupDomainRuleWebhookWithManager registers the webhook for DomainRule in the manager.
+func SetupDomainRuleWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&networkingv1alpha1.DomainRule{}).
+		WithValidator(&DomainRuleCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-networking-liebler-dev-v1alpha1-domainrule,mutating=false,failurePolicy=fail,sideEffects=None,groups=networking.liebler.dev,resources=domainrules,verbs=create;update,versions=v1alpha1,name=vdomainrule-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// DomainRuleCustomValidator rejects DomainRules whose domain is invalid, e.g. a
+// regular expression FTL can't compile, and DomainRules that duplicate an existing
+// one on the same Pi-hole, as both would manage the same entry.
+type DomainRuleCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &DomainRuleCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type DomainRule.
+func (v *DomainRuleCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	rule, ok := obj.(*networkingv1alpha1.DomainRule)
+	if !ok {
+		return nil, fmt.Errorf("expected a DomainRule object but got %T", obj)
+	}
+	domainrulelog.Info("Validation for DomainRule upon creation", "name", rule.GetName())
+
+	return nil, v.validate(ctx, rule)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type DomainRule.
+func (v *DomainRuleCustomValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	rule, ok := newObj.(*networkingv1alpha1.DomainRule)
+	if !ok {
+		return nil, fmt.Errorf("expected a DomainRule object for the newObj but got %T", newObj)
+	}
+	domainrulelog.Info("Validation for DomainRule upon update", "name", rule.GetName())
+
+	return nil, v.validate(ctx, rule)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type DomainRule.
+func (v *DomainRuleCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *DomainRuleCustomValidator) validate(ctx context.Context, rule *networkingv1alpha1.DomainRule) error {
+	domainPath := field.NewPath("spec", "domain")
+
+	kind := pihole.DomainKindExact
+	if rule.Spec.Match == networkingv1alpha1.DomainRuleMatchRegex {
+		kind = pihole.DomainKindRegex
+	}
+
+	if err := pihole.ValidateDomain(rule.Spec.Domain, kind); err != nil {
+		return invalidDomainRule(rule, field.Invalid(domainPath, rule.Spec.Domain, err.Error()))
+	}
+
+	rules := &networkingv1alpha1.DomainRuleList{}
+	if err := v.Client.List(ctx, rules); err != nil {
+		return err
+	}
+
+	ref := explicitInstanceRef(rule.Namespace, rule.Spec.InstanceRef)
+	for i := range rules.Items {
+		other := &rules.Items[i]
+
+		if other.UID == rule.UID || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if other.Spec.Domain != rule.Spec.Domain || other.Spec.Kind != rule.Spec.Kind || matchOf(other) != matchOf(rule) {
+			continue
+		}
+
+		if explicitInstanceRef(other.Namespace, other.Spec.InstanceRef) == ref {
+			return invalidDomainRule(rule, field.Duplicate(domainPath,
+				fmt.Sprintf("%s is already managed by DomainRule %s/%s on the same Pi-hole", rule.Spec.Domain, other.Namespace, other.Name)))
+		}
+	}
+
+	return nil
+}
+
+// matchOf returns the match of a rule, it defaults to Exact
+func matchOf(rule *networkingv1alpha1.DomainRule) networkingv1alpha1.DomainRuleMatch {
+	if rule.Spec.Match == "" {
+		return networkingv1alpha1.DomainRuleMatchExact
+	}
+
+	return rule.Spec.Match
+}
+
+func invalidDomainRule(rule *networkingv1alpha1.DomainRule, err *field.Error) error {
+	return apierrors.NewInvalid(networkingv1alpha1.GroupVersion.WithKind("DomainRule").GroupKind(), rule.Name, field.ErrorList{err})
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	networkingv1alpha1 "github.com/domnikl/pihole-operator/api/v1alpha1"
+)
+
+var _ = Describe("DomainRule Webhook", func() {
+	ctx := context.Background()
+
+	var validator DomainRuleCustomValidator
+
+	newDomainRule := func(namespace, name string, match networkingv1alpha1.DomainRuleMatch, domain string) *networkingv1alpha1.DomainRule {
+		return &networkingv1alpha1.DomainRule{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: types.UID(namespace + "/" + name)},
+			Spec: networkingv1alpha1.DomainRuleSpec{
+				InstanceRef: networkingv1alpha1.InstanceReference{Name: "pihole"},
+				Kind:        networkingv1alpha1.DomainRuleDeny,
+				Match:       match,
+				Domain:      domain,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(networkingv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		existing := newDomainRule("default", "existing", networkingv1alpha1.DomainRuleMatchRegex, `(\.|^)ads\.com$`)
+
+		validator = DomainRuleCustomValidator{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build(),
+		}
+	})
+
+	DescribeTable("validating the domain",
+		func(match networkingv1alpha1.DomainRuleMatch, domain string, message string) {
+			_, err := validator.ValidateCreate(ctx, newDomainRule("default", "new", match, domain))
+			if message == "" {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(message)))
+			}
+		},
+		Entry("exact domain", networkingv1alpha1.DomainRuleMatchExact, "tracker.com", ""),
+		Entry("exact domain without a match", networkingv1alpha1.DomainRuleMatch(""), "tracker.com", ""),
+		Entry("invalid exact domain", networkingv1alpha1.DomainRuleMatchExact, "*.tracker.com", "not a valid domain"),
+		Entry("regex", networkingv1alpha1.DomainRuleMatchRegex, `^ad[0-9]+\.tracker\.com$;querytype=A`, ""),
+		Entry("broken regex", networkingv1alpha1.DomainRuleMatchRegex, `(tracker\.com`, "missing closing )"),
+		Entry("regex with an unknown option", networkingv1alpha1.DomainRuleMatchRegex, `tracker;block`, "unknown option"),
+	)
+
+	It("should deny a rule duplicating another one on the same Pi-hole", func() {
+		_, err := validator.ValidateCreate(ctx, newDomainRule("default", "new", networkingv1alpha1.DomainRuleMatchRegex, `(\.|^)ads\.com$`))
+		Expect(err).To(MatchError(ContainSubstring("already managed by DomainRule default/existing")))
+	})
+
+	It("should admit the same domain with another kind", func() {
+		rule := newDomainRule("default", "new", networkingv1alpha1.DomainRuleMatchRegex, `(\.|^)ads\.com$`)
+		rule.Spec.Kind = networkingv1alpha1.DomainRuleAllow
+
+		_, err := validator.ValidateCreate(ctx, rule)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should admit the same domain on another Pi-hole", func() {
+		_, err := validator.ValidateCreate(ctx, newDomainRule("other", "new", networkingv1alpha1.DomainRuleMatchRegex, `(\.|^)ads\.com$`))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should admit updates of the rule itself", func() {
+		rule := newDomainRule("default", "existing", networkingv1alpha1.DomainRuleMatchRegex, `(\.|^)ads\.com$`)
+		updated := rule.DeepCopy()
+		updated.Spec.Comment = "ads"
+
+		_, err := validator.ValidateUpdate(ctx, rule, updated)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
@@ -118,5 +118,43 @@ var _ = Describe("controller", Ordered, func() {
 			EventuallyWithOffset(1, verifyControllerUp, time.Minute, time.Second).Should(Succeed())
 
 		})
+
+		It("should sync a DNSName to a Pi-hole", func() {
+			var err error
+
+			// simulatorimage stores the name of the image of the Pi-hole API simulator
+			var simulatorimage = "example.com/pihole-simulator:v0.0.1"
+
+			By("building the Pi-hole API simulator image")
+			cmd := exec.Command("make", "docker-build-simulator", fmt.Sprintf("SIMULATOR_IMG=%s", simulatorimage))
+			_, err = utils.Run(cmd)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+			By("loading the Pi-hole API simulator image on Kind")
+			err = utils.LoadImageToKindClusterWithName(simulatorimage)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+			By("deploying the Pi-hole API simulator and a DNSName")
+			cmd = exec.Command("kubectl", "apply", "-n", namespace, "-f", "test/e2e/testdata/pihole-simulator.yaml")
+			_, err = utils.Run(cmd)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+			By("validating that the DNSName has been synced")
+			verifyDNSNameSynced := func() error {
+				cmd := exec.Command("kubectl", "get", "dnsname", "e2e",
+					"-o", "jsonpath={.status.instances[0].synced}",
+					"-n", namespace,
+				)
+				synced, err := utils.Run(cmd)
+				if err != nil {
+					return err
+				}
+				if string(synced) != "true" {
+					return fmt.Errorf("DNSName is not synced: %s", synced)
+				}
+				return nil
+			}
+			EventuallyWithOffset(1, verifyDNSNameSynced, 2*time.Minute, time.Second).Should(Succeed())
+		})
 	})
 })